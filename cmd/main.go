@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -14,6 +16,9 @@ func main() {
 	config, err := app.GetConfig(config.ServerConfig{
 		DatabaseDriverName: "sqlite",
 		LoggerLvl:          "info",
+		ReminderInterval:   30 * time.Second,
+		WebhookRetryBase:   5 * time.Second,
+		StreamHeartbeat:    15 * time.Second,
 	})
 	if err != nil {
 		log.Fatalf("%+v", err)
@@ -24,6 +29,14 @@ func main() {
 		log.Fatalf("%+v", err)
 	}
 
+	if flag.NArg() > 0 {
+		err = app.RunCommand(&config, logger, flag.Args())
+		if err != nil {
+			logger.Fatal(err.Error(), zap.String("event", "run command"))
+		}
+		return
+	}
+
 	err = app.Run(&config, logger)
 	if err != nil {
 		logger.Fatal(err.Error(), zap.String("event", "start server"))
@@ -1,37 +1,271 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 
 	"github.com/Stern-Ritter/go_task_manager/internal/config"
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+	"github.com/Stern-Ritter/go_task_manager/internal/jobs"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/notify"
 	"github.com/Stern-Ritter/go_task_manager/internal/service"
 	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
 
 	_ "modernc.org/sqlite"
 )
 
+const (
+	shutdownTimeout     = 10 * time.Second
+	webhookPollInterval = time.Second
+	outboxPollInterval  = 5 * time.Second
+)
+
 func Run(config *config.ServerConfig, logger *zap.Logger) error {
+	if config.ReminderInterval <= 0 {
+		return fmt.Errorf("reminder interval must be positive, got %s", config.ReminderInterval)
+	}
+	if config.StreamHeartbeat <= 0 {
+		return fmt.Errorf("stream heartbeat must be positive, got %s", config.StreamHeartbeat)
+	}
+
+	location, weekStart, err := calendarSettings(config)
+	if err != nil {
+		logger.Fatal(err.Error(), zap.String("event", "parse calendar settings"))
+		return fmt.Errorf("error while parse calendar settings: %w", err)
+	}
+	model.Location = location
+
+	db, appPath, err := openDatabase(config, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	authService := service.NewAuthService(config.RootPassword, logger)
+	taskStore := storage.NewTaskStore(db)
+	timeEntryStore := storage.NewTimeEntryStore(db)
+	customFieldStore := storage.NewCustomFieldStore(db)
+	templateStore := storage.NewTemplateStore(db)
+	reminderStore := storage.NewReminderStore(db)
+	notificationChannelStore := storage.NewNotificationChannelStore(db)
+	webhookStore := storage.NewWebhookStore(db)
+	ruleStore := storage.NewRuleStore(db)
+	calendarFeedStore := storage.NewCalendarFeedStore(db)
+	calDAVResourceStore := storage.NewCalDAVResourceStore(db)
+	bus := events.NewBus(logger)
+	outbox := events.NewOutbox(taskStore, bus, logger)
+	taskService := service.NewTaskService(taskStore, customFieldStore, outbox, location, logger)
+	webhookService := service.NewWebhookService(webhookStore, config.WebhookRetryBase, logger)
+	bus.Subscribe(webhookService.HandleEvent)
+	ruleService := service.NewRuleService(ruleStore, taskStore, taskService, logger)
+	bus.Subscribe(ruleService.HandleEvent)
+	streamService := service.NewStreamService(outbox, config.StreamHeartbeat)
+	bus.Subscribe(streamService.HandleEvent)
+	server := service.NewServer(authService, taskService, config, logger)
+	server.WebhookService = webhookService
+	server.RuleService = ruleService
+	server.StreamService = streamService
+	server.CalendarFeedService = service.NewCalendarFeedService(calendarFeedStore, taskStore, location, logger)
+	server.ImportService = service.NewImportService(taskService, logger)
+	server.ExportService = service.NewExportService(customFieldStore, logger)
+	server.CalDAVService = service.NewCalDAVService(taskService, calDAVResourceStore, logger)
+	server.BackupService = service.NewBackupService(storage.NewBackupStore(db), taskStore, outbox, location,
+		logger)
+	server.TimeService = service.NewTimeService(timeEntryStore, taskStore, logger)
+	server.CustomFieldService = service.NewCustomFieldService(customFieldStore, logger)
+	server.TemplateService = service.NewTemplateService(templateStore, taskService, logger)
+	configChannels, err := notify.ChannelsFromConfig(config)
+	if err != nil {
+		return err
+	}
+	notificationService := service.NewNotificationService(notificationChannelStore, configChannels, logger)
+	server.NotificationService = notificationService
+	reminderService := service.NewReminderService(reminderStore, notificationService, location, logger)
+	server.ReminderService = reminderService
+	digestMailer, err := notify.DigestMailerFromConfig(config)
+	if err != nil {
+		return err
+	}
+	digestService, err := service.NewDigestService(taskStore, filepath.Join(appPath, "/resources/templates"),
+		digestMailer, location, logger)
+	if err != nil {
+		logger.Fatal(err.Error(), zap.String("event", "load digest templates"))
+		return fmt.Errorf("error while load digest templates: %w", err)
+	}
+	server.DigestService = digestService
+	agendaService, err := service.NewAgendaService(taskStore, filepath.Join(appPath, "/resources/templates"),
+		location, weekStart, logger)
+	if err != nil {
+		logger.Fatal(err.Error(), zap.String("event", "load agenda templates"))
+		return fmt.Errorf("error while load agenda templates: %w", err)
+	}
+	server.AgendaService = agendaService
+	server.StatsService = service.NewStatsService(taskStore, location, weekStart, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		outbox.Run(ctx, outboxPollInterval)
+	}()
+
+	if len(strings.TrimSpace(config.RolloverTime)) != 0 {
+		at, err := jobs.ParseTimeOfDay(config.RolloverTime)
+		if err != nil {
+			logger.Fatal(err.Error(), zap.String("event", "parse rollover time"))
+			return fmt.Errorf("error while parse rollover time: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobs.RunDaily(ctx, at, location, func(now time.Time) {
+				rolled, err := taskService.RolloverTasks(now)
+				if err != nil {
+					logger.Error("Error rolling over overdue tasks", zap.Error(err))
+					return
+				}
+				logger.Info("rolled over overdue tasks", zap.Int("count", rolled))
+			})
+		}()
+	}
+
+	if len(strings.TrimSpace(config.DigestTime)) != 0 {
+		at, err := jobs.ParseTimeOfDay(config.DigestTime)
+		if err != nil {
+			logger.Fatal(err.Error(), zap.String("event", "parse digest time"))
+			return fmt.Errorf("error while parse digest time: %w", err)
+		}
+		if digestMailer == nil {
+			logger.Fatal("digest requires TODO_SMTP_HOST and recipients", zap.String("event", "configure digest"))
+			return fmt.Errorf("digest requires TODO_SMTP_HOST and recipients")
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobs.RunDaily(ctx, at, location, func(now time.Time) {
+				err := digestService.SendDigest(ctx, now)
+				if err != nil {
+					logger.Error("Error sending digest", zap.Error(err))
+					return
+				}
+				logger.Info("digest sent")
+			})
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		jobs.RunEvery(ctx, config.ReminderInterval, func(now time.Time) {
+			_, err := reminderService.DeliverDue(ctx, now)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("Error delivering reminders", zap.Error(err))
+			}
+		})
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		jobs.RunEvery(ctx, webhookPollInterval, func(now time.Time) {
+			_, err := webhookService.DeliverPending(ctx, now)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("Error delivering webhooks", zap.Error(err))
+			}
+		})
+	}()
+
+	url := strings.Join([]string{"", strconv.Itoa(config.Port)}, ":")
+	r := addRoutes(server, appPath)
+	httpServer := &http.Server{Addr: url, Handler: r}
+
+	go func() {
+		<-ctx.Done()
+		streamService.Close()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Error shutting down server", zap.Error(err))
+		}
+	}()
+
+	server.Logger.Info("starting server", zap.String("url", url))
+	err = httpServer.ListenAndServe()
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		stop()
+		server.Logger.Error(err.Error(), zap.String("event", "start server"))
+		return fmt.Errorf("error while start server: %w", err)
+	}
+
+	server.Logger.Info("server stopped")
+	return nil
+}
+
+// calendarSettings returns the time zone of TODO_TIMEZONE, the local one by
+// default, and the first day of the week of TODO_WEEK_START, Monday by
+// default.
+func calendarSettings(config *config.ServerConfig) (*time.Location, time.Weekday, error) {
+	location := time.Local
+	if name := strings.TrimSpace(config.Timezone); len(name) != 0 {
+		var err error
+		location, err = time.LoadLocation(name)
+		if err != nil {
+			return nil, time.Monday, err
+		}
+	}
+
+	weekStart := time.Monday
+	if value := strings.TrimSpace(config.WeekStart); len(value) != 0 {
+		var err error
+		weekStart, err = utils.ParseWeekday(value)
+		if err != nil {
+			return nil, time.Monday, err
+		}
+	}
+	return location, weekStart, nil
+}
+
+// openDatabase opens the database, creating and migrating its schema as
+// needed, and returns it with the application path.
+func openDatabase(config *config.ServerConfig, logger *zap.Logger) (*sql.DB, string, error) {
 	appPath, err := os.Getwd()
 	if err != nil {
 		logger.Fatal(err.Error(), zap.String("event", "get absolute path for current process"))
-		return fmt.Errorf("error while get absolute path for current process: %w", err)
+		return nil, "", fmt.Errorf("error while get absolute path for current process: %w", err)
 	}
 
-	db, err := sql.Open(config.DatabaseDriverName, config.DatabaseFile)
+	// Background jobs write concurrently with requests, so wait for locks
+	// instead of failing with SQLITE_BUSY.
+	db, err := sql.Open(config.DatabaseDriverName, config.DatabaseFile+"?_pragma=busy_timeout(5000)&_txlock=immediate")
 	if err != nil {
 		logger.Fatal(err.Error(), zap.String("event", "open database connection"))
-		return fmt.Errorf("error while open database connection: %w", err)
+		return nil, "", fmt.Errorf("error while open database connection: %w", err)
 	}
-	defer db.Close()
 
 	databaseNotExistsErr := isDatabaseExists(appPath, config.DatabaseFile)
 	if databaseNotExistsErr != nil {
@@ -40,27 +274,17 @@ func Run(config *config.ServerConfig, logger *zap.Logger) error {
 		err = initDatabase(db, filepath.Join(appPath, "/resources/database/init.sql"))
 		if err != nil {
 			logger.Fatal(err.Error(), zap.String("event", "init database schema"))
-			return fmt.Errorf("error while init database schema: %w", err)
+			return nil, "", fmt.Errorf("error while init database schema: %w", err)
 		}
 	}
 
-	authService := service.NewAuthService(config.RootPassword, logger)
-	taskStore := storage.NewTaskStore(db)
-	taskService := service.NewTaskService(taskStore, logger)
-	server := service.NewServer(authService, taskService, config, logger)
-
-	url := strings.Join([]string{"", strconv.Itoa(config.Port)}, ":")
-	r := addRoutes(server, appPath)
-
-	server.Logger.Info("starting server", zap.String("url", url))
-	err = http.ListenAndServe(url, r)
-
+	err = migrateDatabase(db, filepath.Join(appPath, "/resources/database/migrations"))
 	if err != nil {
-		server.Logger.Fatal(err.Error(), zap.String("event", "start server"))
-		return fmt.Errorf("error while start server: %w", err)
+		logger.Fatal(err.Error(), zap.String("event", "migrate database schema"))
+		return nil, "", fmt.Errorf("error while migrate database schema: %w", err)
 	}
 
-	return nil
+	return db, appPath, nil
 }
 
 func addRoutes(s *service.Server, appPath string) *chi.Mux {
@@ -68,6 +292,20 @@ func addRoutes(s *service.Server, appPath string) *chi.Mux {
 	filesDir := http.Dir(filepath.Join(appPath, "web"))
 	fileServer(r, "/", filesDir)
 
+	chi.RegisterMethod("PROPFIND")
+	chi.RegisterMethod("REPORT")
+	r.Handle("/.well-known/caldav", http.RedirectHandler(service.CalDAVRootPath, http.StatusMovedPermanently))
+	r.Route(strings.TrimSuffix(service.CalDAVRootPath, "/"), func(r chi.Router) {
+		r.Use(s.CalDAVAuthMiddleware)
+		r.Options("/*", s.CalDAVOptionsHandler)
+		r.MethodFunc("PROPFIND", "/*", s.CalDAVPropfindHandler)
+		r.MethodFunc("REPORT", "/*", s.CalDAVReportHandler)
+		r.Get("/*", s.GetCalDAVObjectHandler)
+		r.Head("/*", s.GetCalDAVObjectHandler)
+		r.Put("/*", s.PutCalDAVObjectHandler)
+		r.Delete("/*", s.DeleteCalDAVObjectHandler)
+	})
+
 	r.Route("/api", func(r chi.Router) {
 		r.Post("/signin", s.SignInHandler)
 		r.Get("/nextdate", s.GetNextDateHandler)
@@ -75,6 +313,10 @@ func addRoutes(s *service.Server, appPath string) *chi.Mux {
 		r.Route("/tasks", func(r chi.Router) {
 			r.Use(s.AuthMiddleware)
 			r.Get("/", s.GetTasksHandler)
+			r.Post("/bulk", s.BulkTasksHandler)
+			r.Get("/overdue", s.GetOverdueTasksHandler)
+			r.Post("/rollover", s.RolloverTasksHandler)
+			r.Get("/postponed", s.GetPostponedTasksHandler)
 		})
 
 		r.Route("/task", func(r chi.Router) {
@@ -84,6 +326,148 @@ func addRoutes(s *service.Server, appPath string) *chi.Mux {
 			r.Put("/", s.UpdateTaskHandler)
 			r.Delete("/", s.DeleteTaskHandler)
 			r.Post("/done", s.CompleteTaskHandler)
+			r.Post("/postpone", s.PostponeTaskHandler)
+		})
+
+		r.Route("/fields", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetFieldsHandler)
+		})
+
+		r.Route("/field", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetFieldHandler)
+			r.Post("/", s.AddFieldHandler)
+			r.Put("/", s.UpdateFieldHandler)
+			r.Delete("/", s.DeleteFieldHandler)
+		})
+
+		r.Route("/templates", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetTemplatesHandler)
+		})
+
+		r.Route("/template", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetTemplateHandler)
+			r.Post("/", s.AddTemplateHandler)
+			r.Put("/", s.UpdateTemplateHandler)
+			r.Delete("/", s.DeleteTemplateHandler)
+			r.Post("/instantiate", s.InstantiateTemplateHandler)
+		})
+
+		r.Route("/reminders", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetRemindersHandler)
+		})
+
+		r.Route("/reminder", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Post("/", s.AddReminderHandler)
+			r.Delete("/", s.DeleteReminderHandler)
+		})
+
+		r.Route("/notify", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/channels", s.GetNotificationChannelsHandler)
+			r.Get("/channel", s.GetNotificationChannelHandler)
+			r.Post("/channel", s.AddNotificationChannelHandler)
+			r.Put("/channel", s.UpdateNotificationChannelHandler)
+			r.Delete("/channel", s.DeleteNotificationChannelHandler)
+			r.Post("/test", s.TestNotificationHandler)
+		})
+
+		r.Route("/digest", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/preview", s.PreviewDigestHandler)
+		})
+
+		r.Route("/agenda", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetAgendaHandler)
+			r.Get("/export", s.ExportAgendaHandler)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetWebhooksHandler)
+		})
+
+		r.Route("/webhook", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetWebhookHandler)
+			r.Post("/", s.AddWebhookHandler)
+			r.Put("/", s.UpdateWebhookHandler)
+			r.Delete("/", s.DeleteWebhookHandler)
+			r.Get("/deliveries", s.GetWebhookDeliveriesHandler)
+		})
+
+		r.Route("/stream", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.StreamTasksHandler)
+		})
+
+		r.Get("/calendar.ics", s.GetCalendarHandler)
+
+		r.Route("/calendar", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetCalendarViewHandler)
+			r.Get("/feeds", s.GetCalendarFeedsHandler)
+			r.Get("/feed", s.GetCalendarFeedHandler)
+			r.Post("/feed", s.AddCalendarFeedHandler)
+			r.Put("/feed", s.UpdateCalendarFeedHandler)
+			r.Delete("/feed", s.DeleteCalendarFeedHandler)
+			r.Post("/feed/token", s.RotateCalendarFeedTokenHandler)
+		})
+
+		r.Route("/import", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Post("/ics", s.ImportICSHandler)
+			r.Post("/csv", s.ImportCSVHandler)
+			r.Post("/todotxt", s.ImportTodoTxtHandler)
+			r.Post("/todoist", s.ImportTodoistHandler)
+			r.Post("/google-tasks", s.ImportGoogleTasksHandler)
+			r.Post("/taskwarrior", s.ImportTaskwarriorHandler)
+		})
+
+		r.Route("/backup", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetBackupHandler)
+			r.Post("/restore", s.RestoreBackupHandler)
+		})
+
+		r.Route("/live", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.LiveTasksHandler)
+		})
+
+		r.Route("/rules", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetRulesHandler)
+		})
+
+		r.Route("/rule", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetRuleHandler)
+			r.Post("/", s.AddRuleHandler)
+			r.Put("/", s.UpdateRuleHandler)
+			r.Delete("/", s.DeleteRuleHandler)
+			r.Post("/dry-run", s.DryRunRuleHandler)
+			r.Get("/executions", s.GetRuleExecutionsHandler)
+		})
+
+		r.Route("/time", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetRunningTimerHandler)
+			r.Post("/start", s.StartTimerHandler)
+			r.Post("/stop", s.StopTimerHandler)
+			r.Get("/entries", s.GetTimeEntriesHandler)
+			r.Get("/report", s.GetTimeReportHandler)
+		})
+
+		r.Route("/stats", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Get("/", s.GetStatsHandler)
 		})
 	})
 	return r
@@ -123,3 +507,65 @@ func initDatabase(db *sql.DB, initScriptPath string) error {
 	}
 	return nil
 }
+
+func migrateDatabase(db *sql.DB, migrationsPath string) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR (128) PRIMARY KEY
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error while creating migrations table: %w", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(migrationsPath, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("error while reading migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := filepath.Base(file)
+
+		var applied int
+		err = db.QueryRow(`SELECT count(version) FROM schema_migrations WHERE version = :version`,
+			sql.Named("version", version)).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("error while checking migration %s: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		err = applyMigration(db, file, version)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, file string, version string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error while reading migration %s: %w", version, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(string(data))
+	if err != nil {
+		return fmt.Errorf("error while execution migration %s: %w", version, err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version) VALUES (:version)`, sql.Named("version", version))
+	if err != nil {
+		return fmt.Errorf("error while saving migration %s: %w", version, err)
+	}
+
+	return tx.Commit()
+}
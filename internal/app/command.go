@@ -0,0 +1,150 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/config"
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/service"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+// RunCommand runs a command given on the command line instead of the server,
+// e.g. "import-ics -dry-run tasks.ics". Task events of the command are
+// published when the server starts next.
+func RunCommand(config *config.ServerConfig, logger *zap.Logger, args []string) error {
+	location, _, err := calendarSettings(config)
+	if err != nil {
+		return err
+	}
+	model.Location = location
+
+	db, _, err := openDatabase(config, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	taskStore := storage.NewTaskStore(db)
+	outbox := events.NewOutbox(taskStore, events.NewBus(logger), logger)
+	taskService := service.NewTaskService(taskStore, storage.NewCustomFieldStore(db), outbox, location, logger)
+	importService := service.NewImportService(taskService, logger)
+	backupService := service.NewBackupService(storage.NewBackupStore(db), taskStore, outbox, location, logger)
+
+	switch args[0] {
+	case "import-ics":
+		flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+		dryRun := flags.Bool("dry-run", false, "report what would be imported without saving")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: %s [-dry-run] FILE", args[0])
+		}
+
+		return importFile(flags.Arg(0), os.Stdout, func(r io.Reader) (model.ImportReport, error) {
+			return importService.ImportICS(r, *dryRun, time.Now())
+		})
+	case "backup":
+		flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+		output := flags.String("o", "-", "file to write the backup to")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		b, err := backupService.Backup(time.Now())
+		if err != nil {
+			return err
+		}
+		return writeJSON(*output, model.BackupToBackupDto(b))
+	case "restore":
+		flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+		mode := flags.String("mode", model.RestoreModeMerge, "merge or replace")
+		dryRun := flags.Bool("dry-run", false, "report what would be restored without saving")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: %s [-mode merge|replace] [-dry-run] FILE", args[0])
+		}
+
+		data, err := readFile(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		b, err := model.ParseBackup(data)
+		if err != nil {
+			return err
+		}
+		report, err := backupService.Restore(b, *mode, *dryRun, time.Now())
+		if err != nil {
+			return err
+		}
+		return writeJSON("-", model.RestoreReportToRestoreReportDto(report))
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+// importFile imports the file, "-" meaning standard input, and writes the
+// report as JSON.
+func importFile(path string, out io.Writer, importFn func(r io.Reader) (model.ImportReport, error)) error {
+	in := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	report, err := importFn(in)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(model.ImportReportToImportReportDto(report))
+}
+
+// readFile reads the file, "-" meaning standard input.
+func readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeJSON writes the value as indented JSON to the file, "-" meaning
+// standard output.
+func writeJSON(path string, v any) error {
+	if path == "-" {
+		return encodeJSON(os.Stdout, v)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encodeJSON(file, v); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func encodeJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -1,9 +1,29 @@
 package config
 
+import "time"
+
 type ServerConfig struct {
 	Port               int `env:"TODO_PORT"`
 	DatabaseDriverName string
 	DatabaseFile       string `env:"TODO_DBFILE"`
 	RootPassword       string `env:"TODO_PASSWORD"`
 	LoggerLvl          string
+	RolloverTime       string        `env:"TODO_ROLLOVER_TIME"`
+	ReminderInterval   time.Duration `env:"TODO_REMINDER_INTERVAL"`
+	WebhookURL         string        `env:"TODO_WEBHOOK_URL"`
+	SMTPHost           string        `env:"TODO_SMTP_HOST"`
+	SMTPPort           int           `env:"TODO_SMTP_PORT"`
+	SMTPUsername       string        `env:"TODO_SMTP_USERNAME"`
+	SMTPPassword       string        `env:"TODO_SMTP_PASSWORD"`
+	SMTPFrom           string        `env:"TODO_SMTP_FROM"`
+	SMTPTo             string        `env:"TODO_SMTP_TO"`
+	PushURL            string        `env:"TODO_PUSH_URL"`
+	PushToken          string        `env:"TODO_PUSH_TOKEN"`
+	PushFormat         string        `env:"TODO_PUSH_FORMAT"`
+	DigestTime         string        `env:"TODO_DIGEST_TIME"`
+	DigestTo           string        `env:"TODO_DIGEST_TO"`
+	WebhookRetryBase   time.Duration `env:"TODO_WEBHOOK_RETRY_BASE"`
+	StreamHeartbeat    time.Duration `env:"TODO_STREAM_HEARTBEAT"`
+	Timezone           string        `env:"TODO_TIMEZONE"`
+	WeekStart          string        `env:"TODO_WEEK_START"`
 }
@@ -65,7 +65,7 @@ func (e TaskNotExists) Unwrap() error {
 }
 
 func NewTaskNotExists(message string, err error) error {
-	return InvalidTitleFormat{message, err}
+	return TaskNotExists{message, err}
 }
 
 type AuthenticationError struct {
@@ -84,3 +84,360 @@ func (e AuthenticationError) Unwrap() error {
 func NewAuthenticationError(message string, err error) error {
 	return AuthenticationError{message, err}
 }
+
+type InvalidEstimateFormat struct {
+	message string
+	err     error
+}
+
+func (e InvalidEstimateFormat) Error() string {
+	return e.message
+}
+
+func (e InvalidEstimateFormat) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidEstimateFormat(message string, err error) error {
+	return InvalidEstimateFormat{message, err}
+}
+
+type InvalidTagFormat struct {
+	message string
+	err     error
+}
+
+func (e InvalidTagFormat) Error() string {
+	return e.message
+}
+
+func (e InvalidTagFormat) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidTagFormat(message string, err error) error {
+	return InvalidTagFormat{message, err}
+}
+
+type TimerAlreadyRunning struct {
+	message string
+	err     error
+}
+
+func (e TimerAlreadyRunning) Error() string {
+	return e.message
+}
+
+func (e TimerAlreadyRunning) Unwrap() error {
+	return e.err
+}
+
+func NewTimerAlreadyRunning(message string, err error) error {
+	return TimerAlreadyRunning{message, err}
+}
+
+type TimerNotRunning struct {
+	message string
+	err     error
+}
+
+func (e TimerNotRunning) Error() string {
+	return e.message
+}
+
+func (e TimerNotRunning) Unwrap() error {
+	return e.err
+}
+
+func NewTimerNotRunning(message string, err error) error {
+	return TimerNotRunning{message, err}
+}
+
+type InvalidCustomField struct {
+	message string
+	err     error
+}
+
+func (e InvalidCustomField) Error() string {
+	return e.message
+}
+
+func (e InvalidCustomField) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidCustomField(message string, err error) error {
+	return InvalidCustomField{message, err}
+}
+
+type CustomFieldNotExists struct {
+	message string
+	err     error
+}
+
+func (e CustomFieldNotExists) Error() string {
+	return e.message
+}
+
+func (e CustomFieldNotExists) Unwrap() error {
+	return e.err
+}
+
+func NewCustomFieldNotExists(message string, err error) error {
+	return CustomFieldNotExists{message, err}
+}
+
+type InvalidTemplateFormat struct {
+	message string
+	err     error
+}
+
+func (e InvalidTemplateFormat) Error() string {
+	return e.message
+}
+
+func (e InvalidTemplateFormat) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidTemplateFormat(message string, err error) error {
+	return InvalidTemplateFormat{message, err}
+}
+
+type InvalidBulkOperation struct {
+	message string
+	err     error
+}
+
+func (e InvalidBulkOperation) Error() string {
+	return e.message
+}
+
+func (e InvalidBulkOperation) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidBulkOperation(message string, err error) error {
+	return InvalidBulkOperation{message, err}
+}
+
+type ReminderNotExists struct {
+	message string
+	err     error
+}
+
+func (e ReminderNotExists) Error() string {
+	return e.message
+}
+
+func (e ReminderNotExists) Unwrap() error {
+	return e.err
+}
+
+func NewReminderNotExists(message string, err error) error {
+	return ReminderNotExists{message, err}
+}
+
+type InvalidReminderFormat struct {
+	message string
+	err     error
+}
+
+func (e InvalidReminderFormat) Error() string {
+	return e.message
+}
+
+func (e InvalidReminderFormat) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidReminderFormat(message string, err error) error {
+	return InvalidReminderFormat{message, err}
+}
+
+type InvalidNotificationChannel struct {
+	message string
+	err     error
+}
+
+func (e InvalidNotificationChannel) Error() string {
+	return e.message
+}
+
+func (e InvalidNotificationChannel) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidNotificationChannel(message string, err error) error {
+	return InvalidNotificationChannel{message, err}
+}
+
+type InvalidWebhookSubscription struct {
+	message string
+	err     error
+}
+
+func (e InvalidWebhookSubscription) Error() string {
+	return e.message
+}
+
+func (e InvalidWebhookSubscription) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidWebhookSubscription(message string, err error) error {
+	return InvalidWebhookSubscription{message, err}
+}
+
+type InvalidPriorityFormat struct {
+	message string
+	err     error
+}
+
+func (e InvalidPriorityFormat) Error() string {
+	return e.message
+}
+
+func (e InvalidPriorityFormat) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidPriorityFormat(message string, err error) error {
+	return InvalidPriorityFormat{message, err}
+}
+
+type InvalidRuleFormat struct {
+	message string
+	err     error
+}
+
+func (e InvalidRuleFormat) Error() string {
+	return e.message
+}
+
+func (e InvalidRuleFormat) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidRuleFormat(message string, err error) error {
+	return InvalidRuleFormat{message, err}
+}
+
+type InvalidLiveMessage struct {
+	message string
+	err     error
+}
+
+func (e InvalidLiveMessage) Error() string {
+	return e.message
+}
+
+func (e InvalidLiveMessage) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidLiveMessage(message string, err error) error {
+	return InvalidLiveMessage{message, err}
+}
+
+type InvalidCalendarFeed struct {
+	message string
+	err     error
+}
+
+func (e InvalidCalendarFeed) Error() string {
+	return e.message
+}
+
+func (e InvalidCalendarFeed) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidCalendarFeed(message string, err error) error {
+	return InvalidCalendarFeed{message, err}
+}
+
+type InvalidImportFile struct {
+	message string
+	err     error
+}
+
+func (e InvalidImportFile) Error() string {
+	return e.message
+}
+
+func (e InvalidImportFile) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidImportFile(message string, err error) error {
+	return InvalidImportFile{message, err}
+}
+
+type InvalidCalDAVResource struct {
+	message string
+	err     error
+}
+
+func (e InvalidCalDAVResource) Error() string {
+	return e.message
+}
+
+func (e InvalidCalDAVResource) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidCalDAVResource(message string, err error) error {
+	return InvalidCalDAVResource{message, err}
+}
+
+type CalDAVPreconditionFailed struct {
+	message string
+	err     error
+}
+
+func (e CalDAVPreconditionFailed) Error() string {
+	return e.message
+}
+
+func (e CalDAVPreconditionFailed) Unwrap() error {
+	return e.err
+}
+
+func NewCalDAVPreconditionFailed(message string, err error) error {
+	return CalDAVPreconditionFailed{message, err}
+}
+
+type InvalidBackup struct {
+	message string
+	err     error
+}
+
+func (e InvalidBackup) Error() string {
+	return e.message
+}
+
+func (e InvalidBackup) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidBackup(message string, err error) error {
+	return InvalidBackup{message, err}
+}
+
+type InvalidStatsRange struct {
+	message string
+	err     error
+}
+
+func (e InvalidStatsRange) Error() string {
+	return e.message
+}
+
+func (e InvalidStatsRange) Unwrap() error {
+	return e.err
+}
+
+func NewInvalidStatsRange(message string, err error) error {
+	return InvalidStatsRange{message, err}
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type Handler func(ctx context.Context, e Event)
+
+type subscription struct {
+	names   map[string]bool
+	handler Handler
+}
+
+// Bus delivers events to subscribers synchronously, in the order they
+// subscribed. A panicking subscriber is logged and doesn't affect others.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+	logger        *zap.Logger
+}
+
+func NewBus(logger *zap.Logger) *Bus {
+	return &Bus{logger: logger}
+}
+
+// Subscribe registers the handler for events with the given names or, if no
+// names are given, for all events.
+func (b *Bus) Subscribe(handler Handler, names ...string) {
+	sub := subscription{handler: handler}
+	if len(names) > 0 {
+		sub.names = make(map[string]bool, len(names))
+		for _, name := range names {
+			sub.names[name] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, sub)
+}
+
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		if sub.names != nil && !sub.names[e.Name()] {
+			continue
+		}
+		b.call(ctx, sub.handler, e)
+	}
+}
+
+func (b *Bus) call(ctx context.Context, handler Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("Event subscriber panicked", zap.String("event", e.Name()),
+				zap.Error(fmt.Errorf("%v", r)))
+		}
+	}()
+	handler(ctx, e)
+}
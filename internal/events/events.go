@@ -0,0 +1,98 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// Event is a task lifecycle event. Subscribers switch on the concrete type,
+// e.g. TaskRescheduled, to get event specific data.
+type Event interface {
+	Name() string
+	Base() TaskEvent
+}
+
+// TaskEvent holds the data common to all events. ID is assigned by the
+// outbox and grows monotonically; Task is the task state after the change or,
+// for deleted tasks, before it. Source names the subsystem that made the
+// change, e.g. rules; it is empty for changes made through the API.
+// Postponed is the number of times the task had been postponed when the
+// event occurred.
+type TaskEvent struct {
+	ID         int64
+	OccurredAt time.Time
+	Source     string
+	Task       model.Task
+	Postponed  int
+}
+
+func (e TaskEvent) Base() TaskEvent {
+	return e
+}
+
+type TaskCreated struct{ TaskEvent }
+
+type TaskUpdated struct{ TaskEvent }
+
+type TaskCompleted struct{ TaskEvent }
+
+type TaskRescheduled struct {
+	TaskEvent
+	From time.Time
+}
+
+type TaskDeleted struct{ TaskEvent }
+
+func (TaskCreated) Name() string     { return model.EventTaskCreated }
+func (TaskUpdated) Name() string     { return model.EventTaskUpdated }
+func (TaskCompleted) Name() string   { return model.EventTaskCompleted }
+func (TaskRescheduled) Name() string { return model.EventTaskRescheduled }
+func (TaskDeleted) Name() string     { return model.EventTaskDeleted }
+
+// taskRecord has no UnmarshalJSON, so stored tasks are decoded as is.
+type taskRecord model.Task
+
+type payload struct {
+	Task      taskRecord `json:"task"`
+	Source    string     `json:"source,omitempty"`
+	Postponed int        `json:"postponed,omitempty"`
+	From      time.Time  `json:"from"`
+}
+
+func encode(e Event) (string, error) {
+	base := e.Base()
+	p := payload{Task: taskRecord(base.Task), Source: base.Source, Postponed: base.Postponed}
+	if rescheduled, ok := e.(TaskRescheduled); ok {
+		p.From = rescheduled.From
+	}
+
+	data, err := json.Marshal(p)
+	return string(data), err
+}
+
+func decode(r model.OutboxRecord) (Event, error) {
+	p := payload{}
+	if err := json.Unmarshal([]byte(r.Payload), &p); err != nil {
+		return nil, err
+	}
+
+	base := TaskEvent{ID: r.ID, OccurredAt: r.CreatedAt, Source: p.Source, Task: model.Task(p.Task),
+		Postponed: p.Postponed}
+	switch r.Name {
+	case model.EventTaskCreated:
+		return TaskCreated{base}, nil
+	case model.EventTaskUpdated:
+		return TaskUpdated{base}, nil
+	case model.EventTaskCompleted:
+		return TaskCompleted{base}, nil
+	case model.EventTaskRescheduled:
+		return TaskRescheduled{TaskEvent: base, From: p.From}, nil
+	case model.EventTaskDeleted:
+		return TaskDeleted{base}, nil
+	default:
+		return nil, fmt.Errorf("unknown event: %s", r.Name)
+	}
+}
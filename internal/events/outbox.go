@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+const (
+	outboxBatchSize = 100
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// Outbox records events in the transaction of the change that caused them
+// and publishes them to the bus after the transaction commits, so
+// subscribers see only committed changes. Events are delivered at least
+// once: an event is published again if the server stops before it is marked
+// as dispatched.
+type Outbox struct {
+	store  storage.TaskStore
+	bus    *Bus
+	wake   chan struct{}
+	logger *zap.Logger
+}
+
+func NewOutbox(store storage.TaskStore, bus *Bus, logger *zap.Logger) *Outbox {
+	return &Outbox{store: store, bus: bus, wake: make(chan struct{}, 1), logger: logger}
+}
+
+// Record stores the event using the store, which should be bound to the
+// transaction of the change.
+func (o *Outbox) Record(store storage.TaskStore, e Event) error {
+	p, err := encode(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.AddOutboxRecord(e.Name(), p, time.Now())
+	return err
+}
+
+// Wake makes Run dispatch recorded events without waiting for the next poll.
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches recorded events when woken up and every interval until ctx
+// is done.
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for {
+		err := o.Dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			o.logger.Error("Error dispatching events", zap.Error(err))
+		}
+
+		if now := time.Now(); now.Sub(lastCleanup) > time.Hour {
+			err = o.store.DeleteOutboxRecordsBefore(now.Add(-outboxRetention))
+			if err != nil {
+				o.logger.Error("Error cleaning up events", zap.Error(err))
+			}
+			lastCleanup = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch publishes all recorded events in order.
+func (o *Outbox) Dispatch(ctx context.Context) error {
+	for {
+		records, err := o.store.GetPendingOutboxRecords(outboxBatchSize)
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		for _, r := range records {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			e, err := decode(r)
+			if err != nil {
+				o.logger.Error("Error decoding event", zap.Int64("id", r.ID), zap.Error(err))
+			} else {
+				o.bus.Publish(ctx, e)
+			}
+
+			err = o.store.MarkOutboxRecordDispatched(r.ID, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Replay returns up to limit committed events with IDs greater than after, in
+// order. Some of them may still be on their way to subscribers, who should
+// skip events they have already seen by ID. Events are kept for a week.
+func (o *Outbox) Replay(after int64, limit int) ([]Event, error) {
+	records, err := o.store.GetOutboxRecordsAfter(after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Event, 0, len(records))
+	for _, r := range records {
+		e, err := decode(r)
+		if err != nil {
+			o.logger.Error("Error decoding event", zap.Int64("id", r.ID), zap.Error(err))
+			continue
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
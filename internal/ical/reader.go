@@ -0,0 +1,199 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const maxContentLine = 1 << 20
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block, e.g. VCALENDAR or VTODO.
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// Get returns the first property with the name.
+func (c *Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Text returns the unescaped value of the first TEXT property with the name.
+func (c *Component) Text(name string) string {
+	p, ok := c.Get(name)
+	if !ok {
+		return ""
+	}
+	return UnescapeText(p.Value)
+}
+
+// Parse reads an iCalendar stream and returns its VCALENDAR component.
+func Parse(r io.Reader) (*Component, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxContentLine)
+
+	var stack []*Component
+	var root *Component
+	var line string
+	lineNumber := 0
+
+	handle := func(line string) error {
+		p, err := parseProperty(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		switch p.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if c.Name != "VCALENDAR" {
+				return fmt.Errorf("line %d: expected BEGIN:VCALENDAR", lineNumber)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return fmt.Errorf("line %d: unexpected END:%s", lineNumber, p.Value)
+			}
+			if len(stack) == 1 {
+				root = stack[0]
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return fmt.Errorf("line %d: property outside of a component", lineNumber)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, p)
+		}
+		return nil
+	}
+
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		// A line starting with a space or a tab continues the previous one.
+		if len(text) > 0 && (text[0] == ' ' || text[0] == '\t') {
+			line += text[1:]
+			continue
+		}
+
+		if len(line) > 0 {
+			if err := handle(line); err != nil {
+				return nil, err
+			}
+			if root != nil {
+				return root, nil
+			}
+		}
+		line = text
+		lineNumber++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(line) > 0 {
+		if err := handle(line); err != nil {
+			return nil, err
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no complete VCALENDAR found")
+	}
+	return root, nil
+}
+
+// parseProperty splits a content line "NAME;PARAM=value:VALUE". Colons and
+// semicolons inside quoted parameter values don't count.
+func parseProperty(line string) (Property, error) {
+	p := Property{}
+	inQuotes := false
+	start := 0
+	var parts []string
+	valueStart := -1
+	for idx := 0; idx < len(line) && valueStart < 0; idx++ {
+		switch line[idx] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes {
+				parts = append(parts, line[start:idx])
+				start = idx + 1
+			}
+		case ':':
+			if !inQuotes {
+				parts = append(parts, line[start:idx])
+				valueStart = idx + 1
+			}
+		}
+	}
+	if valueStart < 0 || len(parts[0]) == 0 {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+
+	p.Name = strings.ToUpper(parts[0])
+	p.Value = line[valueStart:]
+	for _, param := range parts[1:] {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return p, fmt.Errorf("invalid parameter %q", param)
+		}
+		if p.Params == nil {
+			p.Params = make(map[string]string)
+		}
+		p.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return p, nil
+}
+
+// UnescapeText unescapes a TEXT value.
+func UnescapeText(value string) string {
+	return textUnescaper.Replace(value)
+}
+
+// ParseDate returns the calendar date of a DATE or DATE-TIME property. UTC
+// times are converted to the local time zone, times with a known TZID are
+// read in that zone and floating times are taken as is.
+func ParseDate(p Property) (time.Time, error) {
+	value := p.Value
+	var t time.Time
+	var err error
+	switch {
+	case len(value) == 8:
+		t, err = time.Parse("20060102", value)
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+		t = t.Local()
+	default:
+		loc := time.Local
+		if tzid, ok := p.Params["TZID"]; ok {
+			if l, err := time.LoadLocation(tzid); err == nil {
+				loc = l
+			}
+		}
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	}
+	if err != nil {
+		return t, fmt.Errorf("invalid %s value %q", p.Name, value)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
@@ -0,0 +1,237 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+var weekDays = []string{"", "MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// RepeatToRRule converts a task repeat rule, e.g. "w 1,3", to an RRULE value,
+// e.g. "FREQ=WEEKLY;BYDAY=MO,WE". It reports false for rules that have no
+// RRULE equivalent or no dates at all.
+func RepeatToRRule(repeat string) (string, bool) {
+	parts := strings.Fields(repeat)
+	if len(parts) == 0 {
+		return "", false
+	}
+
+	switch {
+	case parts[0] == "y" && len(parts) == 1:
+		return "FREQ=YEARLY", true
+	case parts[0] == "d" && len(parts) == 2:
+		days, err := strconv.Atoi(parts[1])
+		if err != nil || days < 1 {
+			return "", false
+		}
+		if days == 1 {
+			return "FREQ=DAILY", true
+		}
+		return "FREQ=DAILY;INTERVAL=" + parts[1], true
+	case parts[0] == "w" && len(parts) == 2:
+		days, ok := parseList(parts[1], 1, 7)
+		if !ok {
+			return "", false
+		}
+		byDay := make([]string, len(days))
+		for idx, day := range days {
+			byDay[idx] = weekDays[day]
+		}
+		return "FREQ=WEEKLY;BYDAY=" + strings.Join(byDay, ","), true
+	case parts[0] == "m" && (len(parts) == 2 || len(parts) == 3):
+		days, ok := parseList(parts[1], -2, 31)
+		if !ok || containsZero(days) || !utils.RepeatHasDates(repeat) {
+			return "", false
+		}
+		rule := "FREQ=MONTHLY;BYMONTHDAY=" + joinInts(days)
+		if len(parts) == 3 {
+			months, ok := parseList(parts[2], 1, 12)
+			if !ok {
+				return "", false
+			}
+			rule += ";BYMONTH=" + joinInts(months)
+		}
+		return rule, true
+	}
+	return "", false
+}
+
+func parseList(value string, min int, max int) ([]int, bool) {
+	items := strings.Split(value, ",")
+	res := make([]int, len(items))
+	for idx, item := range items {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < min || n > max {
+			return nil, false
+		}
+		res[idx] = n
+	}
+	return res, true
+}
+
+func joinInts(values []int) string {
+	items := make([]string, len(values))
+	for idx, v := range values {
+		items[idx] = strconv.Itoa(v)
+	}
+	return strings.Join(items, ",")
+}
+
+// maxRepeatDays is the largest day interval of a task repeat rule.
+const maxRepeatDays = 400
+
+// RRuleToRepeat converts an RRULE value to a task repeat rule. start is the
+// date of the first occurrence, used when the rule takes the day from it.
+// The error explains which part of the rule has no task repeat equivalent.
+func RRuleToRepeat(rrule string, start time.Time) (string, error) {
+	parts := make(map[string]string)
+	for _, part := range strings.Split(strings.ToUpper(rrule), ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid rule part %q", part)
+		}
+		parts[name] = value
+	}
+
+	interval := 1
+	if value, ok := parts["INTERVAL"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("invalid INTERVAL %q", value)
+		}
+		interval = n
+	}
+
+	for name := range parts {
+		switch name {
+		case "FREQ", "INTERVAL", "BYDAY", "BYMONTHDAY", "BYMONTH", "WKST":
+		case "COUNT", "UNTIL":
+			return "", fmt.Errorf("%s is not supported: tasks repeat without an end", name)
+		default:
+			return "", fmt.Errorf("%s is not supported", name)
+		}
+	}
+
+	freq := parts["FREQ"]
+	byDay, hasByDay := parts["BYDAY"]
+	byMonthDay, hasByMonthDay := parts["BYMONTHDAY"]
+	byMonth, hasByMonth := parts["BYMONTH"]
+	switch freq {
+	case "DAILY":
+		if hasByDay || hasByMonthDay || hasByMonth {
+			return "", fmt.Errorf("DAILY rules with BY parts are not supported")
+		}
+		if interval > maxRepeatDays {
+			return "", fmt.Errorf("INTERVAL over %d days is not supported", maxRepeatDays)
+		}
+		return "d " + strconv.Itoa(interval), nil
+	case "WEEKLY":
+		if hasByMonthDay || hasByMonth {
+			return "", fmt.Errorf("WEEKLY rules with BYMONTHDAY or BYMONTH are not supported")
+		}
+		if interval > 1 {
+			// Every n weeks on the start day is every 7n days.
+			if hasByDay && byDay != weekDays[weekDayNumber(start)] {
+				return "", fmt.Errorf("INTERVAL with BYDAY is not supported")
+			}
+			if 7*interval > maxRepeatDays {
+				return "", fmt.Errorf("INTERVAL over %d days is not supported", maxRepeatDays)
+			}
+			return "d " + strconv.Itoa(7*interval), nil
+		}
+		if !hasByDay {
+			return "w " + strconv.Itoa(weekDayNumber(start)), nil
+		}
+		days := make([]string, 0, 7)
+		for _, day := range strings.Split(byDay, ",") {
+			n := weekDayIndex(day)
+			if n == 0 {
+				return "", fmt.Errorf("BYDAY %q is not supported", day)
+			}
+			days = append(days, strconv.Itoa(n))
+		}
+		return "w " + strings.Join(days, ","), nil
+	case "MONTHLY":
+		if hasByDay {
+			return "", fmt.Errorf("MONTHLY rules with BYDAY are not supported")
+		}
+		if interval > 1 {
+			return "", fmt.Errorf("MONTHLY rules with INTERVAL are not supported")
+		}
+		days := strconv.Itoa(start.Day())
+		if hasByMonthDay {
+			values, ok := parseList(byMonthDay, -2, 31)
+			if !ok || containsZero(values) {
+				return "", fmt.Errorf("BYMONTHDAY %q is not supported", byMonthDay)
+			}
+			days = joinInts(values)
+		}
+		repeat := "m " + days
+		if hasByMonth {
+			months, ok := parseList(byMonth, 1, 12)
+			if !ok {
+				return "", fmt.Errorf("invalid BYMONTH %q", byMonth)
+			}
+			repeat += " " + joinInts(months)
+		}
+		if !utils.RepeatHasDates(repeat) {
+			return "", fmt.Errorf("BYMONTHDAY %q is not supported: no such day in the listed months", days)
+		}
+		return repeat, nil
+	case "YEARLY":
+		if interval > 1 {
+			return "", fmt.Errorf("YEARLY rules with INTERVAL are not supported")
+		}
+		if hasByDay {
+			return "", fmt.Errorf("YEARLY rules with BYDAY are not supported")
+		}
+		if !hasByMonthDay && !hasByMonth {
+			return "y", nil
+		}
+		// A fixed day of given months repeats like a monthly rule.
+		return RRuleToRepeat(strings.Replace(strings.ToUpper(rrule), "FREQ=YEARLY", "FREQ=MONTHLY", 1)+monthDefaults(parts, start), start)
+	default:
+		return "", fmt.Errorf("FREQ %q is not supported", freq)
+	}
+}
+
+// monthDefaults completes a yearly rule with the month or the day of the
+// start date it implies.
+func monthDefaults(parts map[string]string, start time.Time) string {
+	if _, ok := parts["BYMONTH"]; !ok {
+		return ";BYMONTH=" + strconv.Itoa(int(start.Month()))
+	}
+	if _, ok := parts["BYMONTHDAY"]; !ok {
+		return ";BYMONTHDAY=" + strconv.Itoa(start.Day())
+	}
+	return ""
+}
+
+func weekDayNumber(date time.Time) int {
+	if date.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(date.Weekday())
+}
+
+func weekDayIndex(day string) int {
+	for idx, name := range weekDays {
+		if idx > 0 && name == day {
+			return idx
+		}
+	}
+	return 0
+}
+
+func containsZero(values []int) bool {
+	for _, v := range values {
+		if v == 0 {
+			return true
+		}
+	}
+	return false
+}
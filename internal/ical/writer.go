@@ -0,0 +1,94 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineLength is the line length limit of RFC 5545 in octets, without the
+// line break.
+const maxLineLength = 75
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Writer writes iCalendar content lines, escaping text values and folding
+// long lines. The first write error is kept and returned by Flush.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Begin(component string) {
+	w.Property("BEGIN", component)
+}
+
+func (w *Writer) End(component string) {
+	w.Property("END", component)
+}
+
+// Property writes the value as is; name may include parameters, e.g.
+// "DTSTART;VALUE=DATE".
+func (w *Writer) Property(name string, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text writes an escaped TEXT value.
+func (w *Writer) Text(name string, value string) {
+	w.Property(name, EscapeText(value))
+}
+
+// Date writes a DATE value.
+func (w *Writer) Date(name string, date time.Time) {
+	w.Property(name+";VALUE=DATE", date.Format("20060102"))
+}
+
+// Timestamp writes a DATE-TIME value in UTC.
+func (w *Writer) Timestamp(name string, t time.Time) {
+	w.Property(name, t.UTC().Format("20060102T150405Z"))
+}
+
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// line writes the content line folded into lines of at most maxLineLength
+// octets, never splitting a UTF-8 sequence.
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space.
+		limit = maxLineLength - 1
+	}
+	w.write(s + "\r\n")
+}
+
+func (w *Writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+// EscapeText escapes a TEXT value.
+func EscapeText(value string) string {
+	return textEscaper.Replace(value)
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+}
+
+func ParseTimeOfDay(value string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return TimeOfDay{}, fmt.Errorf("invalid time of day %q, expected HH:MM: %w", value, err)
+	}
+	return TimeOfDay{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+// Next returns the first moment at this time of day strictly after now in the
+// location of now.
+func (t TimeOfDay) Next(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour, t.Minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, t.Hour, t.Minute, 0, 0, now.Location())
+	}
+	return next
+}
+
+// RunDaily calls fn every day at the given time of day in location until ctx
+// is done.
+func RunDaily(ctx context.Context, at TimeOfDay, location *time.Location, fn func(now time.Time)) {
+	for {
+		timer := time.NewTimer(time.Until(at.Next(time.Now().In(location))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			fn(now)
+		}
+	}
+}
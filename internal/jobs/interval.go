@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// RunEvery calls fn with the given interval until ctx is done. The first call
+// happens immediately.
+func RunEvery(ctx context.Context, interval time.Duration, fn func(now time.Time)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	fn(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			fn(now)
+		}
+	}
+}
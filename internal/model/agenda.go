@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// Agenda is the printable plan of a date range: a day for every date, with
+// the tasks of the day and the projected occurrences of recurring tasks.
+type Agenda struct {
+	From time.Time
+	To   time.Time
+	Days []AgendaDay
+}
+
+type AgendaDay struct {
+	Date    time.Time
+	Entries []AgendaEntry
+}
+
+// AgendaEntry is a task on a day. Projected entries are later occurrences of
+// a recurring task, which the task reaches once its current date is done.
+type AgendaEntry struct {
+	Task       Task
+	Projected  bool
+	RepeatText string
+}
+
+// Count returns the number of entries in the agenda.
+func (a Agenda) Count() int {
+	count := 0
+	for _, day := range a.Days {
+		count += len(day.Entries)
+	}
+	return count
+}
+
+// Names of agenda buckets, in the order they are listed.
+const (
+	AgendaBucketOverdue  = "overdue"
+	AgendaBucketToday    = "today"
+	AgendaBucketTomorrow = "tomorrow"
+	AgendaBucketThisWeek = "this_week"
+	AgendaBucketNextWeek = "next_week"
+	AgendaBucketLater    = "later"
+)
+
+// AgendaBuckets are the tasks grouped by when they are due, as seen on
+// Date. This week is the rest of the week after tomorrow, so it is empty at
+// the end of a week.
+type AgendaBuckets struct {
+	Date      time.Time
+	Location  *time.Location
+	WeekStart time.Weekday
+	Buckets   []AgendaBucket
+}
+
+type AgendaBucket struct {
+	Name  string
+	Tasks []Task
+}
+
+// NewAgendaBuckets puts every task into its bucket. Tasks keep their order
+// within a bucket.
+func NewAgendaBuckets(today time.Time, location *time.Location, weekStart time.Weekday,
+	tasks []Task) AgendaBuckets {
+	names := []string{AgendaBucketOverdue, AgendaBucketToday, AgendaBucketTomorrow,
+		AgendaBucketThisWeek, AgendaBucketNextWeek, AgendaBucketLater}
+	buckets := make([]AgendaBucket, len(names))
+	for idx, name := range names {
+		buckets[idx] = AgendaBucket{Name: name, Tasks: []Task{}}
+	}
+
+	weekEnd := today.AddDate(0, 0, 6-(int(today.Weekday())-int(weekStart)+7)%7)
+	for _, t := range tasks {
+		idx := 5
+		switch {
+		case t.Date.Before(today):
+			idx = 0
+		case t.Date.Equal(today):
+			idx = 1
+		case t.Date.Equal(today.AddDate(0, 0, 1)):
+			idx = 2
+		case !t.Date.After(weekEnd):
+			idx = 3
+		case !t.Date.After(weekEnd.AddDate(0, 0, 7)):
+			idx = 4
+		}
+		buckets[idx].Tasks = append(buckets[idx].Tasks, t)
+	}
+
+	return AgendaBuckets{Date: today, Location: location, WeekStart: weekStart, Buckets: buckets}
+}
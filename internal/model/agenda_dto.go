@@ -0,0 +1,38 @@
+package model
+
+type AgendaBucketDto struct {
+	Name  string    `json:"name"`
+	Count int       `json:"count"`
+	Tasks []TaskDto `json:"tasks"`
+}
+
+// AgendaDto lists the buckets in order; Counts repeats their sizes by name,
+// for badges.
+type AgendaDto struct {
+	Date      string            `json:"date"`
+	Timezone  string            `json:"timezone"`
+	WeekStart string            `json:"week_start"`
+	Total     int               `json:"total"`
+	Counts    map[string]int    `json:"counts"`
+	Buckets   []AgendaBucketDto `json:"buckets"`
+}
+
+func AgendaBucketsToAgendaDto(agenda AgendaBuckets) AgendaDto {
+	dto := AgendaDto{
+		Date:      agenda.Date.Format("20060102"),
+		Timezone:  agenda.Location.String(),
+		WeekStart: agenda.WeekStart.String(),
+		Counts:    make(map[string]int, len(agenda.Buckets)),
+		Buckets:   make([]AgendaBucketDto, len(agenda.Buckets)),
+	}
+	for idx, bucket := range agenda.Buckets {
+		dto.Buckets[idx] = AgendaBucketDto{
+			Name:  bucket.Name,
+			Count: len(bucket.Tasks),
+			Tasks: TasksToTasksDto(bucket.Tasks),
+		}
+		dto.Counts[bucket.Name] = len(bucket.Tasks)
+		dto.Total += len(bucket.Tasks)
+	}
+	return dto
+}
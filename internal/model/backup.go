@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// BackupVersion is the version of the backup schema. It changes whenever a
+// backup can no longer be read the same way, and a backup of another version
+// is refused rather than partially restored.
+const BackupVersion = 1
+
+const (
+	// RestoreModeReplace deletes all data and restores the backup with its ids.
+	RestoreModeReplace = "replace"
+	// RestoreModeMerge adds the backup to the data, giving new ids to tasks,
+	// rules and time entries and updating the references to them.
+	RestoreModeMerge = "merge"
+)
+
+// Backup is everything the user has entered: tasks with their tags and field
+// values, the settings that shape them and their history. Delivery logs,
+// notification channels, webhooks and calendar feeds are not included, since
+// they hold credentials that shouldn't leave the server.
+type Backup struct {
+	Version         int
+	CreatedAt       time.Time
+	Tasks           []Task
+	Fields          []CustomField
+	Templates       []Template
+	Rules           []Rule
+	Reminders       []Reminder
+	TimeEntries     []TimeEntry
+	History         []TaskEvent
+	CalDAVResources []CalDAVResource
+}
+
+// RestoreReport counts what was restored. Warnings list entries that were
+// left out, e.g. a template whose name is already taken in merge mode.
+type RestoreReport struct {
+	Mode            string
+	DryRun          bool
+	Tasks           int
+	Fields          int
+	Templates       int
+	Rules           int
+	Reminders       int
+	TimeEntries     int
+	History         int
+	CalDAVResources int
+	Warnings        []string
+}
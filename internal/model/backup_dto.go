@@ -0,0 +1,420 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+// BackupDto is the versioned backup file. Fields, templates and rules have
+// the same form as in the API; tasks keep their stored date, which the API
+// would move forward for overdue tasks.
+type BackupDto struct {
+	Version         int                       `json:"version"`
+	CreatedAt       string                    `json:"created_at"`
+	Tasks           []BackupTaskDto           `json:"tasks"`
+	Fields          []CustomFieldDto          `json:"fields"`
+	Templates       []TemplateDto             `json:"templates"`
+	Rules           []RuleDto                 `json:"rules"`
+	Reminders       []BackupReminderDto       `json:"reminders"`
+	TimeEntries     []BackupTimeEntryDto      `json:"time_entries"`
+	History         []BackupHistoryDto        `json:"history"`
+	CalDAVResources []BackupCalDAVResourceDto `json:"caldav_resources"`
+}
+
+type BackupTaskDto struct {
+	ID       string            `json:"id"`
+	Date     string            `json:"date"`
+	Title    string            `json:"title"`
+	Comment  string            `json:"comment"`
+	Repeat   string            `json:"repeat"`
+	Estimate int               `json:"estimate"`
+	Project  string            `json:"project"`
+	Priority int               `json:"priority"`
+	Tags     []string          `json:"tags"`
+	Fields   map[string]string `json:"fields"`
+}
+
+type BackupReminderDto struct {
+	ID     string `json:"id"`
+	TaskID string `json:"task_id"`
+	Time   string `json:"time"`
+	Before int    `json:"before"`
+}
+
+type BackupTimeEntryDto struct {
+	ID        string   `json:"id"`
+	TaskID    string   `json:"task_id,omitempty"`
+	TaskTitle string   `json:"task_title"`
+	Project   string   `json:"project"`
+	Tags      []string `json:"tags"`
+	Start     string   `json:"start"`
+	Stop      string   `json:"stop,omitempty"`
+	Note      string   `json:"note"`
+}
+
+type BackupHistoryDto struct {
+	TaskID    string `json:"task_id,omitempty"`
+	TaskTitle string `json:"task_title"`
+	Event     string `json:"event"`
+	FromDate  string `json:"from_date,omitempty"`
+	ToDate    string `json:"to_date,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type BackupCalDAVResourceDto struct {
+	TaskID string `json:"task_id"`
+	Name   string `json:"name"`
+	UID    string `json:"uid"`
+}
+
+type RestoreReportDto struct {
+	Mode            string   `json:"mode"`
+	DryRun          bool     `json:"dry_run"`
+	Tasks           int      `json:"tasks"`
+	Fields          int      `json:"fields"`
+	Templates       int      `json:"templates"`
+	Rules           int      `json:"rules"`
+	Reminders       int      `json:"reminders"`
+	TimeEntries     int      `json:"time_entries"`
+	History         int      `json:"history"`
+	CalDAVResources int      `json:"caldav_resources"`
+	Warnings        []string `json:"warnings,omitempty"`
+}
+
+func BackupToBackupDto(b Backup) BackupDto {
+	dto := BackupDto{
+		Version:         b.Version,
+		CreatedAt:       b.CreatedAt.Format(time.RFC3339),
+		Tasks:           make([]BackupTaskDto, len(b.Tasks)),
+		Fields:          CustomFieldsToCustomFieldsDto(b.Fields),
+		Templates:       TemplatesToTemplatesDto(b.Templates),
+		Rules:           RulesToRulesDto(b.Rules),
+		Reminders:       make([]BackupReminderDto, len(b.Reminders)),
+		TimeEntries:     make([]BackupTimeEntryDto, len(b.TimeEntries)),
+		History:         make([]BackupHistoryDto, len(b.History)),
+		CalDAVResources: make([]BackupCalDAVResourceDto, len(b.CalDAVResources)),
+	}
+
+	for idx, t := range b.Tasks {
+		task := TaskToTaskDto(t)
+		dto.Tasks[idx] = BackupTaskDto{
+			ID:       task.ID,
+			Date:     task.Date,
+			Title:    task.Title,
+			Comment:  task.Comment,
+			Repeat:   task.Repeat,
+			Estimate: task.Estimate,
+			Project:  task.Project,
+			Priority: task.Priority,
+			Tags:     task.Tags,
+			Fields:   task.Fields,
+		}
+	}
+	for idx, r := range b.Reminders {
+		dto.Reminders[idx] = BackupReminderDto{
+			ID:     strconv.Itoa(r.ID),
+			TaskID: strconv.Itoa(r.TaskID),
+			Time:   r.Time,
+			Before: r.Before,
+		}
+	}
+	for idx, e := range b.TimeEntries {
+		dto.TimeEntries[idx] = BackupTimeEntryDto{
+			ID:        strconv.Itoa(e.ID),
+			TaskID:    formatBackupRef(e.TaskID),
+			TaskTitle: e.TaskTitle,
+			Project:   e.Project,
+			Tags:      e.Tags,
+			Start:     e.Start.Format(time.RFC3339),
+			Note:      e.Note,
+		}
+		if e.Stop != nil {
+			dto.TimeEntries[idx].Stop = e.Stop.Format(time.RFC3339)
+		}
+	}
+	for idx, e := range b.History {
+		dto.History[idx] = BackupHistoryDto{
+			TaskID:    formatBackupRef(e.TaskID),
+			TaskTitle: e.TaskTitle,
+			Event:     e.Event,
+			FromDate:  formatBackupDate(e.FromDate),
+			ToDate:    formatBackupDate(e.ToDate),
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	for idx, r := range b.CalDAVResources {
+		dto.CalDAVResources[idx] = BackupCalDAVResourceDto{
+			TaskID: strconv.Itoa(r.TaskID),
+			Name:   r.Name,
+			UID:    r.UID,
+		}
+	}
+	return dto
+}
+
+// ParseBackup decodes and validates a backup file. The version is checked
+// before anything else, so a backup of another version is never half read.
+func ParseBackup(data []byte) (Backup, error) {
+	var header struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Backup{}, errors.NewInvalidBackup("invalid backup file: "+err.Error(), err)
+	}
+	if header.Version == nil {
+		return Backup{}, errors.NewInvalidBackup("backup file has no version", nil)
+	}
+	if *header.Version != BackupVersion {
+		return Backup{}, errors.NewInvalidBackup(fmt.Sprintf("backup version %d is not supported, expected %d",
+			*header.Version, BackupVersion), nil)
+	}
+
+	dto := BackupDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return Backup{}, errors.NewInvalidBackup("invalid backup file: "+err.Error(), err)
+	}
+	return BackupDtoToBackup(dto)
+}
+
+// BackupDtoToBackup validates every entry by the rules of the API.
+func BackupDtoToBackup(dto BackupDto) (Backup, error) {
+	b := Backup{Version: dto.Version}
+	if len(dto.CreatedAt) > 0 {
+		createdAt, err := time.Parse(time.RFC3339, dto.CreatedAt)
+		if err != nil {
+			return b, errors.NewInvalidBackup("invalid backup creation time: "+dto.CreatedAt, err)
+		}
+		b.CreatedAt = createdAt
+	}
+
+	b.Tasks = make([]Task, len(dto.Tasks))
+	taskIDs := make(map[int]bool, len(dto.Tasks))
+	for idx, t := range dto.Tasks {
+		task, err := backupTask(t)
+		if err != nil {
+			return b, backupEntryError("task", idx, err)
+		}
+		if taskIDs[task.ID] {
+			return b, backupEntryError("task", idx, fmt.Errorf("duplicate task id %d", task.ID))
+		}
+		taskIDs[task.ID] = true
+		b.Tasks[idx] = task
+	}
+
+	b.Fields = make([]CustomField, len(dto.Fields))
+	for idx, f := range dto.Fields {
+		if err := decodeBackupEntry(f, &b.Fields[idx]); err != nil {
+			return b, backupEntryError("field", idx, err)
+		}
+	}
+
+	b.Templates = make([]Template, len(dto.Templates))
+	for idx, t := range dto.Templates {
+		if err := decodeBackupEntry(t, &b.Templates[idx]); err != nil {
+			return b, backupEntryError("template", idx, err)
+		}
+	}
+
+	b.Rules = make([]Rule, len(dto.Rules))
+	for idx, r := range dto.Rules {
+		if err := decodeBackupEntry(r, &b.Rules[idx]); err != nil {
+			return b, backupEntryError("rule", idx, err)
+		}
+	}
+
+	b.Reminders = make([]Reminder, len(dto.Reminders))
+	for idx, r := range dto.Reminders {
+		if err := decodeBackupEntry(r, &b.Reminders[idx]); err != nil {
+			return b, backupEntryError("reminder", idx, err)
+		}
+	}
+
+	b.TimeEntries = make([]TimeEntry, len(dto.TimeEntries))
+	for idx, e := range dto.TimeEntries {
+		entry, err := backupTimeEntry(e)
+		if err != nil {
+			return b, backupEntryError("time entry", idx, err)
+		}
+		b.TimeEntries[idx] = entry
+	}
+
+	b.History = make([]TaskEvent, len(dto.History))
+	for idx, e := range dto.History {
+		event, err := backupHistoryEvent(e)
+		if err != nil {
+			return b, backupEntryError("history event", idx, err)
+		}
+		b.History[idx] = event
+	}
+
+	b.CalDAVResources = make([]CalDAVResource, len(dto.CalDAVResources))
+	for idx, r := range dto.CalDAVResources {
+		taskID, err := strconv.Atoi(r.TaskID)
+		if err != nil {
+			return b, backupEntryError("CalDAV resource", idx, err)
+		}
+		if len(r.Name) == 0 || len(r.UID) == 0 {
+			return b, backupEntryError("CalDAV resource", idx, fmt.Errorf("name and uid are required"))
+		}
+		b.CalDAVResources[idx] = CalDAVResource{TaskID: taskID, Name: r.Name, UID: r.UID}
+	}
+	return b, nil
+}
+
+// backupTask validates the task like Task.UnmarshalJSON does, but keeps the
+// date: a restored overdue task stays overdue.
+func backupTask(dto BackupTaskDto) (Task, error) {
+	id, err := strconv.Atoi(dto.ID)
+	if err != nil {
+		return Task{}, err
+	}
+	t := Task{
+		ID:      id,
+		Title:   strings.TrimSpace(dto.Title),
+		Comment: dto.Comment,
+		Repeat:  strings.TrimSpace(dto.Repeat),
+		Fields:  dto.Fields,
+	}
+	if len(t.Title) == 0 {
+		return t, errors.NewInvalidTitleFormat("task title is empty", nil)
+	}
+
+	t.Date, err = utils.ParseDate(dto.Date)
+	if err != nil {
+		return t, err
+	}
+	if len(t.Repeat) > 0 {
+		isRepeatValid, err := utils.ValidateRepeat(t.Repeat)
+		if err != nil || !isRepeatValid {
+			return t, errors.NewInvalidRepeatFormat("invalid task repeat format", err)
+		}
+		if !utils.RepeatHasDates(t.Repeat) {
+			return t, errors.NewInvalidRepeatFormat("task repeat rule has no dates", nil)
+		}
+	}
+
+	if dto.Estimate < 0 {
+		return t, errors.NewInvalidEstimateFormat("task estimate must not be negative", nil)
+	}
+	if dto.Priority < 0 || dto.Priority > MaxPriority {
+		return t, errors.NewInvalidPriorityFormat(fmt.Sprintf("task priority must be from 0 to %d", MaxPriority), nil)
+	}
+	estimate, project, priority := dto.Estimate, strings.TrimSpace(dto.Project), dto.Priority
+	t.Estimate, t.Project, t.Priority = &estimate, &project, &priority
+
+	t.Tags, err = NormalizeTags(dto.Tags)
+	return t, err
+}
+
+func backupTimeEntry(dto BackupTimeEntryDto) (TimeEntry, error) {
+	e := TimeEntry{TaskTitle: dto.TaskTitle, Project: dto.Project, Tags: dto.Tags, Note: dto.Note}
+	var err error
+	if e.ID, err = strconv.Atoi(dto.ID); err != nil {
+		return e, err
+	}
+	if e.TaskID, err = parseBackupRef(dto.TaskID); err != nil {
+		return e, err
+	}
+	if e.Tags == nil {
+		e.Tags = []string{}
+	}
+
+	if e.Start, err = time.Parse(time.RFC3339, dto.Start); err != nil {
+		return e, err
+	}
+	if len(dto.Stop) > 0 {
+		stop, err := time.Parse(time.RFC3339, dto.Stop)
+		if err != nil {
+			return e, err
+		}
+		if stop.Before(e.Start) {
+			return e, fmt.Errorf("time entry stops before it starts")
+		}
+		e.Stop = &stop
+	}
+	return e, nil
+}
+
+func backupHistoryEvent(dto BackupHistoryDto) (TaskEvent, error) {
+	e := TaskEvent{TaskTitle: dto.TaskTitle, Event: dto.Event}
+	var err error
+	if e.TaskID, err = parseBackupRef(dto.TaskID); err != nil {
+		return e, err
+	}
+	if len(e.Event) == 0 {
+		return e, fmt.Errorf("history event has no name")
+	}
+	if e.CreatedAt, err = time.Parse(time.RFC3339, dto.CreatedAt); err != nil {
+		return e, err
+	}
+	if len(dto.FromDate) > 0 {
+		if e.FromDate, err = utils.ParseDate(dto.FromDate); err != nil {
+			return e, err
+		}
+	}
+	if len(dto.ToDate) > 0 {
+		if e.ToDate, err = utils.ParseDate(dto.ToDate); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// decodeBackupEntry decodes the API form of an entry with the UnmarshalJSON
+// of its model, so that it is validated as an API request would be.
+func decodeBackupEntry(dto any, v any) error {
+	data, err := json.Marshal(dto)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func backupEntryError(kind string, idx int, err error) error {
+	return errors.NewInvalidBackup(fmt.Sprintf("invalid %s #%d in backup: %s", kind, idx+1, err), err)
+}
+
+// formatBackupRef returns the id of a task a record refers to; 0 means the
+// task has been deleted.
+func formatBackupRef(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+func parseBackupRef(value string) (int, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func formatBackupDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("20060102")
+}
+
+func RestoreReportToRestoreReportDto(r RestoreReport) RestoreReportDto {
+	return RestoreReportDto{
+		Mode:            r.Mode,
+		DryRun:          r.DryRun,
+		Tasks:           r.Tasks,
+		Fields:          r.Fields,
+		Templates:       r.Templates,
+		Rules:           r.Rules,
+		Reminders:       r.Reminders,
+		TimeEntries:     r.TimeEntries,
+		History:         r.History,
+		CalDAVResources: r.CalDAVResources,
+		Warnings:        r.Warnings,
+	}
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+)
+
+const (
+	BulkComplete = "complete"
+	BulkDelete   = "delete"
+	BulkMove     = "move"
+	BulkPostpone = "postpone"
+	BulkTag      = "tag"
+	BulkProject  = "project"
+
+	maxBulkTasks    = 1000
+	maxPostponeDays = 400
+)
+
+type BulkOperation struct {
+	IDs       []int
+	Operation string    `json:"operation"`
+	Date      time.Time `json:"-"`
+	Days      int       `json:"days"`
+	Tags      []string  `json:"tags"`
+	Project   string    `json:"project"`
+	// Atomic rolls back the whole operation if any of the tasks fails.
+	Atomic bool `json:"atomic"`
+}
+
+func (o *BulkOperation) UnmarshalJSON(data []byte) error {
+	type BulkOperationAlias BulkOperation
+
+	aliasOperation := &struct {
+		*BulkOperationAlias
+		IDs  []any  `json:"ids"`
+		Date string `json:"date"`
+	}{
+		BulkOperationAlias: (*BulkOperationAlias)(o),
+	}
+
+	if err := json.Unmarshal(data, aliasOperation); err != nil {
+		return err
+	}
+
+	if len(aliasOperation.IDs) == 0 || len(aliasOperation.IDs) > maxBulkTasks {
+		return errors.NewInvalidBulkOperation(fmt.Sprintf("bulk operation requires from 1 to %d task ids",
+			maxBulkTasks), nil)
+	}
+	o.IDs = make([]int, 0, len(aliasOperation.IDs))
+	seen := make(map[int]bool, len(aliasOperation.IDs))
+	for _, value := range aliasOperation.IDs {
+		id, err := parseBulkID(value)
+		if err != nil {
+			return err
+		}
+		if !seen[id] {
+			seen[id] = true
+			o.IDs = append(o.IDs, id)
+		}
+	}
+
+	switch o.Operation {
+	case BulkComplete, BulkDelete:
+	case BulkMove:
+		date, err := time.Parse("20060102", aliasOperation.Date)
+		if err != nil {
+			return errors.NewInvalidDateFormat("invalid bulk move date format", err)
+		}
+		o.Date = date
+	case BulkPostpone:
+		if o.Days < 1 || o.Days > maxPostponeDays {
+			return errors.NewInvalidBulkOperation(fmt.Sprintf("postpone days must be from 1 to %d",
+				maxPostponeDays), nil)
+		}
+	case BulkTag:
+		tags, err := NormalizeTags(o.Tags)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return errors.NewInvalidBulkOperation("bulk tag operation requires tags", nil)
+		}
+		o.Tags = tags
+	case BulkProject:
+		o.Project = strings.TrimSpace(o.Project)
+	default:
+		return errors.NewInvalidBulkOperation("unknown bulk operation: "+o.Operation, nil)
+	}
+
+	return nil
+}
+
+func parseBulkID(value any) (int, error) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return 0, errors.NewInvalidBulkOperation(fmt.Sprintf("invalid task id: %v", v), nil)
+		}
+		return int(v), nil
+	case string:
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, errors.NewInvalidBulkOperation("invalid task id: "+v, err)
+		}
+		return id, nil
+	default:
+		return 0, errors.NewInvalidBulkOperation(fmt.Sprintf("invalid task id: %v", value), nil)
+	}
+}
+
+type BulkResult struct {
+	ID  int
+	Err error
+}
+
+type BulkResults struct {
+	Results   []BulkResult
+	Committed bool
+}
@@ -0,0 +1,36 @@
+package model
+
+import "strconv"
+
+type BulkResultDto struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type BulkResultsDto struct {
+	Results   []BulkResultDto `json:"results"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Committed bool            `json:"committed"`
+}
+
+func BulkResultsToBulkResultsDto(results BulkResults) BulkResultsDto {
+	dto := BulkResultsDto{
+		Results:   make([]BulkResultDto, len(results.Results)),
+		Committed: results.Committed,
+	}
+	for idx, result := range results.Results {
+		dto.Results[idx] = BulkResultDto{
+			ID: strconv.Itoa(result.ID),
+			OK: result.Err == nil,
+		}
+		if result.Err != nil {
+			dto.Results[idx].Error = result.Err.Error()
+			dto.Failed++
+		} else {
+			dto.Succeeded++
+		}
+	}
+	return dto
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CalDAVResource binds a task to the resource name and UID chosen by the
+// CalDAV client that created it. Tasks without a binding are served as
+// "task-<id>.ics".
+type CalDAVResource struct {
+	TaskID int
+	Name   string
+	UID    string
+}
+
+// CalDAVObject is a task served as a calendar object resource.
+type CalDAVObject struct {
+	Name string
+	Task Task
+	Data []byte
+	ETag string
+}
+
+// DefaultCalDAVResourceName returns the resource name of a task created
+// outside CalDAV.
+func DefaultCalDAVResourceName(taskID int) string {
+	return "task-" + strconv.Itoa(taskID) + ".ics"
+}
+
+// ParseDefaultCalDAVResourceName returns the task ID of a resource name made
+// by DefaultCalDAVResourceName.
+func ParseDefaultCalDAVResourceName(name string) (int, bool) {
+	value, ok := strings.CutPrefix(name, "task-")
+	if !ok {
+		return 0, false
+	}
+	value, ok = strings.CutSuffix(value, ".ics")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(value)
+	return id, err == nil && id > 0 && strconv.Itoa(id) == value
+}
@@ -0,0 +1,44 @@
+package model
+
+import "encoding/xml"
+
+const (
+	DAVNamespace            = "DAV:"
+	CalDAVNamespace         = "urn:ietf:params:xml:ns:caldav"
+	CalendarServerNamespace = "http://calendarserver.org/ns/"
+)
+
+// CalDAVRequestDto is the body of PROPFIND and REPORT requests: XMLName
+// tells propfind from calendar-query and calendar-multiget.
+type CalDAVRequestDto struct {
+	XMLName  xml.Name
+	AllProp  *struct{}           `xml:"DAV: allprop"`
+	PropName *struct{}           `xml:"DAV: propname"`
+	Prop     *CalDAVPropNamesDto `xml:"DAV: prop"`
+	Hrefs    []string            `xml:"DAV: href"`
+	Filter   *CalDAVFilterDto    `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type CalDAVPropNamesDto struct {
+	Names []CalDAVPropNameDto `xml:",any"`
+}
+
+type CalDAVPropNameDto struct {
+	XMLName xml.Name
+}
+
+type CalDAVFilterDto struct {
+	CompFilters []CalDAVCompFilterDto `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type CalDAVCompFilterDto struct {
+	Name         string                `xml:"name,attr"`
+	IsNotDefined *struct{}             `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *CalDAVTimeRangeDto   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	CompFilters  []CalDAVCompFilterDto `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type CalDAVTimeRangeDto struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
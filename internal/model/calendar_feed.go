@@ -0,0 +1,83 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+)
+
+const (
+	FeedKindEvent = "event"
+	FeedKindTodo  = "todo"
+)
+
+// CalendarFeed is an iCalendar view of the tasks, read by calendar apps with
+// a secret token. Kind selects VEVENT or VTODO entries; a non-nil Project
+// limits the feed to the project. Expand lists every occurrence of
+// recurring tasks instead of an RRULE, for clients that don't support it.
+type CalendarFeed struct {
+	ID         int
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Project    *string `json:"project"`
+	Expand     bool    `json:"expand"`
+	CreatedAt  time.Time
+	AccessedAt time.Time
+}
+
+func (f *CalendarFeed) UnmarshalJSON(data []byte) error {
+	type CalendarFeedAlias CalendarFeed
+
+	aliasFeed := &struct {
+		*CalendarFeedAlias
+		ID string `json:"id"`
+	}{
+		CalendarFeedAlias: (*CalendarFeedAlias)(f),
+	}
+
+	if err := json.Unmarshal(data, aliasFeed); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(aliasFeed.ID)) != 0 {
+		id, err := strconv.Atoi(aliasFeed.ID)
+		if err != nil {
+			return err
+		}
+		f.ID = id
+	}
+
+	f.Name = strings.TrimSpace(f.Name)
+	if len(f.Name) == 0 || len(f.Name) > 128 {
+		return errors.NewInvalidCalendarFeed("feed name must be from 1 to 128 characters", nil)
+	}
+
+	switch f.Kind {
+	case "":
+		f.Kind = FeedKindEvent
+	case FeedKindEvent, FeedKindTodo:
+	default:
+		return errors.NewInvalidCalendarFeed("unknown feed kind: "+f.Kind, nil)
+	}
+
+	if f.Project != nil {
+		project := strings.TrimSpace(*f.Project)
+		f.Project = &project
+	}
+	return nil
+}
+
+// NewFeedToken returns a random feed token. Only its hash is stored.
+func NewFeedToken() (string, error) {
+	return NewWebhookSecret()
+}
+
+func HashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+type CalendarFeedDto struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Project    *string `json:"project,omitempty"`
+	Expand     bool    `json:"expand"`
+	CreatedAt  string  `json:"created_at"`
+	AccessedAt string  `json:"accessed_at,omitempty"`
+}
+
+type CalendarFeedsDto struct {
+	Feeds []CalendarFeedDto `json:"feeds"`
+}
+
+// CalendarFeedTokenDto is returned when a feed is created or its token is
+// replaced; the token can't be read later.
+type CalendarFeedTokenDto struct {
+	ID    int    `json:"id"`
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func CalendarFeedToCalendarFeedDto(f CalendarFeed) CalendarFeedDto {
+	dto := CalendarFeedDto{
+		ID:        strconv.Itoa(f.ID),
+		Name:      f.Name,
+		Kind:      f.Kind,
+		Project:   f.Project,
+		Expand:    f.Expand,
+		CreatedAt: f.CreatedAt.Format(time.RFC3339),
+	}
+	if !f.AccessedAt.IsZero() {
+		dto.AccessedAt = f.AccessedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func CalendarFeedsToCalendarFeedsDto(feeds []CalendarFeed) []CalendarFeedDto {
+	dto := make([]CalendarFeedDto, len(feeds))
+	for idx, f := range feeds {
+		dto[idx] = CalendarFeedToCalendarFeedDto(f)
+	}
+	return dto
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// CalendarView is the calendar of a date range. Occurrences place tasks on
+// dates: a stored occurrence is the current date of its task, a projected
+// one a later date of a recurring task, which exists only in the view.
+type CalendarView struct {
+	From        time.Time
+	To          time.Time
+	Tasks       []Task
+	Occurrences []Occurrence
+	// Truncated is set when the range has more occurrences than the view
+	// returns; the returned ones are the earliest.
+	Truncated bool
+}
+
+type Occurrence struct {
+	TaskID    int
+	Date      time.Time
+	Projected bool
+}
@@ -0,0 +1,36 @@
+package model
+
+import "strconv"
+
+type OccurrenceDto struct {
+	TaskID    string `json:"task_id"`
+	Date      string `json:"date"`
+	Projected bool   `json:"projected"`
+}
+
+type CalendarViewDto struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Tasks       []TaskDto       `json:"tasks"`
+	Occurrences []OccurrenceDto `json:"occurrences"`
+	Truncated   bool            `json:"truncated"`
+}
+
+func CalendarViewToCalendarViewDto(view CalendarView) CalendarViewDto {
+	occurrences := make([]OccurrenceDto, len(view.Occurrences))
+	for idx, o := range view.Occurrences {
+		occurrences[idx] = OccurrenceDto{
+			TaskID:    strconv.Itoa(o.TaskID),
+			Date:      o.Date.Format("20060102"),
+			Projected: o.Projected,
+		}
+	}
+
+	return CalendarViewDto{
+		From:        view.From.Format("20060102"),
+		To:          view.To.Format("20060102"),
+		Tasks:       TasksToTasksDto(view.Tasks),
+		Occurrences: occurrences,
+		Truncated:   view.Truncated,
+	}
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+const (
+	FieldTypeText   = "text"
+	FieldTypeNumber = "number"
+	FieldTypeDate   = "date"
+	FieldTypeEnum   = "enum"
+)
+
+type CustomField struct {
+	ID      int
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Options []string `json:"options"`
+}
+
+func (f *CustomField) UnmarshalJSON(data []byte) error {
+	type CustomFieldAlias CustomField
+
+	aliasField := &struct {
+		*CustomFieldAlias
+		ID string `json:"id"`
+	}{
+		CustomFieldAlias: (*CustomFieldAlias)(f),
+	}
+
+	if err := json.Unmarshal(data, aliasField); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(aliasField.ID)) != 0 {
+		id, err := strconv.Atoi(aliasField.ID)
+		if err != nil {
+			return err
+		}
+		f.ID = id
+	}
+
+	f.Name = strings.TrimSpace(f.Name)
+	isValid, err := utils.ValidateFieldName(f.Name)
+	if err != nil || !isValid {
+		return errors.NewInvalidCustomField("invalid custom field name format", err)
+	}
+
+	options := make([]string, 0, len(f.Options))
+	for _, option := range f.Options {
+		option = strings.TrimSpace(option)
+		if len(option) == 0 || strings.Contains(option, ",") {
+			return errors.NewInvalidCustomField("invalid custom field option: "+option, nil)
+		}
+		options = append(options, option)
+	}
+	f.Options = options
+
+	switch f.Type {
+	case FieldTypeText, FieldTypeNumber, FieldTypeDate:
+		f.Options = nil
+	case FieldTypeEnum:
+		if len(f.Options) == 0 {
+			return errors.NewInvalidCustomField("enum custom field requires options", nil)
+		}
+	default:
+		return errors.NewInvalidCustomField("invalid custom field type: "+f.Type, nil)
+	}
+
+	return nil
+}
+
+// NormalizeValue validates the value against the field type and returns its
+// canonical form, so that equal values are stored and filtered identically.
+func (f CustomField) NormalizeValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch f.Type {
+	case FieldTypeText:
+		return value, nil
+	case FieldTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", errors.NewInvalidCustomField(fmt.Sprintf("field %s must be a number", f.Name), err)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case FieldTypeDate:
+		if _, err := time.Parse("20060102", value); err != nil {
+			return "", errors.NewInvalidCustomField(fmt.Sprintf("field %s must be a date", f.Name), err)
+		}
+		return value, nil
+	case FieldTypeEnum:
+		for _, option := range f.Options {
+			if option == value {
+				return value, nil
+			}
+		}
+		return "", errors.NewInvalidCustomField(fmt.Sprintf("field %s must be one of: %s", f.Name,
+			strings.Join(f.Options, ", ")), nil)
+	default:
+		return "", errors.NewInvalidCustomField("invalid custom field type: "+f.Type, nil)
+	}
+}
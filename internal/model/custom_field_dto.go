@@ -0,0 +1,37 @@
+package model
+
+import "strconv"
+
+type CustomFieldDto struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Options []string `json:"options,omitempty"`
+}
+
+type CustomFieldsDto struct {
+	Fields []CustomFieldDto `json:"fields"`
+}
+
+// UpdateCustomFieldDto reports values removed because they don't fit the
+// changed field.
+type UpdateCustomFieldDto struct {
+	DroppedValues int `json:"dropped_values,omitempty"`
+}
+
+func CustomFieldToCustomFieldDto(field CustomField) CustomFieldDto {
+	return CustomFieldDto{
+		ID:      strconv.Itoa(field.ID),
+		Name:    field.Name,
+		Type:    field.Type,
+		Options: field.Options,
+	}
+}
+
+func CustomFieldsToCustomFieldsDto(fields []CustomField) []CustomFieldDto {
+	dto := make([]CustomFieldDto, len(fields))
+	for idx, field := range fields {
+		dto[idx] = CustomFieldToCustomFieldDto(field)
+	}
+	return dto
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// Digest is the daily agenda: overdue tasks, tasks for today and for the
+// next days, each section grouped by project.
+type Digest struct {
+	Date     time.Time
+	Sections []DigestSection
+}
+
+type DigestSection struct {
+	Title    string
+	Projects []DigestProject
+	Count    int
+}
+
+// DigestProject groups tasks of one project. Name is empty for tasks without
+// a project.
+type DigestProject struct {
+	Name  string
+	Tasks []Task
+}
+
+func NewDigestSection(title string, tasks []Task) DigestSection {
+	byProject := make(map[string][]Task)
+	for _, t := range tasks {
+		project := ""
+		if t.Project != nil {
+			project = *t.Project
+		}
+		byProject[project] = append(byProject[project], t)
+	}
+
+	projects := make([]DigestProject, 0, len(byProject))
+	for name, projectTasks := range byProject {
+		projects = append(projects, DigestProject{Name: name, Tasks: projectTasks})
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Name == "" || projects[j].Name == "" {
+			return projects[j].Name == ""
+		}
+		return projects[i].Name < projects[j].Name
+	})
+
+	return DigestSection{Title: title, Projects: projects, Count: len(tasks)}
+}
+
+// Count returns the number of tasks in the digest.
+func (d Digest) Count() int {
+	count := 0
+	for _, section := range d.Sections {
+		count += section.Count
+	}
+	return count
+}
@@ -0,0 +1,51 @@
+package model
+
+const (
+	ImportItemImported = "imported"
+	ImportItemSkipped  = "skipped"
+	ImportItemFailed   = "failed"
+)
+
+// ImportItem is the outcome of importing one entry of a file. Warnings list
+// what was dropped, e.g. an unsupported repeat rule; Reason explains why an
+// entry was skipped or failed validation.
+type ImportItem struct {
+	Line     int
+	Ref      string
+	Task     Task
+	Status   string
+	Warnings []string
+	Reason   string
+}
+
+// ImportReport describes an import. Tasks are added in one transaction
+// unless it is a dry run; skipped and failed items don't stop the others.
+// Warnings concern the whole file, e.g. ignored columns.
+type ImportReport struct {
+	DryRun    bool
+	Committed bool
+	Items     []ImportItem
+	Warnings  []string
+}
+
+func (r ImportReport) Count(status string) int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// FailedLines returns the lines of failed items, for files whose items
+// have line numbers.
+func (r ImportReport) FailedLines() []int {
+	var lines []int
+	for _, item := range r.Items {
+		if item.Status == ImportItemFailed && item.Line > 0 {
+			lines = append(lines, item.Line)
+		}
+	}
+	return lines
+}
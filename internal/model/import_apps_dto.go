@@ -0,0 +1,118 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ExternalID is an id that other tools write either as a string or as a
+// number.
+type ExternalID string
+
+func (id *ExternalID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*id = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*id = ExternalID(value)
+		return nil
+	}
+	var value json.Number
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*id = ExternalID(value)
+	return nil
+}
+
+// TodoistBackupDto is the Todoist Sync API data; the REST API returns the
+// tasks alone, as an array of TodoistTaskDto.
+type TodoistBackupDto struct {
+	Items    []TodoistTaskDto    `json:"items"`
+	Projects []TodoistProjectDto `json:"projects"`
+	Notes    []TodoistNoteDto    `json:"notes"`
+}
+
+// TodoistTaskDto is a task of the Todoist API, where priority 4 is the
+// highest (p1) and 1 is none.
+type TodoistTaskDto struct {
+	ID          ExternalID          `json:"id"`
+	ProjectID   ExternalID          `json:"project_id"`
+	Content     string              `json:"content"`
+	Description string              `json:"description"`
+	Priority    int                 `json:"priority"`
+	Labels      []string            `json:"labels"`
+	Due         *TodoistDueDto      `json:"due"`
+	Duration    *TodoistDurationDto `json:"duration"`
+	IsCompleted bool                `json:"is_completed"`
+	Checked     bool                `json:"checked"`
+	IsDeleted   bool                `json:"is_deleted"`
+}
+
+type TodoistDueDto struct {
+	Date        string `json:"date"`
+	String      string `json:"string"`
+	IsRecurring bool   `json:"is_recurring"`
+}
+
+type TodoistDurationDto struct {
+	Amount int    `json:"amount"`
+	Unit   string `json:"unit"`
+}
+
+type TodoistProjectDto struct {
+	ID   ExternalID `json:"id"`
+	Name string     `json:"name"`
+}
+
+type TodoistNoteDto struct {
+	ItemID  ExternalID `json:"item_id"`
+	Content string     `json:"content"`
+}
+
+// GoogleTasksDto is the Tasks.json file of Google Takeout.
+type GoogleTasksDto struct {
+	Items []GoogleTaskListDto `json:"items"`
+}
+
+type GoogleTaskListDto struct {
+	ID    string          `json:"id"`
+	Title string          `json:"title"`
+	Items []GoogleTaskDto `json:"items"`
+}
+
+type GoogleTaskDto struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Notes   string `json:"notes"`
+	Status  string `json:"status"`
+	Due     string `json:"due"`
+	Parent  string `json:"parent"`
+	Deleted bool   `json:"deleted"`
+}
+
+// TaskwarriorTaskDto is a task of "task export". Dates are in the
+// 20060102T150405Z form.
+type TaskwarriorTaskDto struct {
+	UUID        string                     `json:"uuid"`
+	Description string                     `json:"description"`
+	Status      string                     `json:"status"`
+	Due         string                     `json:"due"`
+	Until       string                     `json:"until"`
+	Project     string                     `json:"project"`
+	Tags        []string                   `json:"tags"`
+	Priority    string                     `json:"priority"`
+	Recur       string                     `json:"recur"`
+	Parent      string                     `json:"parent"`
+	Annotations []TaskwarriorAnnotationDto `json:"annotations"`
+}
+
+type TaskwarriorAnnotationDto struct {
+	Description string `json:"description"`
+}
@@ -0,0 +1,51 @@
+package model
+
+type ImportItemDto struct {
+	Line     int      `json:"line,omitempty"`
+	Ref      string   `json:"ref,omitempty"`
+	Status   string   `json:"status"`
+	Task     *TaskDto `json:"task,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+type ImportReportDto struct {
+	DryRun      bool            `json:"dry_run"`
+	Committed   bool            `json:"committed"`
+	Imported    int             `json:"imported"`
+	Skipped     int             `json:"skipped"`
+	Failed      int             `json:"failed"`
+	FailedLines []int           `json:"failed_lines,omitempty"`
+	Warnings    []string        `json:"warnings,omitempty"`
+	Items       []ImportItemDto `json:"items"`
+}
+
+func ImportReportToImportReportDto(r ImportReport) ImportReportDto {
+	dto := ImportReportDto{
+		DryRun:      r.DryRun,
+		Committed:   r.Committed,
+		Imported:    r.Count(ImportItemImported),
+		Skipped:     r.Count(ImportItemSkipped),
+		Failed:      r.Count(ImportItemFailed),
+		FailedLines: r.FailedLines(),
+		Warnings:    r.Warnings,
+		Items:       make([]ImportItemDto, len(r.Items)),
+	}
+	for idx, item := range r.Items {
+		dto.Items[idx] = ImportItemDto{
+			Line:     item.Line,
+			Ref:      item.Ref,
+			Status:   item.Status,
+			Warnings: item.Warnings,
+			Reason:   item.Reason,
+		}
+		if item.Status == ImportItemImported {
+			task := TaskToTaskDto(item.Task)
+			if item.Task.ID == 0 {
+				task.ID = ""
+			}
+			dto.Items[idx].Task = &task
+		}
+	}
+	return dto
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+const (
+	LiveSubscribe   = "subscribe"
+	LiveUnsubscribe = "unsubscribe"
+	LiveCreate      = "create"
+	LiveUpdate      = "update"
+	LiveComplete    = "complete"
+	LiveDelete      = "delete"
+
+	LiveAck   = "ack"
+	LiveError = "error"
+	LiveEvent = "event"
+)
+
+// LiveRequestDto is a message sent by a WebSocket client. ID is chosen by
+// the client and echoed in the reply. Subscribe uses Project, From and To;
+// unsubscribe uses Subscription; create and update use Task; complete and
+// delete use TaskID.
+type LiveRequestDto struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	Project      *string         `json:"project"`
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Subscription int             `json:"subscription"`
+	Task         json.RawMessage `json:"task"`
+	TaskID       string          `json:"task_id"`
+}
+
+// LiveResponseDto is a message sent to a WebSocket client: a reply to a
+// request (ack or error) or a task event matching a subscription.
+type LiveResponseDto struct {
+	Type         string   `json:"type"`
+	ID           string   `json:"id,omitempty"`
+	Subscription int      `json:"subscription,omitempty"`
+	TaskID       string   `json:"task_id,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	Event        string   `json:"event,omitempty"`
+	EventID      string   `json:"event_id,omitempty"`
+	Task         *TaskDto `json:"task,omitempty"`
+	From         string   `json:"from,omitempty"`
+}
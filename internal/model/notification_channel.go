@@ -0,0 +1,127 @@
+package model
+
+import (
+	"encoding/json"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+)
+
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelPush    = "push"
+)
+
+const (
+	PushFormatNtfy   = "ntfy"
+	PushFormatGotify = "gotify"
+)
+
+const maxChannelNameLength = 64
+
+// NotificationChannel describes where notifications are delivered. Only the
+// settings of its kind are used: URL for a webhook; URL, Token and Format for
+// a push service; Host, Port, Username, Password, From and To for an email.
+type NotificationChannel struct {
+	ID       int
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Enabled  bool     `json:"enabled"`
+	URL      string   `json:"url"`
+	Token    string   `json:"token"`
+	Format   string   `json:"format"`
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+func (c *NotificationChannel) UnmarshalJSON(data []byte) error {
+	type NotificationChannelAlias NotificationChannel
+
+	aliasChannel := &struct {
+		*NotificationChannelAlias
+		ID      string `json:"id"`
+		Enabled *bool  `json:"enabled"`
+	}{
+		NotificationChannelAlias: (*NotificationChannelAlias)(c),
+	}
+
+	if err := json.Unmarshal(data, aliasChannel); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(aliasChannel.ID)) != 0 {
+		id, err := strconv.Atoi(aliasChannel.ID)
+		if err != nil {
+			return err
+		}
+		c.ID = id
+	}
+
+	c.Enabled = aliasChannel.Enabled == nil || *aliasChannel.Enabled
+
+	c.Name = strings.TrimSpace(c.Name)
+	if len(c.Name) == 0 {
+		c.Name = c.Kind
+	}
+	if len(c.Name) > maxChannelNameLength {
+		return errors.NewInvalidNotificationChannel("notification channel name is too long", nil)
+	}
+
+	return c.Validate()
+}
+
+// Validate checks the settings required by the channel kind.
+func (c *NotificationChannel) Validate() error {
+	switch c.Kind {
+	case ChannelWebhook:
+		return validateChannelURL(c.URL)
+	case ChannelPush:
+		if len(c.Format) == 0 {
+			c.Format = PushFormatNtfy
+		}
+		if c.Format != PushFormatNtfy && c.Format != PushFormatGotify {
+			return errors.NewInvalidNotificationChannel("invalid push format: "+c.Format, nil)
+		}
+		return validateChannelURL(c.URL)
+	case ChannelEmail:
+		if len(strings.TrimSpace(c.Host)) == 0 {
+			return errors.NewInvalidNotificationChannel("email channel requires smtp host", nil)
+		}
+		if c.Port == 0 {
+			c.Port = 25
+		}
+		if c.Port < 1 || c.Port > 65535 {
+			return errors.NewInvalidNotificationChannel("invalid smtp port", nil)
+		}
+		if _, err := mail.ParseAddress(c.From); err != nil {
+			return errors.NewInvalidNotificationChannel("invalid email sender: "+c.From, err)
+		}
+		if len(c.To) == 0 {
+			return errors.NewInvalidNotificationChannel("email channel requires recipients", nil)
+		}
+		for _, to := range c.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return errors.NewInvalidNotificationChannel("invalid email recipient: "+to, err)
+			}
+		}
+		return nil
+	default:
+		return errors.NewInvalidNotificationChannel("invalid notification channel kind: "+c.Kind, nil)
+	}
+}
+
+func validateChannelURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.NewInvalidNotificationChannel("invalid notification channel url: "+value, err)
+	}
+	return nil
+}
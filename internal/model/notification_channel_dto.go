@@ -0,0 +1,46 @@
+package model
+
+import "strconv"
+
+// NotificationChannelDto never includes the channel password and token.
+type NotificationChannelDto struct {
+	ID       string   `json:"id"`
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Enabled  bool     `json:"enabled"`
+	URL      string   `json:"url,omitempty"`
+	Format   string   `json:"format,omitempty"`
+	Host     string   `json:"host,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+type NotificationChannelsDto struct {
+	Channels []NotificationChannelDto `json:"channels"`
+}
+
+func NotificationChannelToNotificationChannelDto(c NotificationChannel) NotificationChannelDto {
+	return NotificationChannelDto{
+		ID:       strconv.Itoa(c.ID),
+		Kind:     c.Kind,
+		Name:     c.Name,
+		Enabled:  c.Enabled,
+		URL:      c.URL,
+		Format:   c.Format,
+		Host:     c.Host,
+		Port:     c.Port,
+		Username: c.Username,
+		From:     c.From,
+		To:       c.To,
+	}
+}
+
+func NotificationChannelsToNotificationChannelsDto(channels []NotificationChannel) []NotificationChannelDto {
+	dto := make([]NotificationChannelDto, len(channels))
+	for idx, c := range channels {
+		dto[idx] = NotificationChannelToNotificationChannelDto(c)
+	}
+	return dto
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+)
+
+const (
+	DeliverySending = "sending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+
+	maxReminderBefore = 7 * 24 * 60
+)
+
+// Reminder fires Before minutes ahead of Time on the task date, e.g. at
+// 09:00 on the day or 30 minutes before 10:00.
+type Reminder struct {
+	ID     int
+	TaskID int
+	Time   string `json:"time"`
+	Before int    `json:"before"`
+
+	TaskTitle string    `json:"-"`
+	TaskDate  time.Time `json:"-"`
+}
+
+func (r *Reminder) UnmarshalJSON(data []byte) error {
+	type ReminderAlias Reminder
+
+	aliasReminder := &struct {
+		*ReminderAlias
+		ID     string `json:"id"`
+		TaskID string `json:"task_id"`
+	}{
+		ReminderAlias: (*ReminderAlias)(r),
+	}
+
+	if err := json.Unmarshal(data, aliasReminder); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(aliasReminder.ID)) != 0 {
+		id, err := strconv.Atoi(aliasReminder.ID)
+		if err != nil {
+			return err
+		}
+		r.ID = id
+	}
+
+	taskID, err := strconv.Atoi(aliasReminder.TaskID)
+	if err != nil {
+		return errors.NewInvalidReminderFormat("invalid reminder task id", err)
+	}
+	r.TaskID = taskID
+
+	if len(strings.TrimSpace(r.Time)) == 0 {
+		r.Time = "09:00"
+	}
+	if _, err := time.Parse("15:04", r.Time); err != nil {
+		return errors.NewInvalidReminderFormat("invalid reminder time format, expected HH:MM", err)
+	}
+
+	if r.Before < 0 || r.Before > maxReminderBefore {
+		return errors.NewInvalidReminderFormat("reminder before must be from 0 to 10080 minutes", nil)
+	}
+
+	return nil
+}
+
+// RemindAt returns the moment the reminder is due for the given task date in
+// the location loc.
+func (r Reminder) RemindAt(date time.Time, loc *time.Location) time.Time {
+	at, _ := time.Parse("15:04", r.Time)
+	moment := time.Date(date.Year(), date.Month(), date.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+	return moment.Add(-time.Duration(r.Before) * time.Minute)
+}
+
+type Notification struct {
+	Title   string
+	Message string
+	TaskID  int
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+type ReminderDto struct {
+	ID       string `json:"id"`
+	TaskID   string `json:"task_id"`
+	Time     string `json:"time"`
+	Before   int    `json:"before"`
+	RemindAt string `json:"remind_at"`
+}
+
+type RemindersDto struct {
+	Reminders []ReminderDto `json:"reminders"`
+}
+
+func ReminderToReminderDto(reminder Reminder) ReminderDto {
+	return ReminderDto{
+		ID:       strconv.Itoa(reminder.ID),
+		TaskID:   strconv.Itoa(reminder.TaskID),
+		Time:     reminder.Time,
+		Before:   reminder.Before,
+		RemindAt: reminder.RemindAt(reminder.TaskDate, time.Local).Format(time.RFC3339),
+	}
+}
+
+func RemindersToRemindersDto(reminders []Reminder) []ReminderDto {
+	dto := make([]ReminderDto, len(reminders))
+	for idx, reminder := range reminders {
+		dto[idx] = ReminderToReminderDto(reminder)
+	}
+	return dto
+}
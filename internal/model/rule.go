@@ -0,0 +1,360 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+const (
+	RuleOpEq       = "eq"
+	RuleOpNe       = "ne"
+	RuleOpContains = "contains"
+	RuleOpGte      = "gte"
+	RuleOpLte      = "lte"
+)
+
+const (
+	RuleFieldTitle     = "title"
+	RuleFieldComment   = "comment"
+	RuleFieldRepeat    = "repeat"
+	RuleFieldProject   = "project"
+	RuleFieldTag       = "tag"
+	RuleFieldPriority  = "priority"
+	RuleFieldEstimate  = "estimate"
+	RuleFieldPostponed = "postponed"
+
+	// RuleFieldCustomPrefix selects a custom field value, e.g. field.stage.
+	RuleFieldCustomPrefix = "field."
+)
+
+const (
+	RuleActionCreateTask    = "create_task"
+	RuleActionAddTag        = "add_tag"
+	RuleActionSetProject    = "set_project"
+	RuleActionSetPriority   = "set_priority"
+	RuleActionRaisePriority = "raise_priority"
+	RuleActionPostpone      = "postpone"
+	RuleActionComplete      = "complete"
+)
+
+const (
+	RuleExecutionSuccess = "success"
+	RuleExecutionFailed  = "failed"
+
+	maxRuleConditions = 20
+	maxRuleActions    = 20
+)
+
+var ruleFields = map[string]bool{
+	RuleFieldTitle:     true,
+	RuleFieldComment:   true,
+	RuleFieldRepeat:    true,
+	RuleFieldProject:   true,
+	RuleFieldTag:       true,
+	RuleFieldPriority:  true,
+	RuleFieldEstimate:  true,
+	RuleFieldPostponed: true,
+}
+
+// Rule runs its actions on the task of a Trigger event when all conditions
+// hold.
+type Rule struct {
+	ID         int
+	Name       string          `json:"name"`
+	Trigger    string          `json:"trigger"`
+	Conditions []RuleCondition `json:"conditions"`
+	Actions    []RuleAction    `json:"actions"`
+	Enabled    bool            `json:"enabled"`
+}
+
+type RuleCondition struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// RuleAction is one step of a rule. Title, Comment, Date, Project and Tags
+// describe the task created by create_task, where Date is a postpone
+// expression counted from today, e.g. "+1d", and "{{title}}" is replaced with
+// the title of the triggering task. Tag is used by add_tag, Project by
+// set_project, Priority by set_priority, By by raise_priority and To by
+// postpone.
+type RuleAction struct {
+	Type     string   `json:"type"`
+	Title    string   `json:"title,omitempty"`
+	Comment  string   `json:"comment,omitempty"`
+	Date     string   `json:"date,omitempty"`
+	Project  string   `json:"project,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Tag      string   `json:"tag,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	By       int      `json:"by,omitempty"`
+	To       string   `json:"to,omitempty"`
+}
+
+// RuleFacts is what conditions are evaluated against.
+type RuleFacts struct {
+	Task      Task
+	Postponed int
+}
+
+type RuleExecution struct {
+	ID        int
+	RuleID    int
+	EventID   int64
+	Event     string
+	TaskID    int
+	TaskTitle string
+	Status    string
+	Actions   []string
+	Error     string
+	CreatedAt time.Time
+}
+
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	type RuleAlias Rule
+
+	aliasRule := &struct {
+		*RuleAlias
+		ID      string `json:"id"`
+		Enabled *bool  `json:"enabled"`
+	}{
+		RuleAlias: (*RuleAlias)(r),
+	}
+
+	if err := json.Unmarshal(data, aliasRule); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(aliasRule.ID)) != 0 {
+		id, err := strconv.Atoi(aliasRule.ID)
+		if err != nil {
+			return err
+		}
+		r.ID = id
+	}
+
+	r.Enabled = aliasRule.Enabled == nil || *aliasRule.Enabled
+
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) == 0 || len(r.Name) > 128 {
+		return errors.NewInvalidRuleFormat("rule name must be from 1 to 128 characters", nil)
+	}
+
+	if !taskEvents[r.Trigger] {
+		return errors.NewInvalidRuleFormat("unknown rule trigger: "+r.Trigger, nil)
+	}
+
+	if len(r.Conditions) > maxRuleConditions {
+		return errors.NewInvalidRuleFormat(fmt.Sprintf("rule can have at most %d conditions", maxRuleConditions), nil)
+	}
+	for idx := range r.Conditions {
+		if err := r.Conditions[idx].normalize(); err != nil {
+			return err
+		}
+	}
+
+	if len(r.Actions) == 0 || len(r.Actions) > maxRuleActions {
+		return errors.NewInvalidRuleFormat(fmt.Sprintf("rule must have from 1 to %d actions", maxRuleActions), nil)
+	}
+	for idx := range r.Actions {
+		if err := r.Actions[idx].normalize(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *RuleCondition) normalize() error {
+	c.Field = strings.TrimSpace(c.Field)
+	if !ruleFields[c.Field] && !strings.HasPrefix(c.Field, RuleFieldCustomPrefix) {
+		return errors.NewInvalidRuleFormat("unknown rule condition field: "+c.Field, nil)
+	}
+
+	switch c.Op {
+	case RuleOpEq, RuleOpNe, RuleOpContains:
+	case RuleOpGte, RuleOpLte:
+		if _, err := strconv.ParseFloat(c.Value, 64); err != nil {
+			return errors.NewInvalidRuleFormat("rule condition "+c.Op+" requires a number", err)
+		}
+	default:
+		return errors.NewInvalidRuleFormat("unknown rule condition operator: "+c.Op, nil)
+	}
+
+	if c.Field == RuleFieldTag {
+		c.Value = strings.TrimPrefix(strings.TrimSpace(c.Value), "#")
+	}
+	return nil
+}
+
+func (a *RuleAction) normalize() error {
+	today := utils.Today(time.Now(), Location)
+
+	switch a.Type {
+	case RuleActionCreateTask:
+		a.Title = strings.TrimSpace(a.Title)
+		if len(a.Title) == 0 {
+			return errors.NewInvalidRuleFormat("create_task action requires a title", nil)
+		}
+		if len(a.Date) > 0 {
+			if _, err := utils.PostponeDate(today, today, a.Date); err != nil {
+				return errors.NewInvalidRuleFormat("invalid create_task date: "+a.Date, err)
+			}
+		}
+		tags, err := NormalizeTags(a.Tags)
+		if err != nil {
+			return err
+		}
+		a.Tags = tags
+	case RuleActionAddTag:
+		tags, err := NormalizeTags([]string{a.Tag})
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return errors.NewInvalidRuleFormat("add_tag action requires a tag", nil)
+		}
+		a.Tag = tags[0]
+	case RuleActionSetProject:
+		a.Project = strings.TrimSpace(a.Project)
+	case RuleActionSetPriority:
+		if a.Priority < 0 || a.Priority > MaxPriority {
+			return errors.NewInvalidRuleFormat(fmt.Sprintf("priority must be from 0 to %d", MaxPriority), nil)
+		}
+	case RuleActionRaisePriority:
+		if a.By == 0 {
+			a.By = 1
+		}
+		if a.By < 1 || a.By > MaxPriority {
+			return errors.NewInvalidRuleFormat(fmt.Sprintf("raise_priority by must be from 1 to %d", MaxPriority), nil)
+		}
+	case RuleActionPostpone:
+		if _, err := utils.PostponeDate(today, today, a.To); err != nil {
+			return errors.NewInvalidRuleFormat("invalid postpone action value: "+a.To, err)
+		}
+	case RuleActionComplete:
+	default:
+		return errors.NewInvalidRuleFormat("unknown rule action: "+a.Type, nil)
+	}
+	return nil
+}
+
+// Evaluate returns the actual value of the condition field and whether the
+// condition holds.
+func (c RuleCondition) Evaluate(f RuleFacts) (string, bool) {
+	if c.Field == RuleFieldTag {
+		matched := false
+		for _, tag := range f.Task.Tags {
+			matched = matched || compareRuleValue(c.Op, tag, c.Value)
+		}
+		if c.Op == RuleOpNe {
+			matched = true
+			for _, tag := range f.Task.Tags {
+				matched = matched && tag != c.Value
+			}
+		}
+		return strings.Join(f.Task.Tags, ","), matched
+	}
+
+	actual := ruleFieldValue(c.Field, f)
+	return actual, compareRuleValue(c.Op, actual, c.Value)
+}
+
+// Matches reports whether all conditions hold.
+func (r Rule) Matches(f RuleFacts) bool {
+	for _, c := range r.Conditions {
+		if _, ok := c.Evaluate(f); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func ruleFieldValue(field string, f RuleFacts) string {
+	t := f.Task
+	switch field {
+	case RuleFieldTitle:
+		return t.Title
+	case RuleFieldComment:
+		return t.Comment
+	case RuleFieldRepeat:
+		return t.Repeat
+	case RuleFieldProject:
+		if t.Project != nil {
+			return *t.Project
+		}
+	case RuleFieldPriority:
+		if t.Priority != nil {
+			return strconv.Itoa(*t.Priority)
+		}
+		return "0"
+	case RuleFieldEstimate:
+		if t.Estimate != nil {
+			return strconv.Itoa(*t.Estimate)
+		}
+		return "0"
+	case RuleFieldPostponed:
+		return strconv.Itoa(f.Postponed)
+	default:
+		return t.Fields[strings.TrimPrefix(field, RuleFieldCustomPrefix)]
+	}
+	return ""
+}
+
+func compareRuleValue(op string, actual string, expected string) bool {
+	switch op {
+	case RuleOpEq:
+		return strings.EqualFold(actual, expected)
+	case RuleOpNe:
+		return !strings.EqualFold(actual, expected)
+	case RuleOpContains:
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(expected))
+	case RuleOpGte, RuleOpLte:
+		a, err := strconv.ParseFloat(actual, 64)
+		if err != nil {
+			return false
+		}
+		e, _ := strconv.ParseFloat(expected, 64)
+		if op == RuleOpGte {
+			return a >= e
+		}
+		return a <= e
+	}
+	return false
+}
+
+// Describe returns a short human readable form of the action for dry runs
+// and the execution log.
+func (a RuleAction) Describe() string {
+	switch a.Type {
+	case RuleActionCreateTask:
+		date := a.Date
+		if len(date) == 0 {
+			date = "today"
+		}
+		return fmt.Sprintf("create task %q on %s", a.Title, date)
+	case RuleActionAddTag:
+		return "add tag #" + a.Tag
+	case RuleActionSetProject:
+		if len(a.Project) == 0 {
+			return "clear project"
+		}
+		return fmt.Sprintf("set project %q", a.Project)
+	case RuleActionSetPriority:
+		return fmt.Sprintf("set priority %d", a.Priority)
+	case RuleActionRaisePriority:
+		return fmt.Sprintf("raise priority by %d", a.By)
+	case RuleActionPostpone:
+		return "postpone " + a.To
+	case RuleActionComplete:
+		return "complete task"
+	}
+	return a.Type
+}
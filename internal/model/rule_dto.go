@@ -0,0 +1,99 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+type RuleDto struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Trigger    string          `json:"trigger"`
+	Conditions []RuleCondition `json:"conditions"`
+	Actions    []RuleAction    `json:"actions"`
+	Enabled    bool            `json:"enabled"`
+}
+
+type RulesDto struct {
+	Rules []RuleDto `json:"rules"`
+}
+
+type RuleExecutionDto struct {
+	ID        string   `json:"id"`
+	EventID   string   `json:"event_id"`
+	Event     string   `json:"event"`
+	TaskID    string   `json:"task_id"`
+	TaskTitle string   `json:"task_title"`
+	Status    string   `json:"status"`
+	Actions   []string `json:"actions"`
+	Error     string   `json:"error,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type RuleExecutionsDto struct {
+	Executions []RuleExecutionDto `json:"executions"`
+}
+
+type RuleConditionResultDto struct {
+	Field   string `json:"field"`
+	Op      string `json:"op"`
+	Value   string `json:"value"`
+	Actual  string `json:"actual"`
+	Matched bool   `json:"matched"`
+}
+
+// RuleDryRunDto shows how a rule would handle a task without changing it.
+type RuleDryRunDto struct {
+	Matched    bool                     `json:"matched"`
+	Conditions []RuleConditionResultDto `json:"conditions"`
+	Actions    []string                 `json:"actions"`
+}
+
+func RuleToRuleDto(r Rule) RuleDto {
+	dto := RuleDto{
+		ID:         strconv.Itoa(r.ID),
+		Name:       r.Name,
+		Trigger:    r.Trigger,
+		Conditions: r.Conditions,
+		Actions:    r.Actions,
+		Enabled:    r.Enabled,
+	}
+	if dto.Conditions == nil {
+		dto.Conditions = []RuleCondition{}
+	}
+	return dto
+}
+
+func RulesToRulesDto(rules []Rule) []RuleDto {
+	dto := make([]RuleDto, len(rules))
+	for idx, r := range rules {
+		dto[idx] = RuleToRuleDto(r)
+	}
+	return dto
+}
+
+func RuleExecutionToRuleExecutionDto(e RuleExecution) RuleExecutionDto {
+	dto := RuleExecutionDto{
+		ID:        strconv.Itoa(e.ID),
+		EventID:   strconv.FormatInt(e.EventID, 10),
+		Event:     e.Event,
+		TaskID:    strconv.Itoa(e.TaskID),
+		TaskTitle: e.TaskTitle,
+		Status:    e.Status,
+		Actions:   e.Actions,
+		Error:     e.Error,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+	}
+	if dto.Actions == nil {
+		dto.Actions = []string{}
+	}
+	return dto
+}
+
+func RuleExecutionsToRuleExecutionsDto(executions []RuleExecution) []RuleExecutionDto {
+	dto := make([]RuleExecutionDto, len(executions))
+	for idx, e := range executions {
+		dto[idx] = RuleExecutionToRuleExecutionDto(e)
+	}
+	return dto
+}
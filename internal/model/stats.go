@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// Completion is a completion recorded in the task history together with
+// the current repeat rule and date of its task; both are empty once the task
+// is deleted.
+type Completion struct {
+	TaskEvent
+	Repeat   string
+	TaskDate time.Time
+}
+
+// OnTime reports whether the task was completed no later than its date, in
+// the given location.
+func (c Completion) OnTime(location *time.Location) bool {
+	done := c.CreatedAt.In(location)
+	day := time.Date(done.Year(), done.Month(), done.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.After(c.FromDate)
+}
+
+// Stats sums up completions from From to To. Days has an item for every
+// day of the range and Weeks for every week touching it, keyed by the first
+// day of the week.
+type Stats struct {
+	From      time.Time
+	To        time.Time
+	Completed int
+	OnTime    int
+	Late      int
+	Days      []StatsPeriod
+	Weeks     []StatsPeriod
+	Streaks   []Streak
+	Postponed []PostponedStat
+}
+
+type StatsPeriod struct {
+	Start     time.Time
+	Completed int
+}
+
+// Streak counts the consecutive on-time completions of a recurring task. A
+// late completion ends a streak, and so does the task being overdue now.
+type Streak struct {
+	TaskID  int
+	Title   string
+	Repeat  string
+	Current int
+	Longest int
+}
+
+// PostponedStat is how many times a task was postponed in the range. TaskID
+// is zero for deleted tasks.
+type PostponedStat struct {
+	TaskID    int
+	Title     string
+	Postponed int
+}
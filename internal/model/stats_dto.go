@@ -0,0 +1,72 @@
+package model
+
+import "strconv"
+
+type StatsPeriodDto struct {
+	Date      string `json:"date"`
+	Completed int    `json:"completed"`
+}
+
+type StreakDto struct {
+	TaskID  string `json:"task_id"`
+	Title   string `json:"title"`
+	Repeat  string `json:"repeat"`
+	Current int    `json:"current"`
+	Longest int    `json:"longest"`
+}
+
+type PostponedStatDto struct {
+	TaskID    string `json:"task_id,omitempty"`
+	Title     string `json:"title"`
+	Postponed int    `json:"postponed"`
+}
+
+type StatsDto struct {
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Completed int                `json:"completed"`
+	OnTime    int                `json:"on_time"`
+	Late      int                `json:"late"`
+	Days      []StatsPeriodDto   `json:"days"`
+	Weeks     []StatsPeriodDto   `json:"weeks"`
+	Streaks   []StreakDto        `json:"streaks"`
+	Postponed []PostponedStatDto `json:"postponed"`
+}
+
+func StatsToStatsDto(stats Stats) StatsDto {
+	dto := StatsDto{
+		From:      stats.From.Format("20060102"),
+		To:        stats.To.Format("20060102"),
+		Completed: stats.Completed,
+		OnTime:    stats.OnTime,
+		Late:      stats.Late,
+		Days:      statsPeriodsToDto(stats.Days),
+		Weeks:     statsPeriodsToDto(stats.Weeks),
+		Streaks:   make([]StreakDto, len(stats.Streaks)),
+		Postponed: make([]PostponedStatDto, len(stats.Postponed)),
+	}
+	for idx, streak := range stats.Streaks {
+		dto.Streaks[idx] = StreakDto{
+			TaskID:  strconv.Itoa(streak.TaskID),
+			Title:   streak.Title,
+			Repeat:  streak.Repeat,
+			Current: streak.Current,
+			Longest: streak.Longest,
+		}
+	}
+	for idx, postponed := range stats.Postponed {
+		dto.Postponed[idx] = PostponedStatDto{Title: postponed.Title, Postponed: postponed.Postponed}
+		if postponed.TaskID != 0 {
+			dto.Postponed[idx].TaskID = strconv.Itoa(postponed.TaskID)
+		}
+	}
+	return dto
+}
+
+func statsPeriodsToDto(periods []StatsPeriod) []StatsPeriodDto {
+	dto := make([]StatsPeriodDto, len(periods))
+	for idx, period := range periods {
+		dto[idx] = StatsPeriodDto{Date: period.Start.Format("20060102"), Completed: period.Completed}
+	}
+	return dto
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -10,12 +12,29 @@ import (
 	"github.com/Stern-Ritter/go_task_manager/internal/utils"
 )
 
+// MaxPriority is the highest task priority; 0 means no priority.
+const MaxPriority = 3
+
+// Location is the time zone of TODO_TIMEZONE, where days of task dates
+// begin. Decoded tasks get today in it by default, and the DTOs tell
+// overdue tasks by it. It is set on start, before any request is served.
+var Location = time.Local
+
 type Task struct {
 	ID      int
 	Date    time.Time
 	Title   string `json:"title"`
 	Comment string `json:"comment"`
 	Repeat  string `json:"repeat"`
+
+	// Estimate (in minutes), Project, Priority, Tags and Fields are optional:
+	// nil means the value was not sent and the stored one should be kept on
+	// update.
+	Estimate *int              `json:"estimate"`
+	Project  *string           `json:"project"`
+	Priority *int              `json:"priority"`
+	Tags     []string          `json:"tags"`
+	Fields   map[string]string `json:"fields"`
 }
 
 func (t *Task) UnmarshalJSON(data []byte) error {
@@ -23,8 +42,9 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 
 	aliasTask := &struct {
 		*TaskAlias
-		Date string `json:"date"`
-		ID   string `json:"id"`
+		Date   string         `json:"date"`
+		ID     string         `json:"id"`
+		Fields map[string]any `json:"fields"`
 	}{
 		TaskAlias: (*TaskAlias)(t),
 	}
@@ -45,15 +65,43 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 		return errors.NewInvalidTitleFormat("task title is empty", nil)
 	}
 
-	currDateTime := time.Now()
-	now := time.Date(currDateTime.Year(), currDateTime.Month(), currDateTime.Day(), 0, 0, 0, 0, time.UTC)
+	if aliasTask.Estimate != nil && *aliasTask.Estimate < 0 {
+		return errors.NewInvalidEstimateFormat("task estimate must not be negative", nil)
+	}
+
+	if aliasTask.Priority != nil && (*aliasTask.Priority < 0 || *aliasTask.Priority > MaxPriority) {
+		return errors.NewInvalidPriorityFormat(fmt.Sprintf("task priority must be from 0 to %d", MaxPriority), nil)
+	}
+
+	if aliasTask.Project != nil {
+		project := strings.TrimSpace(*aliasTask.Project)
+		t.Project = &project
+	}
+
+	if aliasTask.Tags != nil {
+		tags, err := NormalizeTags(aliasTask.Tags)
+		if err != nil {
+			return err
+		}
+		t.Tags = tags
+	}
+
+	if aliasTask.Fields != nil {
+		fields, err := parseFieldValues(aliasTask.Fields)
+		if err != nil {
+			return err
+		}
+		t.Fields = fields
+	}
+
+	now := utils.Today(time.Now(), Location)
 	var date time.Time
 	nextDate := ""
 
 	if len(strings.TrimSpace(aliasTask.Date)) != 0 {
-		value, err := time.Parse("20060102", aliasTask.Date)
+		value, err := utils.ParseDate(aliasTask.Date)
 		if err != nil {
-			return errors.NewInvalidDateFormat("invalid task date format", err)
+			return err
 		}
 		date = value
 	} else {
@@ -83,3 +131,45 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 	t.Date = date
 	return nil
 }
+
+func NormalizeTags(tags []string) ([]string, error) {
+	res := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if len(tag) == 0 || seen[tag] {
+			continue
+		}
+
+		isValid, err := utils.ValidateTag(tag)
+		if err != nil || !isValid {
+			return nil, errors.NewInvalidTagFormat("invalid task tag format", err)
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func parseFieldValues(values map[string]any) (map[string]string, error) {
+	res := make(map[string]string, len(values))
+	for name, value := range values {
+		switch v := value.(type) {
+		case nil:
+			res[name] = ""
+		case string:
+			res[name] = v
+		case float64:
+			res[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, errors.NewInvalidCustomField(fmt.Sprintf("invalid value of custom field %s", name), nil)
+		}
+	}
+	return res, nil
+}
+
+// IsOverdue reports whether the task date has already passed in location.
+func (t Task) IsOverdue(now time.Time, location *time.Location) bool {
+	return t.Date.Before(utils.Today(now, location))
+}
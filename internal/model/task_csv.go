@@ -0,0 +1,31 @@
+package model
+
+// Columns of task CSV files. Custom fields follow as "field:<name>" columns.
+const (
+	CSVColumnID         = "id"
+	CSVColumnDate       = "date"
+	CSVColumnTitle      = "title"
+	CSVColumnComment    = "comment"
+	CSVColumnRepeat     = "repeat"
+	CSVColumnRepeatText = "repeat_text"
+	CSVColumnProject    = "project"
+	CSVColumnPriority   = "priority"
+	CSVColumnEstimate   = "estimate"
+	CSVColumnTags       = "tags"
+
+	CSVFieldColumnPrefix = "field:"
+)
+
+// CSVColumns lists the task columns in export order.
+var CSVColumns = []string{
+	CSVColumnID,
+	CSVColumnDate,
+	CSVColumnTitle,
+	CSVColumnComment,
+	CSVColumnRepeat,
+	CSVColumnRepeatText,
+	CSVColumnProject,
+	CSVColumnPriority,
+	CSVColumnEstimate,
+	CSVColumnTags,
+}
@@ -1,6 +1,9 @@
 package model
 
-import "strconv"
+import (
+	"strconv"
+	"time"
+)
 
 type TaskDto struct {
 	ID      string `json:"id"`
@@ -8,6 +11,30 @@ type TaskDto struct {
 	Title   string `json:"title"`
 	Comment string `json:"comment"`
 	Repeat  string `json:"repeat"`
+
+	Estimate int               `json:"estimate,omitempty"`
+	Project  string            `json:"project,omitempty"`
+	Priority int               `json:"priority,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Overdue  bool              `json:"overdue,omitempty"`
+}
+
+type PostponeTaskDto struct {
+	To string `json:"to"`
+}
+
+type PostponedTaskDto struct {
+	TaskDto
+	Postponed int `json:"postponed"`
+}
+
+type PostponedTasksDto struct {
+	Tasks []PostponedTaskDto `json:"tasks"`
+}
+
+type RolloverTasksDto struct {
+	Rolled int `json:"rolled"`
 }
 
 type TasksDto struct {
@@ -23,14 +50,28 @@ type CreateTaskErrorDto struct {
 }
 
 func TaskToTaskDto(task Task) TaskDto {
-	return TaskDto{
+	dto := TaskDto{
 		ID:      strconv.Itoa(task.ID),
 		Date:    task.Date.Format("20060102"),
 		Title:   task.Title,
 		Comment: task.Comment,
 		Repeat:  task.Repeat,
+		Tags:    task.Tags,
+		Fields:  task.Fields,
+		Overdue: task.IsOverdue(time.Now(), Location),
+	}
+	if task.Estimate != nil {
+		dto.Estimate = *task.Estimate
 	}
+	if task.Priority != nil {
+		dto.Priority = *task.Priority
+	}
+	if task.Project != nil {
+		dto.Project = *task.Project
+	}
+	return dto
 }
+
 func TasksToTasksDto(tasks []Task) []TaskDto {
 	dto := make([]TaskDto, len(tasks))
 	for idx, task := range tasks {
@@ -38,3 +79,14 @@ func TasksToTasksDto(tasks []Task) []TaskDto {
 	}
 	return dto
 }
+
+func PostponedTasksToPostponedTasksDto(tasks []PostponedTask) []PostponedTaskDto {
+	dto := make([]PostponedTaskDto, len(tasks))
+	for idx, task := range tasks {
+		dto[idx] = PostponedTaskDto{
+			TaskDto:   TaskToTaskDto(task.Task),
+			Postponed: task.Postponed,
+		}
+	}
+	return dto
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+const (
+	TaskEventPostponed = "postponed"
+	TaskEventRolled    = "rolled"
+	TaskEventCompleted = "completed"
+)
+
+// Task lifecycle events published to subscribers, e.g. webhooks.
+const (
+	EventTaskCreated     = "task.created"
+	EventTaskUpdated     = "task.updated"
+	EventTaskCompleted   = "task.completed"
+	EventTaskRescheduled = "task.rescheduled"
+	EventTaskDeleted     = "task.deleted"
+)
+
+type TaskEvent struct {
+	ID        int
+	TaskID    int
+	TaskTitle string
+	Event     string
+	FromDate  time.Time
+	ToDate    time.Time
+	CreatedAt time.Time
+}
+
+type PostponedTask struct {
+	Task
+	Postponed int
+}
+
+// OutboxRecord is a serialized task lifecycle event stored together with the
+// change that caused it.
+type OutboxRecord struct {
+	ID        int64
+	Name      string
+	Payload   string
+	CreatedAt time.Time
+}
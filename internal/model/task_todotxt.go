@@ -0,0 +1,347 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+// Keys of todo.txt key:value tags. Values of note, project and custom fields
+// are percent-encoded, since a todo.txt value can't contain spaces.
+const (
+	TodoTxtKeyDue         = "due"
+	TodoTxtKeyRepeat      = "rec"
+	TodoTxtKeyEstimate    = "est"
+	TodoTxtKeyComment     = "note"
+	TodoTxtKeyPriority    = "pri"
+	TodoTxtFieldKeyPrefix = "field."
+)
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	todoTxtRec      = regexp.MustCompile(`^\+?(\d+)([dbwmy])$`)
+)
+
+// TodoTxtEntry is a parsed todo.txt line. Task is the API form of the task,
+// so that decoding it validates the line as an API request.
+type TodoTxtEntry struct {
+	Completed bool
+	Task      TaskDto
+	Warnings  []string
+}
+
+// TaskToTodoTxt formats the task as a todo.txt line. Priorities 3, 2 and 1
+// become (A), (B) and (C), the project becomes +project and tags become
+// @contexts; the date, repeat rule, estimate, comment and custom fields are
+// written as due:, rec:, est:, note: and field.<name>: tags.
+func TaskToTodoTxt(t Task) string {
+	var parts []string
+	if t.Priority != nil && *t.Priority > 0 {
+		parts = append(parts, "("+string(rune('A'+MaxPriority-*t.Priority))+")")
+	}
+	parts = append(parts, todoTxtTitle(t.Title)...)
+
+	if t.Project != nil && len(*t.Project) > 0 {
+		parts = append(parts, "+"+todoTxtEscape(*t.Project))
+	}
+	for _, tag := range t.Tags {
+		parts = append(parts, "@"+tag)
+	}
+
+	parts = append(parts, TodoTxtKeyDue+":"+t.Date.Format("2006-01-02"))
+	if len(t.Repeat) > 0 {
+		parts = append(parts, TodoTxtKeyRepeat+":"+todoTxtRepeat(t.Repeat))
+	}
+	if t.Estimate != nil && *t.Estimate > 0 {
+		parts = append(parts, TodoTxtKeyEstimate+":"+strconv.Itoa(*t.Estimate))
+	}
+	if len(t.Comment) > 0 {
+		parts = append(parts, TodoTxtKeyComment+":"+todoTxtEscape(t.Comment))
+	}
+
+	names := make([]string, 0, len(t.Fields))
+	for name, value := range t.Fields {
+		if len(value) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, TodoTxtFieldKeyPrefix+name+":"+todoTxtEscape(t.Fields[name]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ParseTodoTxt parses a todo.txt line. Projects, contexts and known tags are
+// taken out of the title; a second project stays in it. Priorities below (C)
+// become (C), and recurrences without a repeat rule are reported as warnings.
+func ParseTodoTxt(line string) (TodoTxtEntry, error) {
+	entry := TodoTxtEntry{}
+	tokens := strings.Fields(line)
+
+	if len(tokens) > 0 && tokens[0] == "x" {
+		entry.Completed = true
+		tokens = tokens[1:]
+		// A completed task starts with its completion date.
+		if len(tokens) > 0 && todoTxtDate.MatchString(tokens[0]) {
+			tokens = tokens[1:]
+		}
+	} else if len(tokens) > 0 && todoTxtPriority.MatchString(tokens[0]) {
+		entry.setPriority(tokens[0][1])
+		tokens = tokens[1:]
+	}
+	// The creation date has no task value.
+	if len(tokens) > 0 && todoTxtDate.MatchString(tokens[0]) {
+		tokens = tokens[1:]
+	}
+
+	var words []string
+	var rec string
+	var due time.Time
+	for _, token := range tokens {
+		switch {
+		case len(token) > 1 && token[0] == '+' && len(entry.Task.Project) == 0:
+			entry.Task.Project = todoTxtUnescape(token[1:])
+			continue
+		case len(token) > 1 && token[0] == '@':
+			entry.Task.Tags = append(entry.Task.Tags, token[1:])
+			continue
+		}
+
+		key, value, ok := strings.Cut(token, ":")
+		if !ok || len(key) == 0 || len(value) == 0 {
+			words = append(words, todoTxtUnescape(token))
+			continue
+		}
+
+		switch {
+		case key == TodoTxtKeyDue:
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return entry, errors.NewInvalidDateFormat("invalid due date: "+value, err)
+			}
+			due = date
+			entry.Task.Date = date.Format("20060102")
+		case key == TodoTxtKeyRepeat:
+			rec = value
+		case key == TodoTxtKeyEstimate:
+			estimate, err := strconv.Atoi(value)
+			if err != nil || estimate < 0 {
+				return entry, errors.NewInvalidEstimateFormat("invalid estimate: "+value, err)
+			}
+			entry.Task.Estimate = estimate
+		case key == TodoTxtKeyComment:
+			entry.Task.Comment = todoTxtUnescape(value)
+		case key == TodoTxtKeyPriority && len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z':
+			entry.setPriority(value[0])
+		case strings.HasPrefix(key, TodoTxtFieldKeyPrefix) && len(key) > len(TodoTxtFieldKeyPrefix):
+			if entry.Task.Fields == nil {
+				entry.Task.Fields = make(map[string]string)
+			}
+			entry.Task.Fields[key[len(TodoTxtFieldKeyPrefix):]] = todoTxtUnescape(value)
+		default:
+			words = append(words, todoTxtUnescape(token))
+		}
+	}
+	entry.Task.Title = strings.Join(words, " ")
+
+	if len(rec) > 0 {
+		repeat, err := parseTodoTxtRepeat(rec, due)
+		if err != nil {
+			entry.Warnings = append(entry.Warnings, fmt.Sprintf("rec:%s is not imported: %s", rec, err))
+		}
+		entry.Task.Repeat = repeat
+	}
+	return entry, nil
+}
+
+// TaskOn returns the task of the entry, validated like a restored backup
+// task, so that a past due date is kept as it is in the file. A line without
+// a due: tag is due today.
+func (e TodoTxtEntry) TaskOn(today time.Time) (Task, error) {
+	date := e.Task.Date
+	if len(date) == 0 {
+		date = today.Format("20060102")
+	}
+	return backupTask(BackupTaskDto{
+		ID:       "0",
+		Date:     date,
+		Title:    e.Task.Title,
+		Comment:  e.Task.Comment,
+		Repeat:   e.Task.Repeat,
+		Estimate: e.Task.Estimate,
+		Project:  e.Task.Project,
+		Priority: e.Task.Priority,
+		Tags:     e.Task.Tags,
+		Fields:   e.Task.Fields,
+	})
+}
+
+func (e *TodoTxtEntry) setPriority(letter byte) {
+	priority := MaxPriority - int(letter-'A')
+	if priority < 1 {
+		e.Warnings = append(e.Warnings, fmt.Sprintf("priority (%c) is imported as (C)", letter))
+		priority = 1
+	}
+	e.Task.Priority = priority
+}
+
+// todoTxtRepeat returns the rec: value of the repeat rule: the common form,
+// e.g. 3d or 1y, where there is one, else the rule with underscores for
+// spaces.
+func todoTxtRepeat(repeat string) string {
+	switch {
+	case repeat == "y":
+		return "1y"
+	case strings.HasPrefix(repeat, "d "):
+		return strings.TrimPrefix(repeat, "d ") + "d"
+	default:
+		return strings.ReplaceAll(repeat, " ", "_")
+	}
+}
+
+// parseTodoTxtRepeat maps a rec: value to a repeat rule. Strict recurrence
+// ("+1w") and the normal one are the same here: tasks always repeat from
+// their date.
+func parseTodoTxtRepeat(value string, due time.Time) (string, error) {
+	match := todoTxtRec.FindStringSubmatch(value)
+	if match == nil {
+		repeat := strings.ReplaceAll(value, "_", " ")
+		if valid, err := utils.ValidateRepeat(repeat); err != nil || !valid {
+			return "", fmt.Errorf("unknown recurrence")
+		}
+		return repeat, nil
+	}
+
+	count, err := strconv.Atoi(match[1])
+	if err != nil || count < 1 {
+		return "", fmt.Errorf("invalid interval")
+	}
+	switch match[2] {
+	case "d", "w":
+		if match[2] == "w" {
+			count *= 7
+		}
+		if count > 400 {
+			return "", fmt.Errorf("interval is longer than 400 days")
+		}
+		return "d " + strconv.Itoa(count), nil
+	case "m":
+		if count != 1 {
+			return "", fmt.Errorf("only monthly recurrence is supported")
+		}
+		if due.IsZero() {
+			return "", fmt.Errorf("monthly recurrence needs a due date")
+		}
+		return "m " + strconv.Itoa(due.Day()), nil
+	case "y":
+		if count != 1 {
+			return "", fmt.Errorf("only yearly recurrence is supported")
+		}
+		return "y", nil
+	default:
+		return "", fmt.Errorf("business days are not supported")
+	}
+}
+
+// todoTxtEscape percent-encodes whitespace and percent signs.
+func todoTxtEscape(value string) string {
+	return todoTxtEncode(value, func(r rune) bool {
+		return r == '%' || unicode.IsSpace(r)
+	})
+}
+
+// todoTxtEscapeSpace percent-encodes whitespace.
+func todoTxtEscapeSpace(value string) string {
+	return todoTxtEncode(value, unicode.IsSpace)
+}
+
+// todoTxtEncode percent-encodes the runes for which encode returns true.
+func todoTxtEncode(value string, encode func(r rune) bool) string {
+	var b strings.Builder
+	for _, r := range value {
+		if !encode(r) {
+			b.WriteRune(r)
+			continue
+		}
+		for _, c := range []byte(string(r)) {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// todoTxtTitle returns the words of the title. Words are split at single
+// spaces; further spaces and any other whitespace are percent-encoded, so
+// the title is read back as it is.
+func todoTxtTitle(title string) []string {
+	var words []string
+	spaces := ""
+	for _, word := range strings.Split(title, " ") {
+		if len(word) == 0 {
+			spaces += "%20"
+			continue
+		}
+		word = todoTxtEscapeSpace(todoTxtTitleWord(word, len(words) == 0 && len(spaces) == 0))
+		words = append(words, spaces+word)
+		spaces = ""
+	}
+	switch {
+	case len(spaces) == 0:
+	case len(words) == 0:
+		words = append(words, spaces)
+	default:
+		words[len(words)-1] += spaces
+	}
+	return words
+}
+
+// todoTxtTitleWord escapes a title word that would otherwise be read back as
+// todo.txt markup: a completion mark, priority or date at the start of the
+// line, a project, a context or a known key:value tag. The first character
+// of such a word, or its colon, is percent-encoded; so are percent signs of
+// words that would decode to something else.
+func todoTxtTitleWord(word string, first bool) string {
+	if todoTxtUnescape(word) != word {
+		word = strings.ReplaceAll(word, "%", "%25")
+	}
+	switch {
+	case first && (word == "x" || todoTxtPriority.MatchString(word) || todoTxtDate.MatchString(word)),
+		len(word) > 1 && (word[0] == '+' || word[0] == '@'):
+		return fmt.Sprintf("%%%02X", word[0]) + word[1:]
+	}
+	if key, value, ok := strings.Cut(word, ":"); ok && len(value) > 0 && todoTxtKnownKey(key) {
+		return key + "%3A" + value
+	}
+	return word
+}
+
+// todoTxtKnownKey reports whether ParseTodoTxt takes tags with the key out of
+// the title.
+func todoTxtKnownKey(key string) bool {
+	switch key {
+	case TodoTxtKeyDue, TodoTxtKeyRepeat, TodoTxtKeyEstimate, TodoTxtKeyComment, TodoTxtKeyPriority:
+		return true
+	}
+	return strings.HasPrefix(key, TodoTxtFieldKeyPrefix) && len(key) > len(TodoTxtFieldKeyPrefix)
+}
+
+// todoTxtUnescape decodes a percent-encoded value; values written by other
+// tools may contain a bare percent sign and are then taken as they are.
+func todoTxtUnescape(value string) string {
+	decoded, err := url.PathUnescape(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
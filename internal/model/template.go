@@ -0,0 +1,86 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+type Template struct {
+	ID        int
+	Name      string   `json:"name"`
+	Title     string   `json:"title"`
+	Comment   string   `json:"comment"`
+	Repeat    string   `json:"repeat"`
+	Checklist []string `json:"checklist"`
+	Tags      []string `json:"tags"`
+}
+
+func (t *Template) UnmarshalJSON(data []byte) error {
+	type TemplateAlias Template
+
+	aliasTemplate := &struct {
+		*TemplateAlias
+		ID string `json:"id"`
+	}{
+		TemplateAlias: (*TemplateAlias)(t),
+	}
+
+	if err := json.Unmarshal(data, aliasTemplate); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(aliasTemplate.ID)) != 0 {
+		id, err := strconv.Atoi(aliasTemplate.ID)
+		if err != nil {
+			return err
+		}
+		t.ID = id
+	}
+
+	t.Name = strings.TrimSpace(t.Name)
+	if len(t.Name) == 0 {
+		return errors.NewInvalidTemplateFormat("template name is empty", nil)
+	}
+
+	if len(strings.TrimSpace(t.Title)) == 0 {
+		return errors.NewInvalidTitleFormat("template title is empty", nil)
+	}
+
+	if len(strings.TrimSpace(t.Repeat)) != 0 {
+		isRepeatValid, err := utils.ValidateRepeat(t.Repeat)
+		if err != nil || !isRepeatValid {
+			return errors.NewInvalidRepeatFormat("invalid template repeat format", err)
+		}
+	}
+
+	checklist := make([]string, 0, len(t.Checklist))
+	for _, item := range t.Checklist {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		if strings.Contains(item, "\n") {
+			return errors.NewInvalidTemplateFormat("template checklist item must be a single line", nil)
+		}
+		checklist = append(checklist, item)
+	}
+	t.Checklist = checklist
+
+	tags, err := NormalizeTags(t.Tags)
+	if err != nil {
+		return err
+	}
+	t.Tags = tags
+
+	return nil
+}
+
+type TemplateInstance struct {
+	Date   string            `json:"date"`
+	Offset string            `json:"offset"`
+	Vars   map[string]string `json:"vars"`
+}
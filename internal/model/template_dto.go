@@ -0,0 +1,37 @@
+package model
+
+import "strconv"
+
+type TemplateDto struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Title     string   `json:"title"`
+	Comment   string   `json:"comment"`
+	Repeat    string   `json:"repeat"`
+	Checklist []string `json:"checklist"`
+	Tags      []string `json:"tags"`
+}
+
+type TemplatesDto struct {
+	Templates []TemplateDto `json:"templates"`
+}
+
+func TemplateToTemplateDto(template Template) TemplateDto {
+	return TemplateDto{
+		ID:        strconv.Itoa(template.ID),
+		Name:      template.Name,
+		Title:     template.Title,
+		Comment:   template.Comment,
+		Repeat:    template.Repeat,
+		Checklist: template.Checklist,
+		Tags:      template.Tags,
+	}
+}
+
+func TemplatesToTemplatesDto(templates []Template) []TemplateDto {
+	dto := make([]TemplateDto, len(templates))
+	for idx, template := range templates {
+		dto[idx] = TemplateToTemplateDto(template)
+	}
+	return dto
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+type TimeEntry struct {
+	ID        int
+	TaskID    int
+	TaskTitle string
+	Project   string
+	Tags      []string
+	Start     time.Time
+	Stop      *time.Time
+	Note      string
+}
+
+func (e TimeEntry) IsRunning() bool {
+	return e.Stop == nil
+}
+
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	if e.Stop == nil {
+		return now.Sub(e.Start)
+	}
+	return e.Stop.Sub(e.Start)
+}
+
+type TimeReportItem struct {
+	Key      string
+	Title    string
+	Duration time.Duration
+	Estimate int
+}
+
+type TimeReport struct {
+	From     time.Time
+	To       time.Time
+	Total    time.Duration
+	Tasks    []TimeReportItem
+	Days     []TimeReportItem
+	Tags     []TimeReportItem
+	Projects []TimeReportItem
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+type StartTimerDto struct {
+	Note string `json:"note"`
+}
+
+type TimeEntryDto struct {
+	ID       string `json:"id"`
+	TaskID   string `json:"task_id"`
+	Title    string `json:"title"`
+	Start    string `json:"start"`
+	Stop     string `json:"stop,omitempty"`
+	Duration int    `json:"duration"`
+	Running  bool   `json:"running"`
+	Note     string `json:"note"`
+}
+
+type TimeEntriesDto struct {
+	Entries []TimeEntryDto `json:"entries"`
+}
+
+type TimeReportItemDto struct {
+	Key      string `json:"key"`
+	Title    string `json:"title,omitempty"`
+	Duration int    `json:"duration"`
+	Estimate int    `json:"estimate,omitempty"`
+}
+
+type TimeReportDto struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Total    int                 `json:"total"`
+	Tasks    []TimeReportItemDto `json:"tasks"`
+	Days     []TimeReportItemDto `json:"days"`
+	Tags     []TimeReportItemDto `json:"tags"`
+	Projects []TimeReportItemDto `json:"projects"`
+}
+
+func TimeEntryToTimeEntryDto(entry TimeEntry, now time.Time) TimeEntryDto {
+	dto := TimeEntryDto{
+		ID:       strconv.Itoa(entry.ID),
+		Title:    entry.TaskTitle,
+		Start:    entry.Start.Format(time.RFC3339),
+		Duration: int(entry.Duration(now).Seconds()),
+		Running:  entry.IsRunning(),
+		Note:     entry.Note,
+	}
+	if entry.TaskID != 0 {
+		dto.TaskID = strconv.Itoa(entry.TaskID)
+	}
+	if entry.Stop != nil {
+		dto.Stop = entry.Stop.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func TimeEntriesToTimeEntriesDto(entries []TimeEntry, now time.Time) []TimeEntryDto {
+	dto := make([]TimeEntryDto, len(entries))
+	for idx, entry := range entries {
+		dto[idx] = TimeEntryToTimeEntryDto(entry, now)
+	}
+	return dto
+}
+
+func TimeReportToTimeReportDto(report TimeReport) TimeReportDto {
+	return TimeReportDto{
+		From:     report.From.Format("20060102"),
+		To:       report.To.Format("20060102"),
+		Total:    int(report.Total.Seconds()),
+		Tasks:    timeReportItemsToDto(report.Tasks),
+		Days:     timeReportItemsToDto(report.Days),
+		Tags:     timeReportItemsToDto(report.Tags),
+		Projects: timeReportItemsToDto(report.Projects),
+	}
+}
+
+func timeReportItemsToDto(items []TimeReportItem) []TimeReportItemDto {
+	dto := make([]TimeReportItemDto, len(items))
+	for idx, item := range items {
+		dto[idx] = TimeReportItemDto{
+			Key:      item.Key,
+			Title:    item.Title,
+			Duration: int(item.Duration.Seconds()),
+			Estimate: item.Estimate,
+		}
+	}
+	return dto
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+)
+
+const DeliveryPending = "pending"
+
+var taskEvents = map[string]bool{
+	EventTaskCreated:     true,
+	EventTaskUpdated:     true,
+	EventTaskCompleted:   true,
+	EventTaskRescheduled: true,
+	EventTaskDeleted:     true,
+}
+
+// WebhookSubscription receives task events posted to URL and signed with
+// Secret. Empty Events means all events.
+type WebhookSubscription struct {
+	ID      int
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+}
+
+func (w *WebhookSubscription) UnmarshalJSON(data []byte) error {
+	type WebhookSubscriptionAlias WebhookSubscription
+
+	aliasSubscription := &struct {
+		*WebhookSubscriptionAlias
+		ID      string `json:"id"`
+		Enabled *bool  `json:"enabled"`
+	}{
+		WebhookSubscriptionAlias: (*WebhookSubscriptionAlias)(w),
+	}
+
+	if err := json.Unmarshal(data, aliasSubscription); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(aliasSubscription.ID)) != 0 {
+		id, err := strconv.Atoi(aliasSubscription.ID)
+		if err != nil {
+			return err
+		}
+		w.ID = id
+	}
+
+	w.Enabled = aliasSubscription.Enabled == nil || *aliasSubscription.Enabled
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.NewInvalidWebhookSubscription("invalid webhook url: "+w.URL, err)
+	}
+
+	w.Secret = strings.TrimSpace(w.Secret)
+	if len(w.Secret) > 128 {
+		return errors.NewInvalidWebhookSubscription("webhook secret is too long", nil)
+	}
+
+	events := make(map[string]bool, len(w.Events))
+	for _, event := range w.Events {
+		event = strings.TrimSpace(event)
+		if !taskEvents[event] {
+			return errors.NewInvalidWebhookSubscription("unknown webhook event: "+event, nil)
+		}
+		events[event] = true
+	}
+	w.Events = make([]string, 0, len(events))
+	for event := range events {
+		w.Events = append(w.Events, event)
+	}
+	sort.Strings(w.Events)
+
+	return nil
+}
+
+func (w WebhookSubscription) Accepts(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NewWebhookSecret returns a random secret for signing payloads.
+func NewWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type WebhookDelivery struct {
+	ID             int
+	SubscriptionID int
+	EventID        int64
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int
+	ResponseStatus int
+	Error          string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+type WebhookSubscriptionDto struct {
+	ID      string   `json:"id"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+}
+
+type WebhookSubscriptionsDto struct {
+	Webhooks []WebhookSubscriptionDto `json:"webhooks"`
+}
+
+type WebhookDeliveryDto struct {
+	ID             string `json:"id"`
+	EventID        string `json:"event_id"`
+	Event          string `json:"event"`
+	Payload        string `json:"payload"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	ResponseStatus int    `json:"response_status,omitempty"`
+	Error          string `json:"error,omitempty"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+type WebhookDeliveriesDto struct {
+	Deliveries []WebhookDeliveryDto `json:"deliveries"`
+}
+
+// TaskEventPayloadDto is the body posted to webhook subscribers.
+type TaskEventPayloadDto struct {
+	Event     string  `json:"event"`
+	CreatedAt string  `json:"created_at"`
+	Task      TaskDto `json:"task"`
+	From      string  `json:"from,omitempty"`
+}
+
+func WebhookSubscriptionToWebhookSubscriptionDto(w WebhookSubscription) WebhookSubscriptionDto {
+	return WebhookSubscriptionDto{
+		ID:      strconv.Itoa(w.ID),
+		URL:     w.URL,
+		Secret:  w.Secret,
+		Events:  w.Events,
+		Enabled: w.Enabled,
+	}
+}
+
+func WebhookSubscriptionsToWebhookSubscriptionsDto(webhooks []WebhookSubscription) []WebhookSubscriptionDto {
+	dto := make([]WebhookSubscriptionDto, len(webhooks))
+	for idx, w := range webhooks {
+		dto[idx] = WebhookSubscriptionToWebhookSubscriptionDto(w)
+	}
+	return dto
+}
+
+func WebhookDeliveryToWebhookDeliveryDto(d WebhookDelivery) WebhookDeliveryDto {
+	dto := WebhookDeliveryDto{
+		ID:             strconv.Itoa(d.ID),
+		EventID:        strconv.FormatInt(d.EventID, 10),
+		Event:          d.Event,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      d.UpdatedAt.Format(time.RFC3339),
+	}
+	if d.Status == DeliveryPending {
+		dto.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func WebhookDeliveriesToWebhookDeliveriesDto(deliveries []WebhookDelivery) []WebhookDeliveryDto {
+	dto := make([]WebhookDeliveryDto, len(deliveries))
+	for idx, d := range deliveries {
+		dto[idx] = WebhookDeliveryToWebhookDeliveryDto(d)
+	}
+	return dto
+}
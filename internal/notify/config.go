@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"strings"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/config"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// ChannelsFromConfig returns the channels set up with environment variables.
+// They are always enabled and can't be changed through the API.
+func ChannelsFromConfig(c *config.ServerConfig) ([]model.NotificationChannel, error) {
+	var channels []model.NotificationChannel
+
+	if len(c.WebhookURL) > 0 {
+		channels = append(channels, model.NotificationChannel{
+			Kind: model.ChannelWebhook,
+			URL:  c.WebhookURL,
+		})
+	}
+
+	if len(c.SMTPHost) > 0 {
+		channels = append(channels, model.NotificationChannel{
+			Kind:     model.ChannelEmail,
+			Host:     c.SMTPHost,
+			Port:     c.SMTPPort,
+			Username: c.SMTPUsername,
+			Password: c.SMTPPassword,
+			From:     c.SMTPFrom,
+			To:       splitAddresses(c.SMTPTo),
+		})
+	}
+
+	if len(c.PushURL) > 0 {
+		channels = append(channels, model.NotificationChannel{
+			Kind:   model.ChannelPush,
+			URL:    c.PushURL,
+			Token:  c.PushToken,
+			Format: c.PushFormat,
+		})
+	}
+
+	for idx := range channels {
+		channels[idx].Name = channels[idx].Kind
+		channels[idx].Enabled = true
+		if err := channels[idx].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// DigestMailerFromConfig returns the mailer for the daily digest, sent to
+// TODO_DIGEST_TO or, if it is empty, to TODO_SMTP_TO. It returns nil when
+// SMTP is not configured.
+func DigestMailerFromConfig(c *config.ServerConfig) (*EmailNotifier, error) {
+	if len(c.SMTPHost) == 0 {
+		return nil, nil
+	}
+
+	to := splitAddresses(c.DigestTo)
+	if len(to) == 0 {
+		to = splitAddresses(c.SMTPTo)
+	}
+	channel := model.NotificationChannel{
+		Kind:     model.ChannelEmail,
+		Host:     c.SMTPHost,
+		Port:     c.SMTPPort,
+		Username: c.SMTPUsername,
+		Password: c.SMTPPassword,
+		From:     c.SMTPFrom,
+		To:       to,
+	}
+	if err := channel.Validate(); err != nil {
+		return nil, err
+	}
+	return NewEmailNotifier(channel.Host, channel.Port, channel.Username, channel.Password, channel.From, channel.To), nil
+}
+
+func splitAddresses(value string) []string {
+	var addresses []string
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); len(addr) > 0 {
+			addresses = append(addresses, addr)
+		}
+	}
+	return addresses
+}
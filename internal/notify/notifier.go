@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// Notifier delivers notifications to a channel, e.g. a webhook or an email.
+type Notifier interface {
+	Notify(ctx context.Context, n model.Notification) error
+}
+
+// LogNotifier writes notifications to the log. It is used when no delivery
+// channel is configured.
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+func NewLogNotifier(logger *zap.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	n.logger.Info("notification", zap.String("title", notification.Title),
+		zap.String("message", notification.Message), zap.Int("task_id", notification.TaskID))
+	return nil
+}
+
+// New returns the notifier delivering to the channel.
+func New(c model.NotificationChannel) Notifier {
+	switch c.Kind {
+	case model.ChannelEmail:
+		return NewEmailNotifier(c.Host, c.Port, c.Username, c.Password, c.From, c.To)
+	case model.ChannelPush:
+		return NewPushNotifier(c.URL, c.Token, c.Format)
+	default:
+		return NewWebhookNotifier(c.URL)
+	}
+}
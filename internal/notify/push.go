@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// PushNotifier sends notifications to an ntfy topic URL, e.g.
+// https://ntfy.sh/todo, or to a Gotify server.
+type PushNotifier struct {
+	url    string
+	token  string
+	format string
+	client *http.Client
+}
+
+func NewPushNotifier(url string, token string, format string) *PushNotifier {
+	return &PushNotifier{url: url, token: token, format: format, client: &http.Client{Timeout: requestTimeout}}
+}
+
+func (n *PushNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	req, err := n.request(ctx, notification)
+	if err != nil {
+		return err
+	}
+	return send(n.client, req)
+}
+
+func (n *PushNotifier) request(ctx context.Context, notification model.Notification) (*http.Request, error) {
+	if n.format == model.PushFormatGotify {
+		body, err := json.Marshal(map[string]any{
+			"title":    notification.Title,
+			"message":  notification.Message,
+			"priority": 5,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		url := strings.TrimRight(n.url, "/") + "/message"
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+		req.Header.Set("X-Gotify-Key", n.token)
+		return req, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, strings.NewReader(notification.Message))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=UTF-8")
+	req.Header.Set("Title", notification.Title)
+	if len(n.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	return req, nil
+}
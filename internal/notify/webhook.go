@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const requestTimeout = 10 * time.Second
+
+type webhookPayload struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	TaskID  int    `json:"task_id,omitempty"`
+	SentAt  string `json:"sent_at"`
+}
+
+// WebhookNotifier posts notifications as JSON to an URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: requestTimeout}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		Title:   notification.Title,
+		Message: notification.Message,
+		TaskID:  notification.TaskID,
+		SentAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	return send(n.client, req)
+}
+
+// send performs the request and treats any non 2xx response as an error.
+func send(client *http.Client, req *http.Request) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		text, _ := io.ReadAll(io.LimitReader(res.Body, 256))
+		return fmt.Errorf("unexpected response status %d: %s", res.StatusCode, bytes.TrimSpace(text))
+	}
+	return nil
+}
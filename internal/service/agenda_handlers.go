@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+// GetAgendaHandler returns all tasks grouped into agenda buckets. The date
+// parameter selects the day the agenda is seen on, today by default.
+func (s *Server) GetAgendaHandler(res http.ResponseWriter, req *http.Request) {
+	today := s.AgendaService.Today(time.Now())
+	if date := req.FormValue("date"); len(date) > 0 {
+		parsed, err := utils.ParseDate(date)
+		if err != nil {
+			s.Logger.Error("Error parsing agenda date", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, err.Error())
+			return
+		}
+		today = parsed
+	}
+
+	agenda, err := s.AgendaService.GetBuckets(today)
+	if err != nil {
+		s.Logger.Error("Error getting agenda", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	agendaDto := model.AgendaBucketsToAgendaDto(agenda)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(agendaDto); err != nil {
+		s.Logger.Error("Error encoding get agenda response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ExportAgendaHandler renders the tasks from the from date to the to date
+// for printing. The format parameter selects html (default) or markdown.
+func (s *Server) ExportAgendaHandler(res http.ResponseWriter, req *http.Request) {
+	format := req.FormValue("format")
+	if len(format) == 0 {
+		format = AgendaFormatHTML
+	}
+
+	contentType := "text/html; charset=UTF-8"
+	switch format {
+	case AgendaFormatHTML:
+	case AgendaFormatMarkdown:
+		contentType = "text/markdown; charset=UTF-8"
+	default:
+		sendTaskError(res, http.StatusBadRequest, "unknown agenda format: "+format)
+		return
+	}
+
+	today := s.AgendaService.Today(time.Now())
+	from, to, err := parseDateRange(today, req.FormValue("from"), req.FormValue("to"))
+	if err != nil {
+		s.Logger.Error("Error parsing agenda range", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	agenda, err := s.AgendaService.BuildAgenda(from, to)
+	if err != nil {
+		s.Logger.Error("Error building agenda", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	body, err := s.AgendaService.RenderAgenda(agenda, format)
+	if err != nil {
+		s.Logger.Error("Error rendering agenda", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	if _, err := res.Write([]byte(body)); err != nil {
+		s.Logger.Error("Error writing agenda response", zap.Error(err))
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+const (
+	AgendaFormatHTML     = "html"
+	AgendaFormatMarkdown = "markdown"
+
+	// Date ranges of the agenda and calendar cover a week by default and a
+	// quarter at most.
+	defaultRangeDays = 7
+	maxRangeDays     = 92
+)
+
+var agendaMarkdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`, "|", `\|`)
+
+var agendaFuncs = map[string]any{
+	"date": func(t time.Time) string {
+		return t.Format("02.01.2006")
+	},
+	"weekday": func(t time.Time) string {
+		return t.Weekday().String()
+	},
+	"project": taskProject,
+	// md escapes text for a Markdown line; lines of a comment are quoted
+	// under the task.
+	"md": func(text string) string {
+		return agendaMarkdownEscaper.Replace(strings.Join(strings.Fields(text), " "))
+	},
+	"mdquote": func(text string) string {
+		lines := strings.Split(strings.TrimSpace(text), "\n")
+		for idx, line := range lines {
+			lines[idx] = "  > " + agendaMarkdownEscaper.Replace(strings.TrimSpace(line))
+		}
+		return strings.Join(lines, "\n")
+	},
+}
+
+// AgendaService groups tasks by when they are due and renders the tasks of
+// a date range for printing. Days are those of location, and weeks start on
+// weekStart.
+type AgendaService struct {
+	store     storage.TaskStore
+	html      *htmltemplate.Template
+	markdown  *texttemplate.Template
+	location  *time.Location
+	weekStart time.Weekday
+	logger    *zap.Logger
+}
+
+// NewAgendaService loads the agenda.html and agenda.md templates from
+// templatesPath.
+func NewAgendaService(store storage.TaskStore, templatesPath string, location *time.Location,
+	weekStart time.Weekday, logger *zap.Logger) (*AgendaService, error) {
+	html, err := htmltemplate.New("agenda.html").Funcs(agendaFuncs).
+		ParseFiles(filepath.Join(templatesPath, "agenda.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	markdown, err := texttemplate.New("agenda.md").Funcs(agendaFuncs).
+		ParseFiles(filepath.Join(templatesPath, "agenda.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &AgendaService{store: store, html: html, markdown: markdown, location: location, weekStart: weekStart,
+		logger: logger}, nil
+}
+
+// Today returns the current day in the location of the service.
+func (s AgendaService) Today(now time.Time) time.Time {
+	return utils.Today(now, s.location)
+}
+
+// GetBuckets returns all tasks grouped into agenda buckets as seen on today,
+// see model.NewAgendaBuckets.
+func (s AgendaService) GetBuckets(today time.Time) (model.AgendaBuckets, error) {
+	tasks, err := s.store.GetAll()
+	if err != nil {
+		return model.AgendaBuckets{}, err
+	}
+	return model.NewAgendaBuckets(today, s.location, s.weekStart, tasks), nil
+}
+
+// parseDateRange returns the dates of a from/to request: from defaults to
+// today and to to a week from it.
+func parseDateRange(today time.Time, from string, to string) (time.Time, time.Time, error) {
+	start := today
+	if len(from) > 0 {
+		var err error
+		if start, err = utils.ParseDate(from); err != nil {
+			return start, start, err
+		}
+	}
+
+	end := start.AddDate(0, 0, defaultRangeDays-1)
+	if len(to) > 0 {
+		var err error
+		if end, err = utils.ParseDate(to); err != nil {
+			return start, end, err
+		}
+	}
+
+	if end.Before(start) {
+		return start, end, fmt.Errorf("end date is before start date")
+	}
+	if end.Sub(start) >= maxRangeDays*24*time.Hour {
+		return start, end, fmt.Errorf("date range can't be longer than %d days", maxRangeDays)
+	}
+	return start, end, nil
+}
+
+// BuildAgenda collects the tasks from from to to by day. Recurring tasks are
+// also shown on their later occurrences, including tasks that are overdue.
+func (s AgendaService) BuildAgenda(from time.Time, to time.Time) (model.Agenda, error) {
+	tasks, err := s.store.GetAllBefore(to.AddDate(0, 0, 1).Format("20060102"))
+	if err != nil {
+		return model.Agenda{}, err
+	}
+
+	days := int(to.Sub(from).Hours()/24) + 1
+	byDate := make(map[time.Time][]model.AgendaEntry, days)
+	for _, t := range tasks {
+		for _, date := range taskDates(t, from, to, days) {
+			byDate[date] = append(byDate[date], model.AgendaEntry{
+				Task:       t,
+				Projected:  !date.Equal(t.Date),
+				RepeatText: utils.DescribeRepeat(t.Repeat),
+			})
+		}
+	}
+
+	agenda := model.Agenda{From: from, To: to, Days: make([]model.AgendaDay, 0, days)}
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		entries := byDate[date]
+		// Stored tasks come first, then by priority.
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Projected != entries[j].Projected {
+				return !entries[i].Projected
+			}
+			return priorityOf(entries[i].Task) > priorityOf(entries[j].Task)
+		})
+		agenda.Days = append(agenda.Days, model.AgendaDay{Date: date, Entries: entries})
+	}
+	return agenda, nil
+}
+
+func (s AgendaService) RenderAgenda(a model.Agenda, format string) (string, error) {
+	var b strings.Builder
+	var err error
+	switch format {
+	case AgendaFormatHTML:
+		err = s.html.Execute(&b, a)
+	case AgendaFormatMarkdown:
+		err = s.markdown.Execute(&b, a)
+	default:
+		return "", fmt.Errorf("unknown agenda format: %s", format)
+	}
+	return b.String(), err
+}
+
+func priorityOf(t model.Task) int {
+	if t.Priority == nil {
+		return 0
+	}
+	return *t.Priority
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
@@ -11,16 +12,25 @@ func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		rootPassword := s.Config.RootPassword
 
-		if len(strings.TrimSpace(rootPassword)) > 0 {
-			var jwt string
-			cookie, err := r.Cookie("token")
-			if err == nil {
-				jwt = cookie.Value
-			}
+		if len(strings.TrimSpace(rootPassword)) > 0 && !hasAuthToken(r, rootPassword) {
+			http.Error(w, "Authentification required", http.StatusUnauthorized)
+			return
+		}
 
-			err = utils.CompareHash(rootPassword, jwt)
+		next.ServeHTTP(w, r)
+	})
+}
 
-			if err != nil {
+// CalDAVAuthMiddleware also accepts the password sent with HTTP basic
+// authentication, since CalDAV clients can't sign in to get the token.
+func (s *Server) CalDAVAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rootPassword := s.Config.RootPassword
+
+		if len(strings.TrimSpace(rootPassword)) > 0 && !hasAuthToken(r, rootPassword) {
+			_, password, ok := r.BasicAuth()
+			if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(rootPassword)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="go_task_manager", charset="UTF-8"`)
 				http.Error(w, "Authentification required", http.StatusUnauthorized)
 				return
 			}
@@ -29,3 +39,13 @@ func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+func hasAuthToken(r *http.Request, rootPassword string) bool {
+	var jwt string
+	cookie, err := r.Cookie("token")
+	if err == nil {
+		jwt = cookie.Value
+	}
+
+	return utils.CompareHash(rootPassword, jwt) == nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) GetBackupHandler(res http.ResponseWriter, req *http.Request) {
+	now := time.Now()
+	b, err := s.BackupService.Backup(now)
+	if err != nil {
+		s.Logger.Error("Error reading backup", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	res.Header().Set("Content-Disposition", `attachment; filename="tasks-backup-`+now.Format("20060102")+`.json"`)
+	enc := json.NewEncoder(res)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(model.BackupToBackupDto(b)); err != nil {
+		s.Logger.Error("Error encoding backup response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreBackupHandler restores a backup sent as the request body or the
+// "file" field of a multipart form. The mode parameter is merge or replace.
+func (s *Server) RestoreBackupHandler(res http.ResponseWriter, req *http.Request) {
+	dryRun, file, ok := s.importRequest(res, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		s.Logger.Error("Error reading backup file", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	b, err := model.ParseBackup(data)
+	if err != nil {
+		s.Logger.Error("Error parsing backup file", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	report, err := s.BackupService.Restore(b, req.URL.Query().Get("mode"), dryRun, time.Now())
+	if err != nil {
+		s.Logger.Error("Error restoring backup", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(model.RestoreReportToRestoreReportDto(report)); err != nil {
+		s.Logger.Error("Error encoding restore response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+// BackupEventSource marks events of restored backups. Events with this
+// source do not trigger rules: what rules did is in the backup already.
+const BackupEventSource = "backup"
+
+type BackupService struct {
+	store     storage.BackupStore
+	taskStore storage.TaskStore
+	outbox    *events.Outbox
+	location  *time.Location
+	logger    *zap.Logger
+}
+
+// NewBackupService returns a service that reads times of restored reminders
+// in location.
+func NewBackupService(store storage.BackupStore, taskStore storage.TaskStore, outbox *events.Outbox,
+	location *time.Location, logger *zap.Logger) *BackupService {
+	return &BackupService{store: store, taskStore: taskStore, outbox: outbox, location: location, logger: logger}
+}
+
+func (s BackupService) Backup(now time.Time) (model.Backup, error) {
+	b, err := s.store.Read()
+	b.CreatedAt = now
+	return b, err
+}
+
+// Restore restores the backup in the mode, merge when it is empty. Nothing
+// is changed unless the whole backup is restored. Subscribers receive
+// task.deleted for every task removed by a replace and task.created for
+// every restored task.
+func (s BackupService) Restore(b model.Backup, mode string, dryRun bool,
+	now time.Time) (model.RestoreReport, error) {
+	if len(mode) == 0 {
+		mode = model.RestoreModeMerge
+	}
+	if mode != model.RestoreModeMerge && mode != model.RestoreModeReplace {
+		return model.RestoreReport{}, errors.NewInvalidBackup("unknown restore mode: "+mode, nil)
+	}
+
+	report, err := s.store.Restore(b, mode, dryRun, now.In(s.location), s.recordEvents(now))
+	if err != nil {
+		return report, err
+	}
+	if !dryRun {
+		s.outbox.Wake()
+		s.logger.Info("Backup restored", zap.String("mode", mode), zap.Int("tasks", report.Tasks),
+			zap.Int("warnings", len(report.Warnings)))
+	}
+	return report, nil
+}
+
+// recordEvents returns the callback recording the events of a restore in
+// its transaction.
+func (s BackupService) recordEvents(now time.Time) func(tx *sql.Tx, removed []model.Task, restored []int) error {
+	return func(tx *sql.Tx, removed []model.Task, restored []int) error {
+		store := s.taskStore.WithTx(tx)
+		for _, t := range removed {
+			err := s.outbox.Record(store, events.TaskDeleted{TaskEvent: events.TaskEvent{OccurredAt: now,
+				Source: BackupEventSource, Task: t}})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, id := range restored {
+			t, err := store.GetByID(id)
+			if err != nil {
+				return err
+			}
+			postponed, err := store.CountEvents(id, model.TaskEventPostponed)
+			if err != nil {
+				return err
+			}
+			err = s.outbox.Record(store, events.TaskCreated{TaskEvent: events.TaskEvent{OccurredAt: now,
+				Source: BackupEventSource, Task: t, Postponed: postponed}})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
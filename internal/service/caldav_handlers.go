@@ -0,0 +1,535 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const (
+	// The principal is also the calendar home, which holds the only calendar.
+	CalDAVRootPath     = "/caldav/"
+	calDAVCalendarPath = CalDAVRootPath + "tasks/"
+
+	calDAVPrincipalName = "Task manager"
+	calDAVCalendarName  = "Tasks"
+	calDAVMethods       = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+	maxCalDAVObjectSize = 1 << 20
+)
+
+const (
+	calDAVKindPrincipal = iota
+	calDAVKindCalendar
+	calDAVKindObject
+)
+
+var (
+	calDAVResourceType      = xml.Name{Space: model.DAVNamespace, Local: "resourcetype"}
+	calDAVDisplayName       = xml.Name{Space: model.DAVNamespace, Local: "displayname"}
+	calDAVUserPrincipal     = xml.Name{Space: model.DAVNamespace, Local: "current-user-principal"}
+	calDAVPrincipalURL      = xml.Name{Space: model.DAVNamespace, Local: "principal-URL"}
+	calDAVPrivilegeSet      = xml.Name{Space: model.DAVNamespace, Local: "current-user-privilege-set"}
+	calDAVReportSet         = xml.Name{Space: model.DAVNamespace, Local: "supported-report-set"}
+	calDAVETag              = xml.Name{Space: model.DAVNamespace, Local: "getetag"}
+	calDAVContentType       = xml.Name{Space: model.DAVNamespace, Local: "getcontenttype"}
+	calDAVContentLength     = xml.Name{Space: model.DAVNamespace, Local: "getcontentlength"}
+	calDAVHomeSet           = xml.Name{Space: model.CalDAVNamespace, Local: "calendar-home-set"}
+	calDAVComponentSet      = xml.Name{Space: model.CalDAVNamespace, Local: "supported-calendar-component-set"}
+	calDAVCalendarData      = xml.Name{Space: model.CalDAVNamespace, Local: "calendar-data"}
+	calDAVCalendarQuery     = xml.Name{Space: model.CalDAVNamespace, Local: "calendar-query"}
+	calDAVMultiget          = xml.Name{Space: model.CalDAVNamespace, Local: "calendar-multiget"}
+	calDAVCTag              = xml.Name{Space: model.CalendarServerNamespace, Local: "getctag"}
+	calDAVNamespacePrefixes = map[string]string{
+		model.DAVNamespace:            "D",
+		model.CalDAVNamespace:         "C",
+		model.CalendarServerNamespace: "CS",
+	}
+)
+
+// calDAVProp is a property of a resource; value is its XML content.
+type calDAVProp struct {
+	name  xml.Name
+	value string
+}
+
+func (s *Server) CalDAVOptionsHandler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("DAV", "1, 3, calendar-access")
+	res.Header().Set("Allow", calDAVMethods)
+	res.WriteHeader(http.StatusOK)
+}
+
+// CalDAVPropfindHandler lists the properties of the principal, the calendar
+// and its objects. Depth 0 returns only the requested resource, any other
+// depth its members too.
+func (s *Server) CalDAVPropfindHandler(res http.ResponseWriter, req *http.Request) {
+	kind, name, ok := calDAVPath(req.URL.Path)
+	if !ok {
+		http.NotFound(res, req)
+		return
+	}
+
+	request, ok := s.calDAVRequest(res, req)
+	if !ok {
+		return
+	}
+	members := req.Header.Get("Depth") != "0"
+
+	ms := newCalDAVMultistatus()
+	switch kind {
+	case calDAVKindObject:
+		o, err := s.CalDAVService.GetObject(name)
+		if err != nil {
+			s.sendCalDAVError(res, err)
+			return
+		}
+		ms.response(calDAVObjectHref(o.Name), calDAVObjectProps(o), request)
+	case calDAVKindPrincipal, calDAVKindCalendar:
+		objects, err := s.CalDAVService.GetObjects()
+		if err != nil {
+			s.sendCalDAVError(res, err)
+			return
+		}
+
+		if kind == calDAVKindPrincipal {
+			ms.response(CalDAVRootPath, calDAVPrincipalProps(), request)
+			if members {
+				ms.response(calDAVCalendarPath, calDAVCalendarProps(CalDAVCTag(objects)), request)
+			}
+			break
+		}
+
+		ms.response(calDAVCalendarPath, calDAVCalendarProps(CalDAVCTag(objects)), request)
+		if members {
+			for _, o := range objects {
+				ms.response(calDAVObjectHref(o.Name), calDAVObjectProps(o), request)
+			}
+		}
+	}
+
+	s.sendCalDAVMultistatus(res, ms)
+}
+
+// CalDAVReportHandler answers calendar-query and calendar-multiget reports
+// on the calendar. Time ranges are matched against task dates; recurring
+// tasks match any range that ends after their date.
+func (s *Server) CalDAVReportHandler(res http.ResponseWriter, req *http.Request) {
+	kind, _, ok := calDAVPath(req.URL.Path)
+	if !ok {
+		http.NotFound(res, req)
+		return
+	}
+	if kind != calDAVKindCalendar {
+		http.Error(res, "Reports are supported on the calendar only", http.StatusForbidden)
+		return
+	}
+
+	request, ok := s.calDAVRequest(res, req)
+	if !ok {
+		return
+	}
+	if request.Prop == nil && request.AllProp == nil && request.PropName == nil {
+		request.Prop = &model.CalDAVPropNamesDto{Names: []model.CalDAVPropNameDto{{XMLName: calDAVETag}}}
+	}
+
+	objects, err := s.CalDAVService.GetObjects()
+	if err != nil {
+		s.sendCalDAVError(res, err)
+		return
+	}
+
+	ms := newCalDAVMultistatus()
+	switch request.XMLName {
+	case calDAVCalendarQuery:
+		for _, o := range objects {
+			if matchCalDAVFilter(o.Task, request.Filter) {
+				ms.response(calDAVObjectHref(o.Name), calDAVObjectProps(o), request)
+			}
+		}
+	case calDAVMultiget:
+		byName := make(map[string]model.CalDAVObject, len(objects))
+		for _, o := range objects {
+			byName[o.Name] = o
+		}
+		for _, href := range request.Hrefs {
+			href = strings.TrimSpace(href)
+			o, found := byName[calDAVHrefName(href)]
+			if !found {
+				ms.status(href, http.StatusNotFound)
+				continue
+			}
+			ms.response(href, calDAVObjectProps(o), request)
+		}
+	default:
+		http.Error(res, "Unsupported report: "+request.XMLName.Local, http.StatusForbidden)
+		return
+	}
+
+	s.sendCalDAVMultistatus(res, ms)
+}
+
+func (s *Server) GetCalDAVObjectHandler(res http.ResponseWriter, req *http.Request) {
+	kind, name, ok := calDAVPath(req.URL.Path)
+	if !ok {
+		http.NotFound(res, req)
+		return
+	}
+	if kind != calDAVKindObject {
+		res.Header().Set("Allow", "OPTIONS, PROPFIND, REPORT")
+		http.Error(res, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	o, err := s.CalDAVService.GetObject(name)
+	if err != nil {
+		s.sendCalDAVError(res, err)
+		return
+	}
+
+	res.Header().Set("ETag", o.ETag)
+	if ifNoneMatch := req.Header.Get("If-None-Match"); len(ifNoneMatch) > 0 && matchETag(ifNoneMatch, o.ETag) {
+		res.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	res.Header().Set("Content-Type", "text/calendar; charset=UTF-8")
+	res.Header().Set("Content-Length", strconv.Itoa(len(o.Data)))
+	if _, err := res.Write(o.Data); err != nil {
+		s.Logger.Error("Error writing CalDAV object response", zap.Error(err))
+	}
+}
+
+// PutCalDAVObjectHandler creates or updates a task. The stored object is
+// rendered from the task and differs from the sent one, so no ETag is
+// returned and clients fetch the object again.
+func (s *Server) PutCalDAVObjectHandler(res http.ResponseWriter, req *http.Request) {
+	kind, name, ok := calDAVPath(req.URL.Path)
+	if !ok || kind != calDAVKindObject {
+		res.Header().Set("Allow", "OPTIONS, PROPFIND, REPORT")
+		http.Error(res, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(res, req.Body, maxCalDAVObjectSize))
+	if err != nil {
+		s.Logger.Error("Error reading CalDAV object", zap.Error(err))
+		http.Error(res, "Calendar object is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	created, err := s.CalDAVService.PutObject(name, data, req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"), time.Now())
+	if err != nil {
+		s.sendCalDAVError(res, err)
+		return
+	}
+
+	if created {
+		res.WriteHeader(http.StatusCreated)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) DeleteCalDAVObjectHandler(res http.ResponseWriter, req *http.Request) {
+	kind, name, ok := calDAVPath(req.URL.Path)
+	if !ok || kind != calDAVKindObject {
+		http.Error(res, "Only calendar objects can be deleted", http.StatusForbidden)
+		return
+	}
+
+	if err := s.CalDAVService.DeleteObject(name, req.Header.Get("If-Match")); err != nil {
+		s.sendCalDAVError(res, err)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// calDAVRequest parses the XML body of PROPFIND and REPORT requests. An
+// empty body asks for all properties.
+func (s *Server) calDAVRequest(res http.ResponseWriter, req *http.Request) (model.CalDAVRequestDto, bool) {
+	request := model.CalDAVRequestDto{}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(http.MaxBytesReader(res, req.Body, maxCalDAVObjectSize)); err != nil {
+		s.Logger.Error("Error reading CalDAV request", zap.Error(err))
+		http.Error(res, "Invalid request body", http.StatusBadRequest)
+		return request, false
+	}
+
+	if len(bytes.TrimSpace(buf.Bytes())) == 0 {
+		request.AllProp = &struct{}{}
+		return request, true
+	}
+
+	if err := xml.Unmarshal(buf.Bytes(), &request); err != nil {
+		s.Logger.Error("Error parsing CalDAV request", zap.Error(err))
+		http.Error(res, "Invalid XML body", http.StatusBadRequest)
+		return request, false
+	}
+	return request, true
+}
+
+func (s *Server) sendCalDAVMultistatus(res http.ResponseWriter, ms *calDAVMultistatus) {
+	res.Header().Set("Content-Type", "application/xml; charset=UTF-8")
+	res.WriteHeader(http.StatusMultiStatus)
+	if _, err := res.Write(ms.bytes()); err != nil {
+		s.Logger.Error("Error writing CalDAV multistatus response", zap.Error(err))
+	}
+}
+
+func (s *Server) sendCalDAVError(res http.ResponseWriter, err error) {
+	var (
+		notExistsErr    errors.TaskNotExists
+		resourceErr     errors.InvalidCalDAVResource
+		preconditionErr errors.CalDAVPreconditionFailed
+	)
+	switch {
+	case goerrors.As(err, &notExistsErr):
+		http.Error(res, "Not found", http.StatusNotFound)
+	case goerrors.As(err, &preconditionErr):
+		http.Error(res, err.Error(), http.StatusPreconditionFailed)
+	case goerrors.As(err, &resourceErr):
+		http.Error(res, err.Error(), http.StatusForbidden)
+	case isClientError(err):
+		http.Error(res, err.Error(), http.StatusBadRequest)
+	default:
+		s.Logger.Error("Error handling CalDAV request", zap.Error(err))
+		http.Error(res, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// calDAVPath returns the kind of the resource at the path and, for
+// calendar objects, the resource name.
+func calDAVPath(path string) (int, string, bool) {
+	rest := strings.TrimPrefix(path, strings.TrimSuffix(CalDAVRootPath, "/"))
+	rest = strings.Trim(rest, "/")
+	switch {
+	case len(rest) == 0:
+		return calDAVKindPrincipal, "", true
+	case rest == strings.Trim(strings.TrimPrefix(calDAVCalendarPath, CalDAVRootPath), "/"):
+		return calDAVKindCalendar, "", true
+	}
+
+	name := calDAVHrefName(path)
+	if len(name) == 0 || strings.Contains(name, "/") {
+		return 0, "", false
+	}
+	return calDAVKindObject, name, true
+}
+
+// calDAVHrefName returns the resource name of an object href, which may be
+// a full URL, or an empty string for other hrefs.
+func calDAVHrefName(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	name, ok := strings.CutPrefix(u.Path, calDAVCalendarPath)
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+func calDAVObjectHref(name string) string {
+	return calDAVCalendarPath + url.PathEscape(name)
+}
+
+func calDAVPrincipalProps() []calDAVProp {
+	home := calDAVHref(CalDAVRootPath)
+	return []calDAVProp{
+		{calDAVResourceType, calDAVElement(xml.Name{Space: model.DAVNamespace, Local: "collection"}, "") +
+			calDAVElement(xml.Name{Space: model.DAVNamespace, Local: "principal"}, "")},
+		{calDAVDisplayName, calDAVEscape(calDAVPrincipalName)},
+		{calDAVUserPrincipal, home},
+		{calDAVPrincipalURL, home},
+		{calDAVHomeSet, home},
+	}
+}
+
+func calDAVCalendarProps(ctag string) []calDAVProp {
+	privileges := ""
+	for _, privilege := range []string{"read", "write", "write-content", "bind", "unbind"} {
+		privileges += calDAVElement(xml.Name{Space: model.DAVNamespace, Local: "privilege"},
+			calDAVElement(xml.Name{Space: model.DAVNamespace, Local: privilege}, ""))
+	}
+
+	reports := ""
+	for _, report := range []xml.Name{calDAVCalendarQuery, calDAVMultiget} {
+		reports += calDAVElement(xml.Name{Space: model.DAVNamespace, Local: "supported-report"},
+			calDAVElement(xml.Name{Space: model.DAVNamespace, Local: "report"}, calDAVElement(report, "")))
+	}
+
+	return []calDAVProp{
+		{calDAVResourceType, calDAVElement(xml.Name{Space: model.DAVNamespace, Local: "collection"}, "") +
+			calDAVElement(xml.Name{Space: model.CalDAVNamespace, Local: "calendar"}, "")},
+		{calDAVDisplayName, calDAVEscape(calDAVCalendarName)},
+		{calDAVUserPrincipal, calDAVHref(CalDAVRootPath)},
+		{calDAVComponentSet, `<C:comp name="VTODO"/>`},
+		{calDAVReportSet, reports},
+		{calDAVPrivilegeSet, privileges},
+		{calDAVCTag, calDAVEscape(ctag)},
+		{calDAVETag, calDAVEscape(`"` + ctag + `"`)},
+	}
+}
+
+func calDAVObjectProps(o model.CalDAVObject) []calDAVProp {
+	return []calDAVProp{
+		{calDAVResourceType, ""},
+		{calDAVETag, calDAVEscape(o.ETag)},
+		{calDAVContentType, "text/calendar; charset=utf-8; component=VTODO"},
+		{calDAVContentLength, strconv.Itoa(len(o.Data))},
+		{calDAVCalendarData, calDAVEscape(string(o.Data))},
+	}
+}
+
+func matchCalDAVFilter(t model.Task, filter *model.CalDAVFilterDto) bool {
+	if filter == nil {
+		return true
+	}
+
+	for _, calendar := range filter.CompFilters {
+		if calendar.Name != "VCALENDAR" {
+			return false
+		}
+		for _, c := range calendar.CompFilters {
+			if !matchCalDAVCompFilter(t, c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchCalDAVCompFilter(t model.Task, c model.CalDAVCompFilterDto) bool {
+	if c.Name != "VTODO" {
+		return c.IsNotDefined != nil
+	}
+	if c.IsNotDefined != nil {
+		return false
+	}
+	if c.TimeRange == nil {
+		return true
+	}
+
+	start, _ := time.Parse("20060102T150405Z", c.TimeRange.Start)
+	end, _ := time.Parse("20060102T150405Z", c.TimeRange.End)
+	if !end.IsZero() && !t.Date.Before(end) {
+		return false
+	}
+	return len(t.Repeat) > 0 || start.IsZero() || t.Date.AddDate(0, 0, 1).After(start)
+}
+
+// calDAVMultistatus builds a 207 Multi-Status body.
+type calDAVMultistatus struct {
+	buf bytes.Buffer
+}
+
+func newCalDAVMultistatus() *calDAVMultistatus {
+	ms := &calDAVMultistatus{}
+	ms.buf.WriteString(xml.Header)
+	ms.buf.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" ` +
+		`xmlns:CS="http://calendarserver.org/ns/">`)
+	return ms
+}
+
+// response adds the properties of the resource asked for by the request:
+// the listed ones, the names of all or all but calendar data.
+func (ms *calDAVMultistatus) response(href string, props []calDAVProp, request model.CalDAVRequestDto) {
+	var found, missing strings.Builder
+	switch {
+	case request.PropName != nil:
+		for _, p := range props {
+			found.WriteString(calDAVElement(p.name, ""))
+		}
+	case request.Prop != nil:
+		for _, requested := range request.Prop.Names {
+			p, ok := findCalDAVProp(props, requested.XMLName)
+			if !ok {
+				missing.WriteString(calDAVElement(requested.XMLName, ""))
+				continue
+			}
+			found.WriteString(calDAVElement(p.name, p.value))
+		}
+	default:
+		for _, p := range props {
+			if p.name != calDAVCalendarData {
+				found.WriteString(calDAVElement(p.name, p.value))
+			}
+		}
+	}
+
+	ms.buf.WriteString("<D:response>" + calDAVHref(href))
+	if found.Len() > 0 {
+		ms.propstat(found.String(), http.StatusOK)
+	}
+	if missing.Len() > 0 {
+		ms.propstat(missing.String(), http.StatusNotFound)
+	}
+	ms.buf.WriteString("</D:response>")
+}
+
+func (ms *calDAVMultistatus) propstat(props string, status int) {
+	ms.buf.WriteString("<D:propstat><D:prop>" + props + "</D:prop>" + calDAVStatus(status) + "</D:propstat>")
+}
+
+func (ms *calDAVMultistatus) status(href string, status int) {
+	ms.buf.WriteString("<D:response>" + calDAVHref(href) + calDAVStatus(status) + "</D:response>")
+}
+
+func (ms *calDAVMultistatus) bytes() []byte {
+	ms.buf.WriteString("</D:multistatus>")
+	return ms.buf.Bytes()
+}
+
+func findCalDAVProp(props []calDAVProp, name xml.Name) (calDAVProp, bool) {
+	for _, p := range props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return calDAVProp{}, false
+}
+
+// calDAVElement writes an element with the XML content, using the prefixes
+// declared on multistatus for known namespaces.
+func calDAVElement(name xml.Name, content string) string {
+	tag := name.Local
+	attrs := ""
+	if prefix, ok := calDAVNamespacePrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if len(name.Space) > 0 {
+		attrs = ` xmlns="` + calDAVEscape(name.Space) + `"`
+	}
+
+	if len(content) == 0 {
+		return "<" + tag + attrs + "/>"
+	}
+	return "<" + tag + attrs + ">" + content + "</" + tag + ">"
+}
+
+func calDAVHref(href string) string {
+	return "<D:href>" + calDAVEscape(href) + "</D:href>"
+}
+
+func calDAVStatus(status int) string {
+	return fmt.Sprintf("<D:status>HTTP/1.1 %d %s</D:status>", status, http.StatusText(status))
+}
+
+func calDAVEscape(value string) string {
+	var buf strings.Builder
+	_ = xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	goerrors "errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/ical"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+// CalDAVEventSource marks changes made by CalDAV clients.
+const CalDAVEventSource = "caldav"
+
+// Tasks keep no modification time, so every resource has the same DTSTAMP:
+// a resource, and so its ETag, changes only when the task does.
+var calDAVStamp = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// CalDAVService serves tasks as VTODO resources of a CalDAV calendar.
+// Changes made by clients go through TaskService, so they are validated and
+// published like changes made through the API.
+type CalDAVService struct {
+	taskService *TaskService
+	store       storage.CalDAVResourceStore
+	logger      *zap.Logger
+}
+
+func NewCalDAVService(taskService *TaskService, store storage.CalDAVResourceStore,
+	logger *zap.Logger) *CalDAVService {
+	return &CalDAVService{
+		taskService: taskService.WithSource(CalDAVEventSource),
+		store:       store,
+		logger:      logger,
+	}
+}
+
+// GetObjects returns all tasks as calendar objects.
+func (s CalDAVService) GetObjects() ([]model.CalDAVObject, error) {
+	tasks, err := s.taskService.GetTasks("", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.store.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	byTask := make(map[int]model.CalDAVResource, len(resources))
+	for _, r := range resources {
+		byTask[r.TaskID] = r
+	}
+
+	objects := make([]model.CalDAVObject, 0, len(tasks))
+	for _, t := range tasks {
+		r, ok := byTask[t.ID]
+		if !ok {
+			r = defaultCalDAVResource(t.ID)
+		}
+
+		o, err := calDAVObject(t, r)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, o)
+	}
+	return objects, nil
+}
+
+// GetObject returns the calendar object with the resource name.
+func (s CalDAVService) GetObject(name string) (model.CalDAVObject, error) {
+	r, err := calDAVResource(s.store, name)
+	if err != nil {
+		return model.CalDAVObject{}, err
+	}
+
+	t, err := s.taskService.GetTask(r.TaskID)
+	if err != nil {
+		return model.CalDAVObject{}, err
+	}
+	return calDAVObject(t, r)
+}
+
+// calDAVResource returns the resource with the name, either stored or the
+// default one of a task.
+func calDAVResource(store storage.CalDAVResourceStore, name string) (model.CalDAVResource, error) {
+	r, err := store.GetByName(name)
+	if err != nil {
+		var notExistsErr errors.TaskNotExists
+		if !goerrors.As(err, &notExistsErr) {
+			return r, err
+		}
+
+		id, ok := model.ParseDefaultCalDAVResourceName(name)
+		if !ok {
+			return r, err
+		}
+		// A task created by a client is served only under the client's name.
+		if _, bound, err := store.GetByTaskID(id); err != nil || bound {
+			if err == nil {
+				err = errors.NewTaskNotExists("CalDAV resource "+name+" doesn`t exist", nil)
+			}
+			return r, err
+		}
+		r = defaultCalDAVResource(id)
+	}
+	return r, nil
+}
+
+// CalDAVCTag returns a tag of the whole collection that changes whenever any
+// of the objects is added, changed or removed.
+func CalDAVCTag(objects []model.CalDAVObject) string {
+	h := sha256.New()
+	for _, o := range objects {
+		h.Write([]byte(o.Name + " " + o.ETag + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// PutObject stores the to-do sent by a client and reports whether a new
+// task was created. ifMatch and ifNoneMatch are the values of the request
+// headers. Completing a to-do completes the task, which moves a recurring
+// task to its next date and removes any other one.
+func (s CalDAVService) PutObject(name string, data []byte, ifMatch string, ifNoneMatch string,
+	now time.Time) (bool, error) {
+	current, err := s.GetObject(name)
+	var notExistsErr errors.TaskNotExists
+	if err != nil && !goerrors.As(err, &notExistsErr) {
+		return false, err
+	}
+	exists := err == nil
+
+	if !exists {
+		if err := checkCalDAVConditions(current, false, ifMatch, ifNoneMatch); err != nil {
+			return false, err
+		}
+	}
+
+	todo, err := parseCalDAVTodo(data)
+	if err != nil {
+		return false, err
+	}
+
+	status := strings.ToUpper(todo.Text("STATUS"))
+	_, completedAt := todo.Get("COMPLETED")
+	completed := completedAt || status == "COMPLETED" || status == "CANCELLED"
+
+	today := s.taskService.Today(now)
+	if !exists {
+		return true, s.createObject(name, todo, completed, today)
+	}
+
+	check := s.checkObject(name, ifMatch, ifNoneMatch)
+	if completed {
+		return false, s.taskService.CompleteTaskWith(current.Task.ID, check)
+	}
+
+	t, err := s.todoTask(todo, today, current.Task)
+	if err != nil {
+		return false, err
+	}
+	t.ID = current.Task.ID
+	return false, s.taskService.UpdateTaskWith(t, check)
+}
+
+func (s CalDAVService) createObject(name string, todo *ical.Component, completed bool, today time.Time) error {
+	if _, ok := model.ParseDefaultCalDAVResourceName(name); ok {
+		return errors.NewInvalidCalDAVResource("resource name "+name+" is reserved", nil)
+	}
+	if completed {
+		return errors.NewInvalidCalDAVResource("completed to-dos are not stored", nil)
+	}
+
+	uid := todo.Text("UID")
+	if len(uid) == 0 {
+		return errors.NewInvalidCalDAVResource("to-do has no UID", nil)
+	}
+
+	t, err := s.todoTask(todo, today, model.Task{})
+	if err != nil {
+		return err
+	}
+
+	// A concurrent PUT of the same name fails on the unique name in the
+	// transaction and leaves no task behind.
+	_, err = s.taskService.AddTaskWith(t, func(tx *sql.Tx, id int) error {
+		return s.store.WithTx(tx).Create(model.CalDAVResource{TaskID: id, Name: name, UID: uid})
+	})
+	return err
+}
+
+// DeleteObject deletes the task of the resource.
+func (s CalDAVService) DeleteObject(name string, ifMatch string) error {
+	current, err := s.GetObject(name)
+	if err != nil {
+		return err
+	}
+	return s.taskService.DeleteTaskWith(current.Task.ID, s.checkObject(name, ifMatch, ""))
+}
+
+// checkObject returns the check of the conditions against the object as it
+// is stored in the transaction changing it, so that of two concurrent
+// requests with the same If-Match only the first one succeeds.
+func (s CalDAVService) checkObject(name string, ifMatch string, ifNoneMatch string) func(tx *sql.Tx,
+	current model.Task) error {
+	return func(tx *sql.Tx, current model.Task) error {
+		r, err := calDAVResource(s.store.WithTx(tx), name)
+		if err != nil {
+			return err
+		}
+		if r.TaskID != current.ID {
+			return errors.NewCalDAVPreconditionFailed("resource has been changed", nil)
+		}
+
+		o, err := calDAVObject(current, r)
+		if err != nil {
+			return err
+		}
+		return checkCalDAVConditions(o, true, ifMatch, ifNoneMatch)
+	}
+}
+
+// todoTask maps the to-do to the task. Project, estimate and custom fields
+// have no iCalendar form and are kept, as is a repeat rule that has no RRULE
+// form, so clients can't lose them by saving a to-do.
+func (s CalDAVService) todoTask(todo *ical.Component, today time.Time, current model.Task) (model.Task, error) {
+	t, warnings, err := icsTask(todo, today)
+	if err != nil {
+		return t, errors.NewInvalidCalDAVResource(err.Error(), err)
+	}
+
+	if rrule, ok := todo.Get("RRULE"); ok && len(t.Repeat) == 0 {
+		return t, errors.NewInvalidCalDAVResource("unsupported RRULE: "+rrule.Value, nil)
+	}
+	if _, ok := todo.Get("RRULE"); !ok && len(current.Repeat) > 0 {
+		if _, ok := ical.RepeatToRRule(current.Repeat); !ok {
+			t.Repeat = current.Repeat
+		}
+	}
+	if len(warnings) > 0 {
+		s.logger.Info("Parts of CalDAV to-do are ignored", zap.Strings("warnings", warnings))
+	}
+
+	if t.Tags == nil {
+		t.Tags = []string{}
+	}
+	if t.Priority == nil {
+		priority := 0
+		t.Priority = &priority
+	}
+	return t, nil
+}
+
+// parseCalDAVTodo returns the to-do of a calendar object resource. Overrides
+// of single occurrences (RECURRENCE-ID) are ignored.
+func parseCalDAVTodo(data []byte) (*ical.Component, error) {
+	calendar, err := ical.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.NewInvalidCalDAVResource("invalid iCalendar data: "+err.Error(), err)
+	}
+
+	var todo *ical.Component
+	for _, c := range calendar.Components {
+		switch c.Name {
+		case "VTODO":
+			if _, override := c.Get("RECURRENCE-ID"); !override && todo == nil {
+				todo = c
+			}
+		case "VEVENT", "VJOURNAL", "VFREEBUSY":
+			return nil, errors.NewInvalidCalDAVResource("the calendar supports only VTODO components", nil)
+		}
+	}
+	if todo == nil {
+		return nil, errors.NewInvalidCalDAVResource("calendar object has no VTODO", nil)
+	}
+	return todo, nil
+}
+
+// checkCalDAVConditions checks If-Match and If-None-Match header values
+// against the current object.
+func checkCalDAVConditions(current model.CalDAVObject, exists bool, ifMatch string, ifNoneMatch string) error {
+	if len(ifMatch) > 0 && !(exists && matchETag(ifMatch, current.ETag)) {
+		return errors.NewCalDAVPreconditionFailed("resource has been changed", nil)
+	}
+	if len(ifNoneMatch) > 0 && exists && matchETag(ifNoneMatch, current.ETag) {
+		return errors.NewCalDAVPreconditionFailed("resource already exists", nil)
+	}
+	return nil
+}
+
+// matchETag reports whether the header value, "*" or a list of ETags,
+// matches the ETag.
+func matchETag(header string, etag string) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultCalDAVResource(taskID int) model.CalDAVResource {
+	return model.CalDAVResource{
+		TaskID: taskID,
+		Name:   model.DefaultCalDAVResourceName(taskID),
+		UID:    "task-" + strconv.Itoa(taskID) + "@" + calendarUIDDomain,
+	}
+}
+
+// calDAVObject renders the task as a VTODO. A recurring to-do needs DTSTART
+// for its RRULE, so it starts and is due on the task date.
+func calDAVObject(t model.Task, r model.CalDAVResource) (model.CalDAVObject, error) {
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	w.Begin("VCALENDAR")
+	w.Property("VERSION", "2.0")
+	w.Property("PRODID", calendarProductID)
+	w.Begin("VTODO")
+	w.Property("UID", r.UID)
+	w.Timestamp("DTSTAMP", calDAVStamp)
+	rrule, ok := ical.RepeatToRRule(t.Repeat)
+	if ok {
+		w.Date("DTSTART", t.Date)
+	}
+	w.Date("DUE", t.Date)
+	if ok {
+		w.Property("RRULE", rrule)
+	}
+	w.Property("STATUS", "NEEDS-ACTION")
+	w.Text("SUMMARY", t.Title)
+	if len(t.Comment) > 0 {
+		w.Text("DESCRIPTION", t.Comment)
+	}
+	if len(t.Tags) > 0 {
+		categories := make([]string, len(t.Tags))
+		for idx, tag := range t.Tags {
+			categories[idx] = ical.EscapeText(tag)
+		}
+		w.Property("CATEGORIES", strings.Join(categories, ","))
+	}
+	if t.Priority != nil && *t.Priority > 0 {
+		w.Property("PRIORITY", strconv.Itoa(9-(*t.Priority-1)*4))
+	}
+	w.End("VTODO")
+	w.End("VCALENDAR")
+	if err := w.Flush(); err != nil {
+		return model.CalDAVObject{}, err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return model.CalDAVObject{
+		Name: r.Name,
+		Task: t,
+		Data: buf.Bytes(),
+		ETag: `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}, nil
+}
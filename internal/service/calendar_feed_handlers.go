@@ -0,0 +1,217 @@
+package service
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// GetCalendarHandler serves the feed selected by the token parameter. It is
+// not behind AuthMiddleware: calendar apps can't send the token cookie.
+func (s *Server) GetCalendarHandler(res http.ResponseWriter, req *http.Request) {
+	token := req.FormValue("token")
+
+	calendar, err := s.CalendarFeedService.RenderFeed(token, time.Now())
+	if err != nil {
+		var feedErr errors.InvalidCalendarFeed
+		if goerrors.As(err, &feedErr) {
+			http.Error(res, "Calendar feed not found", http.StatusNotFound)
+			return
+		}
+		s.Logger.Error("Error rendering calendar feed", zap.Error(err))
+		http.Error(res, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "text/calendar; charset=UTF-8")
+	res.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+	res.Header().Set("Cache-Control", "no-cache")
+	if _, err := res.Write(calendar); err != nil {
+		s.Logger.Error("Error writing calendar feed response", zap.Error(err))
+	}
+}
+
+func (s *Server) GetCalendarFeedsHandler(res http.ResponseWriter, req *http.Request) {
+	feeds, err := s.CalendarFeedService.GetFeeds()
+	if err != nil {
+		s.Logger.Error("Error getting calendar feeds", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feedsDto := model.CalendarFeedsDto{
+		Feeds: model.CalendarFeedsToCalendarFeedsDto(feeds),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(feedsDto); err != nil {
+		s.Logger.Error("Error encoding get calendar feeds response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetCalendarFeedHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get calendar feed id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feed, err := s.CalendarFeedService.GetFeed(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting calendar feed", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	feedDto := model.CalendarFeedToCalendarFeedDto(feed)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(feedDto); err != nil {
+		s.Logger.Error("Error encoding get calendar feed response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) AddCalendarFeedHandler(res http.ResponseWriter, req *http.Request) {
+	feed := model.CalendarFeed{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&feed); err != nil {
+		s.Logger.Error("Error decoding add calendar feed", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, token, err := s.CalendarFeedService.AddFeed(feed)
+	if err != nil {
+		s.Logger.Error("Error adding calendar feed", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	tokenDto := model.CalendarFeedTokenDto{
+		ID:    id,
+		Token: token,
+		URL:   calendarFeedURL(req, token),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(tokenDto); err != nil {
+		s.Logger.Error("Error encoding add calendar feed response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) UpdateCalendarFeedHandler(res http.ResponseWriter, req *http.Request) {
+	feed := model.CalendarFeed{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&feed); err != nil {
+		s.Logger.Error("Error decoding update calendar feed", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := s.CalendarFeedService.UpdateFeed(feed)
+	if err != nil {
+		s.Logger.Error("Error updating calendar feed", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding update calendar feed response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) RotateCalendarFeedTokenHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing rotate calendar feed token id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, err := s.CalendarFeedService.RotateToken(idNumber)
+	if err != nil {
+		s.Logger.Error("Error rotating calendar feed token", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	tokenDto := model.CalendarFeedTokenDto{
+		ID:    idNumber,
+		Token: token,
+		URL:   calendarFeedURL(req, token),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(tokenDto); err != nil {
+		s.Logger.Error("Error encoding rotate calendar feed token response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DeleteCalendarFeedHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing delete calendar feed id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.CalendarFeedService.DeleteFeed(idNumber)
+	if err != nil {
+		s.Logger.Error("Error deleting calendar feed", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding delete calendar feed response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// calendarFeedURL returns the feed address as seen by the client, which is
+// what users paste into their calendar app.
+func calendarFeedURL(req *http.Request, token string) string {
+	scheme := "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host + "/api/calendar.ics?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/ical"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+const (
+	calendarProductID = "-//Stern-Ritter//go_task_manager//EN"
+	calendarUIDDomain = "go-task-manager"
+
+	// Recurring tasks are expanded for calendarExpandDays from today, up to
+	// maxCalendarOccurrences occurrences per task.
+	calendarExpandDays     = 90
+	maxCalendarOccurrences = 100
+)
+
+type CalendarFeedService struct {
+	store     storage.CalendarFeedStore
+	taskStore storage.TaskStore
+	location  *time.Location
+	logger    *zap.Logger
+}
+
+// NewCalendarFeedService returns a service that expands recurring tasks
+// from the current day of location.
+func NewCalendarFeedService(store storage.CalendarFeedStore, taskStore storage.TaskStore, location *time.Location,
+	logger *zap.Logger) *CalendarFeedService {
+	return &CalendarFeedService{store: store, taskStore: taskStore, location: location, logger: logger}
+}
+
+// AddFeed returns the ID of the new feed and its token.
+func (s CalendarFeedService) AddFeed(f model.CalendarFeed) (int, string, error) {
+	token, err := model.NewFeedToken()
+	if err != nil {
+		return 0, "", err
+	}
+
+	f.CreatedAt = time.Now()
+	id, err := s.store.Create(f, model.HashFeedToken(token))
+	return id, token, err
+}
+
+func (s CalendarFeedService) UpdateFeed(f model.CalendarFeed) error {
+	return s.store.Update(f)
+}
+
+// RotateToken replaces the feed token; calendars using the old one stop
+// receiving the feed.
+func (s CalendarFeedService) RotateToken(id int) (string, error) {
+	token, err := model.NewFeedToken()
+	if err != nil {
+		return "", err
+	}
+	return token, s.store.SetTokenHash(id, model.HashFeedToken(token))
+}
+
+func (s CalendarFeedService) DeleteFeed(id int) error {
+	return s.store.Delete(id)
+}
+
+func (s CalendarFeedService) GetFeed(id int) (model.CalendarFeed, error) {
+	return s.store.GetByID(id)
+}
+
+func (s CalendarFeedService) GetFeeds() ([]model.CalendarFeed, error) {
+	return s.store.GetAll()
+}
+
+// RenderFeed returns the iCalendar document of the feed with the token.
+func (s CalendarFeedService) RenderFeed(token string, now time.Time) ([]byte, error) {
+	f, err := s.store.GetByTokenHash(model.HashFeedToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.SetAccessedAt(f.ID, now); err != nil {
+		s.logger.Error("Error updating calendar feed access time", zap.Int("feed_id", f.ID), zap.Error(err))
+	}
+
+	tasks, err := s.taskStore.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	w.Begin("VCALENDAR")
+	w.Property("VERSION", "2.0")
+	w.Property("PRODID", calendarProductID)
+	w.Property("CALSCALE", "GREGORIAN")
+	w.Property("METHOD", "PUBLISH")
+	w.Text("X-WR-CALNAME", f.Name)
+
+	for _, t := range tasks {
+		if f.Project != nil && taskProject(t) != *f.Project {
+			continue
+		}
+		s.writeCalendarTask(w, f, t, now)
+	}
+
+	w.End("VCALENDAR")
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func taskProject(t model.Task) string {
+	if t.Project == nil {
+		return ""
+	}
+	return *t.Project
+}
+
+// writeCalendarTask writes a recurring task as one entry with an RRULE or,
+// when the feed asks for expansion or lists to-dos, as an entry per
+// occurrence: a recurring VTODO would need a DTSTART before its due date.
+// Tasks whose rule has no RRULE form are written as a single entry.
+func (s CalendarFeedService) writeCalendarTask(w *ical.Writer, f model.CalendarFeed, t model.Task, now time.Time) {
+	if len(t.Repeat) == 0 {
+		writeCalendarEntry(w, f, t, t.Date, "", "task-"+strconv.Itoa(t.ID), now)
+		return
+	}
+
+	rrule, ok := ical.RepeatToRRule(t.Repeat)
+	if !ok {
+		// NextDate can't be trusted to terminate for such rules.
+		writeCalendarEntry(w, f, t, t.Date, "", "task-"+strconv.Itoa(t.ID), now)
+		return
+	}
+
+	if !f.Expand && f.Kind == model.FeedKindEvent {
+		writeCalendarEntry(w, f, t, t.Date, rrule, "task-"+strconv.Itoa(t.ID), now)
+		return
+	}
+
+	for _, date := range expandTaskDates(t, utils.Today(now, s.location)) {
+		uid := "task-" + strconv.Itoa(t.ID) + "-" + date.Format("20060102")
+		writeCalendarEntry(w, f, t, date, "", uid, now)
+	}
+}
+
+// expandTaskDates returns the task date and the following occurrences up to
+// calendarExpandDays from today.
+func expandTaskDates(t model.Task, today time.Time) []time.Time {
+	return taskDates(t, t.Date, today.AddDate(0, 0, calendarExpandDays), maxCalendarOccurrences)
+}
+
+// taskDates returns up to limit dates of the task from from to to: its date
+// and, for a recurring task, the following occurrences. Rules without an
+// RRULE form aren't expanded: NextDate can't be trusted to terminate for
+// them.
+func taskDates(t model.Task, from time.Time, to time.Time, limit int) []time.Time {
+	dates := []time.Time{}
+	if _, ok := ical.RepeatToRRule(t.Repeat); !ok {
+		if !t.Date.Before(from) && !t.Date.After(to) && limit > 0 {
+			dates = append(dates, t.Date)
+		}
+		return dates
+	}
+
+	date := t.Date
+	if date.Before(from) {
+		// Jump to the range instead of stepping through the dates before it.
+		next, ok := nextTaskDate(from.AddDate(0, 0, -1), date, t.Repeat)
+		if !ok {
+			return dates
+		}
+		date = next
+	}
+	// The jump lands at most a day before the range, so limit+1 steps
+	// suffice whatever dates NextDate returns.
+	for steps := 0; !date.After(to) && len(dates) < limit && steps <= limit; steps++ {
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+		next, ok := nextTaskDate(date, date, t.Repeat)
+		if !ok || !next.After(date) {
+			break
+		}
+		date = next
+	}
+	return dates
+}
+
+func nextTaskDate(now time.Time, date time.Time, repeat string) (time.Time, bool) {
+	value, err := utils.NextDate(now, date.Format("20060102"), repeat)
+	if err != nil {
+		return date, false
+	}
+	next, err := time.Parse("20060102", value)
+	return next, err == nil
+}
+
+func writeCalendarEntry(w *ical.Writer, f model.CalendarFeed, t model.Task, date time.Time, rrule string,
+	uid string, now time.Time) {
+	component := "VEVENT"
+	if f.Kind == model.FeedKindTodo {
+		component = "VTODO"
+	}
+
+	w.Begin(component)
+	w.Property("UID", uid+"@"+calendarUIDDomain)
+	w.Timestamp("DTSTAMP", now)
+	if f.Kind == model.FeedKindTodo {
+		w.Date("DUE", date)
+		w.Property("STATUS", "NEEDS-ACTION")
+	} else {
+		w.Date("DTSTART", date)
+		w.Date("DTEND", date.AddDate(0, 0, 1))
+		w.Property("TRANSP", "TRANSPARENT")
+	}
+	if len(rrule) > 0 {
+		w.Property("RRULE", rrule)
+	}
+	w.Text("SUMMARY", t.Title)
+	if len(t.Comment) > 0 {
+		w.Text("DESCRIPTION", t.Comment)
+	}
+
+	categories := make([]string, 0, len(t.Tags)+1)
+	if project := taskProject(t); len(project) > 0 {
+		categories = append(categories, ical.EscapeText(project))
+	}
+	for _, tag := range t.Tags {
+		categories = append(categories, ical.EscapeText(tag))
+	}
+	if len(categories) > 0 {
+		w.Property("CATEGORIES", strings.Join(categories, ","))
+	}
+
+	if t.Priority != nil && *t.Priority > 0 {
+		// iCalendar priorities go from 1 (highest) to 9 (lowest).
+		w.Property("PRIORITY", strconv.Itoa(9-(*t.Priority-1)*4))
+	}
+	w.End(component)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) GetFieldsHandler(res http.ResponseWriter, req *http.Request) {
+	fields, err := s.CustomFieldService.GetFields()
+	if err != nil {
+		s.Logger.Error("Error getting custom fields", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	fieldsDto := model.CustomFieldsDto{
+		Fields: model.CustomFieldsToCustomFieldsDto(fields),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(fieldsDto); err != nil {
+		s.Logger.Error("Error encoding get custom fields response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetFieldHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get custom field id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	field, err := s.CustomFieldService.GetField(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting custom field", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	fieldDto := model.CustomFieldToCustomFieldDto(field)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(fieldDto); err != nil {
+		s.Logger.Error("Error encoding get custom field response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) AddFieldHandler(res http.ResponseWriter, req *http.Request) {
+	field := model.CustomField{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&field); err != nil {
+		s.Logger.Error("Error decoding add custom field", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.CustomFieldService.AddField(field)
+	if err != nil {
+		s.Logger.Error("Error adding custom field", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := model.CreateTaskSuccessDto{
+		ID: id,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding add custom field response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) UpdateFieldHandler(res http.ResponseWriter, req *http.Request) {
+	field := model.CustomField{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&field); err != nil {
+		s.Logger.Error("Error decoding update custom field", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dropped, err := s.CustomFieldService.UpdateField(field)
+	if err != nil {
+		s.Logger.Error("Error updating custom field", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := model.UpdateCustomFieldDto{
+		DroppedValues: dropped,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding update custom field response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DeleteFieldHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing delete custom field id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.CustomFieldService.DeleteField(idNumber)
+	if err != nil {
+		s.Logger.Error("Error deleting custom field", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding delete custom field response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+type CustomFieldService struct {
+	store  storage.CustomFieldStore
+	logger *zap.Logger
+}
+
+func NewCustomFieldService(store storage.CustomFieldStore, logger *zap.Logger) *CustomFieldService {
+	return &CustomFieldService{store: store, logger: logger}
+}
+
+func (s CustomFieldService) AddField(f model.CustomField) (int, error) {
+	return s.store.Create(f)
+}
+
+// UpdateField changes the field and brings its values in line with it in one
+// transaction. It returns the number of values that don't fit the new
+// definition and are removed.
+func (s CustomFieldService) UpdateField(f model.CustomField) (int, error) {
+	tx, err := s.store.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	store := s.store.WithTx(tx)
+	err = store.Update(f)
+	if err != nil {
+		return 0, err
+	}
+
+	dropped, err := store.NormalizeValues(f)
+	if err != nil {
+		return 0, err
+	}
+	return dropped, tx.Commit()
+}
+
+func (s CustomFieldService) DeleteField(id int) error {
+	return s.store.Delete(id)
+}
+
+func (s CustomFieldService) GetField(id int) (model.CustomField, error) {
+	return s.store.GetByID(id)
+}
+
+func (s CustomFieldService) GetFields() ([]model.CustomField, error) {
+	return s.store.GetAll()
+}
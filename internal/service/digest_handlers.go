@@ -0,0 +1,59 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+// PreviewDigestHandler renders the digest without sending it. The format
+// parameter selects html (default) or text, date selects the digest day.
+func (s *Server) PreviewDigestHandler(res http.ResponseWriter, req *http.Request) {
+	format := req.FormValue("format")
+	if len(format) == 0 {
+		format = DigestFormatHTML
+	}
+
+	today := s.DigestService.Today(time.Now())
+	if date := req.FormValue("date"); len(date) > 0 {
+		parsed, err := utils.ParseDate(date)
+		if err != nil {
+			s.Logger.Error("Error parsing digest date", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, err.Error())
+			return
+		}
+		today = parsed
+	}
+
+	contentType := "text/html; charset=UTF-8"
+	switch format {
+	case DigestFormatHTML:
+	case DigestFormatText:
+		contentType = "text/plain; charset=UTF-8"
+	default:
+		sendTaskError(res, http.StatusBadRequest, "unknown digest format: "+format)
+		return
+	}
+
+	digest, err := s.DigestService.BuildDigest(today)
+	if err != nil {
+		s.Logger.Error("Error building digest", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	body, err := s.DigestService.RenderDigest(digest, format)
+	if err != nil {
+		s.Logger.Error("Error rendering digest", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	if _, err := res.Write([]byte(body)); err != nil {
+		s.Logger.Error("Error writing digest preview response", zap.Error(err))
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/notify"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+const (
+	DigestFormatHTML = "html"
+	DigestFormatText = "text"
+
+	digestAheadDays = 3
+)
+
+var digestFuncs = map[string]any{
+	"date": func(t time.Time) string {
+		return t.Format("02.01.2006")
+	},
+}
+
+// DigestService builds the daily agenda and emails it.
+type DigestService struct {
+	store    storage.TaskStore
+	html     *htmltemplate.Template
+	text     *texttemplate.Template
+	mailer   *notify.EmailNotifier
+	location *time.Location
+	logger   *zap.Logger
+}
+
+// NewDigestService loads the digest.html and digest.txt templates from
+// templatesPath. mailer may be nil, then the digest can only be previewed.
+// Days of the digest are those of location.
+func NewDigestService(store storage.TaskStore, templatesPath string, mailer *notify.EmailNotifier,
+	location *time.Location, logger *zap.Logger) (*DigestService, error) {
+	html, err := htmltemplate.New("digest.html").Funcs(digestFuncs).
+		ParseFiles(filepath.Join(templatesPath, "digest.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := texttemplate.New("digest.txt").Funcs(digestFuncs).
+		ParseFiles(filepath.Join(templatesPath, "digest.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DigestService{store: store, html: html, text: text, mailer: mailer, location: location,
+		logger: logger}, nil
+}
+
+// Today returns the current day in the location of the service.
+func (s DigestService) Today(now time.Time) time.Time {
+	return utils.Today(now, s.location)
+}
+
+// BuildDigest collects overdue tasks, tasks for today and for the next three
+// days.
+func (s DigestService) BuildDigest(today time.Time) (model.Digest, error) {
+	overdue, err := s.store.GetAllBefore(today.Format("20060102"))
+	if err != nil {
+		return model.Digest{}, err
+	}
+
+	todays, err := s.store.GetAllByDate(today.Format("20060102"))
+	if err != nil {
+		return model.Digest{}, err
+	}
+
+	upcoming, err := s.store.GetAllInRange(today.AddDate(0, 0, 1).Format("20060102"),
+		today.AddDate(0, 0, digestAheadDays).Format("20060102"))
+	if err != nil {
+		return model.Digest{}, err
+	}
+
+	return model.Digest{
+		Date: today,
+		Sections: []model.DigestSection{
+			model.NewDigestSection("Overdue", overdue),
+			model.NewDigestSection("Today", todays),
+			model.NewDigestSection(fmt.Sprintf("Next %d days", digestAheadDays), upcoming),
+		},
+	}, nil
+}
+
+func (s DigestService) RenderDigest(d model.Digest, format string) (string, error) {
+	var b strings.Builder
+	var err error
+	switch format {
+	case DigestFormatHTML:
+		err = s.html.Execute(&b, d)
+	case DigestFormatText:
+		err = s.text.Execute(&b, d)
+	default:
+		return "", fmt.Errorf("unknown digest format: %s", format)
+	}
+	return b.String(), err
+}
+
+// SendDigest emails the digest for the day of now. Nothing is sent when
+// there are no tasks.
+func (s DigestService) SendDigest(ctx context.Context, now time.Time) error {
+	if s.mailer == nil {
+		return fmt.Errorf("digest email is not configured")
+	}
+
+	d, err := s.BuildDigest(s.Today(now))
+	if err != nil {
+		return err
+	}
+	if d.Count() == 0 {
+		s.logger.Info("digest is empty, skip sending")
+		return nil
+	}
+
+	text, err := s.RenderDigest(d, DigestFormatText)
+	if err != nil {
+		return err
+	}
+	html, err := s.RenderDigest(d, DigestFormatHTML)
+	if err != nil {
+		return err
+	}
+
+	subject := "Agenda for " + d.Date.Format("02.01.2006")
+	return s.mailer.SendAlternative(ctx, subject, text, html)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+// ExportService writes tasks in the file formats of other tools.
+type ExportService struct {
+	fieldStore storage.CustomFieldStore
+	logger     *zap.Logger
+}
+
+func NewExportService(fieldStore storage.CustomFieldStore, logger *zap.Logger) *ExportService {
+	return &ExportService{fieldStore: fieldStore, logger: logger}
+}
+
+// ExportCSV writes the tasks as CSV with a header: the columns of
+// model.CSVColumns, then a "field:<name>" column for every custom field. The
+// file starts with a byte order mark, so spreadsheets read it as UTF-8.
+func (s ExportService) ExportCSV(w io.Writer, tasks []model.Task) error {
+	definitions, err := s.fieldStore.GetAll()
+	if err != nil {
+		return err
+	}
+	fieldNames := make([]string, len(definitions))
+	for idx, definition := range definitions {
+		fieldNames[idx] = definition.Name
+	}
+	sort.Strings(fieldNames)
+
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	header := append([]string{}, model.CSVColumns...)
+	for _, name := range fieldNames {
+		header = append(header, model.CSVFieldColumnPrefix+name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		record := []string{
+			strconv.Itoa(t.ID),
+			t.Date.Format("20060102"),
+			csvSafeCell(t.Title),
+			csvSafeCell(t.Comment),
+			t.Repeat,
+			utils.DescribeRepeat(t.Repeat),
+			csvSafeCell(taskProject(t)),
+			optionalInt(t.Priority),
+			optionalInt(t.Estimate),
+			strings.Join(t.Tags, ","),
+		}
+		for _, name := range fieldNames {
+			record = append(record, csvSafeCell(t.Fields[name]))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportTodoTxt writes the tasks as todo.txt lines, see model.TaskToTodoTxt.
+func (s ExportService) ExportTodoTxt(w io.Writer, tasks []model.Task) error {
+	for _, t := range tasks {
+		if _, err := io.WriteString(w, model.TaskToTodoTxt(t)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func optionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+// csvSafeCell prefixes text that spreadsheets would run as a formula with an
+// apostrophe, which they hide; csvCellValue removes it on import.
+func csvSafeCell(value string) string {
+	if len(value) > 0 && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func csvCellValue(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
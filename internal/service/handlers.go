@@ -2,15 +2,21 @@ package service
 
 import (
 	"encoding/json"
+	goerrors "errors"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
 	"github.com/Stern-Ritter/go_task_manager/internal/model"
 )
 
+const fieldFilterPrefix = "field."
+
 func (s *Server) SignInHandler(res http.ResponseWriter, req *http.Request) {
 	authReq := model.AuthRequestDto{}
 	dec := json.NewDecoder(req.Body)
@@ -73,7 +79,7 @@ func (s *Server) AddTaskHandler(res http.ResponseWriter, req *http.Request) {
 	id, err := s.TaskService.AddTask(task)
 	if err != nil {
 		s.Logger.Error("Error adding task", zap.Error(err))
-		sendTaskError(res, http.StatusInternalServerError, "Internal server error")
+		sendTaskServiceError(res, err)
 		return
 	}
 
@@ -102,7 +108,7 @@ func (s *Server) UpdateTaskHandler(res http.ResponseWriter, req *http.Request) {
 	err := s.TaskService.UpdateTask(task)
 	if err != nil {
 		s.Logger.Error("Error updating task", zap.Error(err))
-		sendTaskError(res, http.StatusInternalServerError, "Internal server error")
+		sendTaskServiceError(res, err)
 		return
 	}
 
@@ -175,14 +181,28 @@ func (s *Server) DeleteTaskHandler(res http.ResponseWriter, req *http.Request) {
 
 func (s *Server) GetTasksHandler(res http.ResponseWriter, req *http.Request) {
 	search := req.FormValue("search")
+	fields := parseFieldFilters(req)
 
-	tasks, err := s.TaskService.GetTasks(search)
+	tasks, err := s.TaskService.GetTasks(search, fields)
 	if err != nil {
 		s.Logger.Error("Error getting tasks", zap.Error(err))
 		sendTaskError(res, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	switch format := req.FormValue("format"); format {
+	case "", "json":
+	case "csv":
+		s.sendTasksCSV(res, tasks)
+		return
+	case "todotxt":
+		s.sendTasksTodoTxt(res, tasks)
+		return
+	default:
+		sendTaskError(res, http.StatusBadRequest, "unknown tasks format: "+format)
+		return
+	}
+
 	tasksDto := model.TasksDto{
 		Tasks: model.TasksToTasksDto(tasks),
 	}
@@ -196,6 +216,188 @@ func (s *Server) GetTasksHandler(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func (s *Server) sendTasksCSV(res http.ResponseWriter, tasks []model.Task) {
+	res.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+	res.Header().Set("Content-Disposition", `attachment; filename="tasks.csv"`)
+	if err := s.ExportService.ExportCSV(res, tasks); err != nil {
+		s.Logger.Error("Error writing tasks CSV response", zap.Error(err))
+	}
+}
+
+func (s *Server) sendTasksTodoTxt(res http.ResponseWriter, tasks []model.Task) {
+	res.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	res.Header().Set("Content-Disposition", `attachment; filename="todo.txt"`)
+	if err := s.ExportService.ExportTodoTxt(res, tasks); err != nil {
+		s.Logger.Error("Error writing tasks todo.txt response", zap.Error(err))
+	}
+}
+
+func (s *Server) BulkTasksHandler(res http.ResponseWriter, req *http.Request) {
+	op := model.BulkOperation{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&op); err != nil {
+		s.Logger.Error("Error decoding bulk tasks operation", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := s.TaskService.BulkUpdate(op)
+	if err != nil {
+		s.Logger.Error("Error applying bulk tasks operation", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	resultsDto := model.BulkResultsToBulkResultsDto(results)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(resultsDto); err != nil {
+		s.Logger.Error("Error encoding bulk tasks response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) PostponeTaskHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing postpone task id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	postponeDto := model.PostponeTaskDto{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&postponeDto); err != nil {
+		s.Logger.Error("Error decoding postpone task", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	task, err := s.TaskService.PostponeTask(idNumber, postponeDto.To)
+	if err != nil {
+		s.Logger.Error("Error postponing task", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	taskDto := model.TaskToTaskDto(task)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(taskDto); err != nil {
+		s.Logger.Error("Error encoding postpone task response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetOverdueTasksHandler(res http.ResponseWriter, req *http.Request) {
+	tasks, err := s.TaskService.GetOverdueTasks(time.Now())
+	if err != nil {
+		s.Logger.Error("Error getting overdue tasks", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tasksDto := model.TasksDto{
+		Tasks: model.TasksToTasksDto(tasks),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(tasksDto); err != nil {
+		s.Logger.Error("Error encoding get overdue tasks response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) RolloverTasksHandler(res http.ResponseWriter, req *http.Request) {
+	rolled, err := s.TaskService.RolloverTasks(time.Now())
+	if err != nil {
+		s.Logger.Error("Error rolling over tasks", zap.Error(err))
+		sendTaskError(res, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	rolloverDto := model.RolloverTasksDto{
+		Rolled: rolled,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(rolloverDto); err != nil {
+		s.Logger.Error("Error encoding rollover tasks response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetPostponedTasksHandler(res http.ResponseWriter, req *http.Request) {
+	minCount := 1
+	if value := req.FormValue("min"); len(value) > 0 {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			s.Logger.Error("Error parsing postponed tasks min count", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, err.Error())
+			return
+		}
+		minCount = number
+	}
+
+	tasks, err := s.TaskService.GetPostponedTasks(minCount)
+	if err != nil {
+		s.Logger.Error("Error getting postponed tasks", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tasksDto := model.PostponedTasksDto{
+		Tasks: model.PostponedTasksToPostponedTasksDto(tasks),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(tasksDto); err != nil {
+		s.Logger.Error("Error encoding get postponed tasks response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetCalendarViewHandler returns the occurrences of tasks from the from
+// date to the to date, see TaskService.GetCalendar.
+func (s *Server) GetCalendarViewHandler(res http.ResponseWriter, req *http.Request) {
+	today := s.AgendaService.Today(time.Now())
+	from, to, err := parseDateRange(today, req.FormValue("from"), req.FormValue("to"))
+	if err != nil {
+		s.Logger.Error("Error parsing calendar range", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	view, err := s.TaskService.GetCalendar(from, to)
+	if err != nil {
+		s.Logger.Error("Error getting calendar", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	viewDto := model.CalendarViewToCalendarViewDto(view)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(viewDto); err != nil {
+		s.Logger.Error("Error encoding get calendar response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
 func (s *Server) GetTaskHandler(res http.ResponseWriter, req *http.Request) {
 	id := req.FormValue("id")
 
@@ -224,6 +426,85 @@ func (s *Server) GetTaskHandler(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func parseFieldFilters(req *http.Request) map[string]string {
+	if err := req.ParseForm(); err != nil {
+		return nil
+	}
+
+	fields := make(map[string]string)
+	for key, values := range req.Form {
+		if strings.HasPrefix(key, fieldFilterPrefix) && len(values) > 0 {
+			fields[strings.TrimPrefix(key, fieldFilterPrefix)] = values[0]
+		}
+	}
+	return fields
+}
+
+// sendTaskServiceError reports validation and not found errors to the client
+// and hides the details of any other error.
+func sendTaskServiceError(res http.ResponseWriter, err error) {
+	if isClientError(err) {
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+	sendTaskError(res, http.StatusInternalServerError, "Internal server error")
+}
+
+func isClientError(err error) bool {
+	var (
+		notExistsErr errors.TaskNotExists
+		missingErr   errors.ReminderNotExists
+		dateErr      errors.InvalidDateFormat
+		repeatErr    errors.InvalidRepeatFormat
+		titleErr     errors.InvalidTitleFormat
+		fieldErr     errors.InvalidCustomField
+		templateErr  errors.InvalidTemplateFormat
+		bulkErr      errors.InvalidBulkOperation
+		reminderErr  errors.InvalidReminderFormat
+		channelErr   errors.InvalidNotificationChannel
+		webhookErr   errors.InvalidWebhookSubscription
+		priorityErr  errors.InvalidPriorityFormat
+		ruleErr      errors.InvalidRuleFormat
+		liveErr      errors.InvalidLiveMessage
+		feedErr      errors.InvalidCalendarFeed
+		importErr    errors.InvalidImportFile
+		caldavErr    errors.InvalidCalDAVResource
+		conditionErr errors.CalDAVPreconditionFailed
+		backupErr    errors.InvalidBackup
+		statsErr     errors.InvalidStatsRange
+		runningErr   errors.TimerAlreadyRunning
+		stoppedErr   errors.TimerNotRunning
+		estimateErr  errors.InvalidEstimateFormat
+		tagErr       errors.InvalidTagFormat
+		noFieldErr   errors.CustomFieldNotExists
+	)
+	return goerrors.As(err, &notExistsErr) ||
+		goerrors.As(err, &missingErr) ||
+		goerrors.As(err, &dateErr) ||
+		goerrors.As(err, &repeatErr) ||
+		goerrors.As(err, &titleErr) ||
+		goerrors.As(err, &fieldErr) ||
+		goerrors.As(err, &templateErr) ||
+		goerrors.As(err, &bulkErr) ||
+		goerrors.As(err, &reminderErr) ||
+		goerrors.As(err, &channelErr) ||
+		goerrors.As(err, &webhookErr) ||
+		goerrors.As(err, &priorityErr) ||
+		goerrors.As(err, &ruleErr) ||
+		goerrors.As(err, &liveErr) ||
+		goerrors.As(err, &feedErr) ||
+		goerrors.As(err, &importErr) ||
+		goerrors.As(err, &caldavErr) ||
+		goerrors.As(err, &conditionErr) ||
+		goerrors.As(err, &backupErr) ||
+		goerrors.As(err, &statsErr) ||
+		goerrors.As(err, &runningErr) ||
+		goerrors.As(err, &stoppedErr) ||
+		goerrors.As(err, &estimateErr) ||
+		goerrors.As(err, &tagErr) ||
+		goerrors.As(err, &noFieldErr)
+}
+
 func sendTaskError(res http.ResponseWriter, statusCode int, msg string) {
 	errorDto := model.CreateTaskErrorDto{
 		Error: msg,
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// ImportGoogleTasks imports the Tasks.json file of Google Takeout. The list
+// of a task becomes its project and its notes the comment. Google Tasks
+// doesn't export recurrences, so every task is imported once. Completed and
+// deleted tasks are skipped.
+func (s ImportService) ImportGoogleTasks(r io.Reader, dryRun bool) (model.ImportReport, error) {
+	report := model.ImportReport{DryRun: dryRun}
+
+	file := model.GoogleTasksDto{}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return report, errors.NewInvalidImportFile("invalid Google Tasks file: "+err.Error(), err)
+	}
+
+	for _, list := range file.Items {
+		for _, t := range list.Items {
+			report.Items = append(report.Items, s.googleTaskImportItem(list, t))
+		}
+	}
+
+	return report, s.commit(&report)
+}
+
+func (s ImportService) googleTaskImportItem(list model.GoogleTaskListDto, t model.GoogleTaskDto) model.ImportItem {
+	item := model.ImportItem{Ref: t.ID, Status: model.ImportItemSkipped}
+	switch {
+	case t.Deleted:
+		item.Reason = "task is deleted"
+		return item
+	case t.Status == "completed":
+		item.Reason = "task is completed"
+		return item
+	}
+
+	task := model.TaskDto{
+		Title:   strings.TrimSpace(t.Title),
+		Comment: strings.TrimSpace(t.Notes),
+		Project: strings.TrimSpace(list.Title),
+	}
+	// Due dates have no time: Google Tasks stores them as midnight UTC.
+	if len(t.Due) > 0 {
+		due, err := time.Parse(time.RFC3339, t.Due)
+		if err != nil {
+			item.Warnings = append(item.Warnings, "due date "+t.Due+" is not imported")
+		} else {
+			task.Date = due.UTC().Format("20060102")
+		}
+	}
+	return s.withTask(item, task)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const maxImportSize = 10 << 20
+
+func (s *Server) ImportICSHandler(res http.ResponseWriter, req *http.Request) {
+	dryRun, file, ok := s.importRequest(res, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	report, err := s.ImportService.ImportICS(file, dryRun, time.Now())
+	if err != nil {
+		s.Logger.Error("Error importing iCalendar file", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendImportReport(res, report)
+}
+
+// ImportCSVHandler imports a CSV file. The optional mapping parameter is a
+// JSON object mapping file headers to task columns.
+func (s *Server) ImportCSVHandler(res http.ResponseWriter, req *http.Request) {
+	dryRun, file, ok := s.importRequest(res, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	// FormValue would read a raw body sent as a form, so the mapping is taken
+	// from the query or the multipart form only.
+	value := req.URL.Query().Get("mapping")
+	if req.MultipartForm != nil && len(req.MultipartForm.Value["mapping"]) > 0 {
+		value = req.MultipartForm.Value["mapping"][0]
+	}
+
+	mapping := map[string]string{}
+	if len(value) != 0 {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			s.Logger.Error("Error parsing CSV import mapping", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, "invalid mapping: "+err.Error())
+			return
+		}
+	}
+
+	report, err := s.ImportService.ImportCSV(file, mapping, dryRun)
+	if err != nil {
+		s.Logger.Error("Error importing CSV file", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendImportReport(res, report)
+}
+
+func (s *Server) ImportTodoTxtHandler(res http.ResponseWriter, req *http.Request) {
+	dryRun, file, ok := s.importRequest(res, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	report, err := s.ImportService.ImportTodoTxt(file, dryRun, time.Now())
+	if err != nil {
+		s.Logger.Error("Error importing todo.txt file", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendImportReport(res, report)
+}
+
+// ImportTodoistHandler imports a Todoist CSV file or the JSON tasks of its API.
+func (s *Server) ImportTodoistHandler(res http.ResponseWriter, req *http.Request) {
+	dryRun, file, ok := s.importRequest(res, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	report, err := s.ImportService.ImportTodoist(file, dryRun, time.Now())
+	if err != nil {
+		s.Logger.Error("Error importing Todoist file", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendImportReport(res, report)
+}
+
+func (s *Server) ImportGoogleTasksHandler(res http.ResponseWriter, req *http.Request) {
+	dryRun, file, ok := s.importRequest(res, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	report, err := s.ImportService.ImportGoogleTasks(file, dryRun)
+	if err != nil {
+		s.Logger.Error("Error importing Google Tasks file", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendImportReport(res, report)
+}
+
+func (s *Server) ImportTaskwarriorHandler(res http.ResponseWriter, req *http.Request) {
+	dryRun, file, ok := s.importRequest(res, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	report, err := s.ImportService.ImportTaskwarrior(file, dryRun)
+	if err != nil {
+		s.Logger.Error("Error importing Taskwarrior file", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendImportReport(res, report)
+}
+
+// importRequest returns the dry_run parameter and the uploaded file: the
+// "file" field of a multipart form or the request body.
+func (s *Server) importRequest(res http.ResponseWriter, req *http.Request) (bool, io.ReadCloser, bool) {
+	req.Body = http.MaxBytesReader(res, req.Body, maxImportSize)
+
+	dryRun := false
+	if value := req.URL.Query().Get("dry_run"); len(value) != 0 {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			s.Logger.Error("Error parsing import dry run", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, "invalid dry_run value: "+value)
+			return false, nil, false
+		}
+	}
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := req.FormFile("file")
+		if err != nil {
+			s.Logger.Error("Error reading import file", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, err.Error())
+			return false, nil, false
+		}
+		return dryRun, file, true
+	}
+	return dryRun, req.Body, true
+}
+
+func (s *Server) sendImportReport(res http.ResponseWriter, report model.ImportReport) {
+	reportDto := model.ImportReportToImportReportDto(report)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(reportDto); err != nil {
+		s.Logger.Error("Error encoding import response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
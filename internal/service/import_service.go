@@ -0,0 +1,512 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/ical"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+// ImportService turns files of other tools into tasks. Every import builds a
+// report first and then, unless it is a dry run, adds all importable tasks
+// in one transaction.
+type ImportService struct {
+	taskService *TaskService
+	logger      *zap.Logger
+}
+
+func NewImportService(taskService *TaskService, logger *zap.Logger) *ImportService {
+	return &ImportService{taskService: taskService, logger: logger}
+}
+
+// ImportICS imports the VTODO and VEVENT entries of an iCalendar file. DUE,
+// or DTSTART when there is no DUE, becomes the task date; supported RRULEs
+// become the repeat rule and unsupported ones are reported as warnings.
+// Completed and cancelled entries are skipped.
+func (s ImportService) ImportICS(r io.Reader, dryRun bool, now time.Time) (model.ImportReport, error) {
+	report := model.ImportReport{DryRun: dryRun}
+
+	calendar, err := ical.Parse(r)
+	if err != nil {
+		return report, errors.NewInvalidImportFile("invalid iCalendar file: "+err.Error(), err)
+	}
+
+	today := s.taskService.Today(now)
+	for idx, c := range calendar.Components {
+		if c.Name != "VTODO" && c.Name != "VEVENT" {
+			continue
+		}
+		report.Items = append(report.Items, icsImportItem(c, idx+1, today))
+	}
+
+	return report, s.commit(&report)
+}
+
+func icsImportItem(c *ical.Component, number int, today time.Time) model.ImportItem {
+	item := model.ImportItem{Ref: c.Text("UID"), Status: model.ImportItemSkipped}
+	if len(item.Ref) == 0 {
+		item.Ref = c.Name + " #" + strconv.Itoa(number)
+	}
+
+	status := strings.ToUpper(c.Text("STATUS"))
+	if _, completed := c.Get("COMPLETED"); completed || status == "COMPLETED" || status == "CANCELLED" {
+		item.Reason = "entry is completed"
+		if status == "CANCELLED" {
+			item.Reason = "entry is cancelled"
+		}
+		return item
+	}
+
+	t, warnings, err := icsTask(c, today)
+	item.Warnings = warnings
+	if err != nil {
+		item.Status = model.ImportItemFailed
+		item.Reason = err.Error()
+		return item
+	}
+
+	item.Task = t
+	item.Status = model.ImportItemImported
+	return item
+}
+
+// icsTask maps a VTODO or VEVENT entry to a task. Parts of the entry that
+// can't be mapped are returned as warnings.
+func icsTask(c *ical.Component, today time.Time) (model.Task, []string, error) {
+	var warnings []string
+	t := model.Task{
+		Title:   strings.TrimSpace(c.Text("SUMMARY")),
+		Comment: strings.TrimSpace(c.Text("DESCRIPTION")),
+	}
+	if len(t.Title) == 0 {
+		return t, nil, errors.NewInvalidImportFile("entry has no SUMMARY", nil)
+	}
+
+	dateProperty, ok := c.Get("DUE")
+	if !ok || c.Name != "VTODO" {
+		dateProperty, ok = c.Get("DTSTART")
+	}
+	date := today
+	if ok {
+		value, err := ical.ParseDate(dateProperty)
+		if err != nil {
+			return t, nil, errors.NewInvalidImportFile(err.Error(), err)
+		}
+		date = value
+	}
+
+	if rrule, ok := c.Get("RRULE"); ok {
+		repeat, err := ical.RRuleToRepeat(rrule.Value, date)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("RRULE %s is not imported: %s", rrule.Value, err))
+		} else {
+			t.Repeat = repeat
+		}
+	}
+	for _, name := range []string{"RDATE", "EXDATE", "EXRULE"} {
+		if _, ok := c.Get(name); ok {
+			warnings = append(warnings, name+" is not supported and is ignored")
+		}
+	}
+
+	aligned, err := alignTaskDate(today, date, t.Repeat)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("repeat rule %q is not imported: %s", t.Repeat, err))
+		t.Repeat = ""
+		aligned, _ = alignTaskDate(today, date, "")
+	}
+	t.Date = aligned
+
+	if categories, ok := c.Get("CATEGORIES"); ok {
+		tags, err := model.NormalizeTags(splitICSList(categories.Value))
+		if err != nil {
+			warnings = append(warnings, "CATEGORIES are not imported: "+err.Error())
+		} else {
+			t.Tags = tags
+		}
+	}
+
+	if p, ok := c.Get("PRIORITY"); ok {
+		// iCalendar priorities go from 1 (highest) to 9 (lowest), 0 is none.
+		if value, err := strconv.Atoi(p.Value); err == nil && value > 0 && value <= 9 {
+			priority := 3 - (value-1)/3
+			t.Priority = &priority
+		}
+	}
+
+	return t, warnings, nil
+}
+
+// splitICSList splits a list value on commas that are not escaped.
+func splitICSList(value string) []string {
+	var res []string
+	var current strings.Builder
+	for idx := 0; idx < len(value); idx++ {
+		switch {
+		case value[idx] == '\\' && idx+1 < len(value):
+			current.WriteByte(value[idx])
+			current.WriteByte(value[idx+1])
+			idx++
+		case value[idx] == ',':
+			res = append(res, ical.UnescapeText(current.String()))
+			current.Reset()
+		default:
+			current.WriteByte(value[idx])
+		}
+	}
+	return append(res, ical.UnescapeText(current.String()))
+}
+
+// ImportCSV imports the rows of a CSV file with a header. mapping maps
+// header names to task columns, e.g. {"Срок": "date"}; headers named like
+// the columns of the export need no mapping, and mapping a header to an
+// empty string ignores the column. Every row is validated by the same rules
+// as tasks sent to the API; rows that fail are reported with their line.
+func (s ImportService) ImportCSV(r io.Reader, mapping map[string]string, dryRun bool) (model.ImportReport, error) {
+	report := model.ImportReport{DryRun: dryRun}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return report, err
+	}
+	// Spreadsheets save UTF-8 files with a byte order mark.
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = csvDelimiter(data)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return report, errors.NewInvalidImportFile("CSV file is empty", err)
+	}
+	if err != nil {
+		return report, errors.NewInvalidImportFile("invalid CSV file: "+err.Error(), err)
+	}
+
+	columns, warnings, err := csvColumns(header, mapping)
+	if err != nil {
+		return report, err
+	}
+	report.Warnings = warnings
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, errors.NewInvalidImportFile("invalid CSV file: "+err.Error(), err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		report.Items = append(report.Items, s.csvImportItem(record, columns, line))
+	}
+
+	return report, s.commit(&report)
+}
+
+// csvDelimiter guesses the delimiter from the header line: spreadsheets use
+// semicolons in locales where the comma is the decimal separator.
+func csvDelimiter(data []byte) rune {
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	delimiter, count := ',', bytes.Count(header, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if c := bytes.Count(header, []byte(string(candidate))); c > count {
+			delimiter, count = candidate, c
+		}
+	}
+	return delimiter
+}
+
+// csvColumns returns the task column of every CSV column, an empty string
+// for ignored ones.
+func csvColumns(header []string, mapping map[string]string) ([]string, []string, error) {
+	known := make(map[string]bool, len(model.CSVColumns))
+	for _, column := range model.CSVColumns {
+		known[column] = true
+	}
+
+	targets := make(map[string]string, len(mapping))
+	for name, column := range mapping {
+		column = strings.TrimSpace(column)
+		if len(column) > 0 && !known[column] && !strings.HasPrefix(column, model.CSVFieldColumnPrefix) {
+			return nil, nil, errors.NewInvalidImportFile("unknown task column in mapping: "+column, nil)
+		}
+		targets[strings.ToLower(strings.TrimSpace(name))] = column
+	}
+
+	var warnings []string
+	columns := make([]string, len(header))
+	mapped := make(map[string]string, len(header))
+	for idx, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		column, ok := targets[key]
+		switch {
+		case ok:
+			delete(targets, key)
+		case known[key] || strings.HasPrefix(key, model.CSVFieldColumnPrefix):
+			column = key
+			if strings.HasPrefix(key, model.CSVFieldColumnPrefix) {
+				column = model.CSVFieldColumnPrefix + strings.TrimSpace(name)[len(model.CSVFieldColumnPrefix):]
+			}
+		default:
+			warnings = append(warnings, fmt.Sprintf("column %q is ignored", name))
+		}
+
+		if column == model.CSVColumnID || column == model.CSVColumnRepeatText {
+			column = ""
+		}
+		if len(column) == 0 {
+			continue
+		}
+		if previous, ok := mapped[column]; ok {
+			return nil, nil, errors.NewInvalidImportFile(
+				fmt.Sprintf("columns %q and %q are both mapped to %s", previous, name, column), nil)
+		}
+		mapped[column] = name
+		columns[idx] = column
+	}
+
+	for name := range targets {
+		return nil, nil, errors.NewInvalidImportFile(fmt.Sprintf("mapped column %q is not in the file", name), nil)
+	}
+	if _, ok := mapped[model.CSVColumnTitle]; !ok {
+		return nil, nil, errors.NewInvalidImportFile("no column is mapped to title", nil)
+	}
+	return columns, warnings, nil
+}
+
+// csvImportItem builds the JSON form of the row and decodes it as a task.
+func (s ImportService) csvImportItem(record []string, columns []string, line int) model.ImportItem {
+	item := model.ImportItem{Line: line, Status: model.ImportItemFailed}
+
+	values := make(map[string]any, len(columns))
+	fields := make(map[string]any)
+	empty := true
+	for idx, column := range columns {
+		if idx >= len(record) || len(column) == 0 {
+			continue
+		}
+		value := csvCellValue(strings.TrimSpace(record[idx]))
+		empty = empty && len(value) == 0
+
+		switch column {
+		case model.CSVColumnPriority, model.CSVColumnEstimate:
+			if len(value) == 0 {
+				continue
+			}
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				item.Reason = fmt.Sprintf("%s must be a whole number: %s", column, value)
+				return item
+			}
+			values[column] = number
+		case model.CSVColumnTags:
+			values[column] = strings.FieldsFunc(value, func(r rune) bool {
+				return r == ',' || unicode.IsSpace(r)
+			})
+		default:
+			if name, ok := strings.CutPrefix(column, model.CSVFieldColumnPrefix); ok {
+				fields[name] = value
+				continue
+			}
+			values[column] = value
+		}
+	}
+	if empty {
+		item.Status = model.ImportItemSkipped
+		item.Reason = "row is empty"
+		return item
+	}
+	if len(fields) > 0 {
+		values["fields"] = fields
+	}
+
+	t, err := s.decodeTask(values)
+	if err != nil {
+		item.Reason = err.Error()
+		return item
+	}
+
+	item.Task = t
+	item.Status = model.ImportItemImported
+	return item
+}
+
+// ImportTodoTxt imports the lines of a todo.txt file, see model.ParseTodoTxt.
+// Completed tasks are skipped: completing a task removes it or moves it to
+// its next date.
+func (s ImportService) ImportTodoTxt(r io.Reader, dryRun bool, now time.Time) (model.ImportReport, error) {
+	report := model.ImportReport{DryRun: dryRun}
+	today := s.taskService.Today(now)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if len(text) == 0 {
+			continue
+		}
+		report.Items = append(report.Items, s.todoTxtImportItem(text, line, today))
+	}
+	if err := scanner.Err(); err != nil {
+		return report, errors.NewInvalidImportFile("invalid todo.txt file: "+err.Error(), err)
+	}
+
+	return report, s.commit(&report)
+}
+
+// todoTxtImportItem keeps the due date of the line even if it is past, as a
+// restored backup does: todo.txt files are mostly exported task lists.
+func (s ImportService) todoTxtImportItem(text string, line int, today time.Time) model.ImportItem {
+	item := model.ImportItem{Line: line, Status: model.ImportItemFailed}
+
+	entry, err := model.ParseTodoTxt(text)
+	item.Warnings = entry.Warnings
+	if err != nil {
+		item.Reason = err.Error()
+		return item
+	}
+	if entry.Completed {
+		item.Status = model.ImportItemSkipped
+		item.Reason = "task is completed"
+		return item
+	}
+
+	t, err := entry.TaskOn(today)
+	if err == nil {
+		t.Fields, err = s.taskService.normalizeFields(t.Fields)
+	}
+	if err != nil {
+		item.Reason = err.Error()
+		return item
+	}
+
+	item.Task = t
+	item.Status = model.ImportItemImported
+	return item
+}
+
+// withTask decodes the task of the item and marks the item imported, or
+// failed if the task is invalid.
+func (s ImportService) withTask(item model.ImportItem, value any) model.ImportItem {
+	t, err := s.decodeTask(value)
+	if err != nil {
+		item.Status = model.ImportItemFailed
+		item.Reason = err.Error()
+		return item
+	}
+
+	item.Task = t
+	item.Status = model.ImportItemImported
+	return item
+}
+
+// dailyRepeat returns the repeat rule of an interval in days.
+func dailyRepeat(days int) (string, error) {
+	if days < 1 || days > 400 {
+		return "", fmt.Errorf("interval must be from 1 to 400 days")
+	}
+	return "d " + strconv.Itoa(days), nil
+}
+
+// monthlyRepeat returns the repeat rule of a recurrence every step months on
+// the day of start. Steps that don't divide a year can't be expressed with a
+// list of months.
+func monthlyRepeat(start time.Time, step int) (string, error) {
+	if step == 1 {
+		return "m " + strconv.Itoa(start.Day()), nil
+	}
+	if step < 1 || 12%step != 0 {
+		return "", fmt.Errorf("only intervals of 1, 2, 3, 4, 6 and 12 months are supported")
+	}
+	if step == 12 {
+		return "y", nil
+	}
+
+	months := make([]int, 0, 12/step)
+	for month := int(start.Month()); len(months) < 12/step; month += step {
+		months = append(months, (month-1)%12+1)
+	}
+	return fmt.Sprintf("m %d %s", start.Day(), joinInts(months)), nil
+}
+
+// firstOccurrence returns the first date of a recurring task without a start
+// date: today for intervals, else the first matching day from today.
+func firstOccurrence(today time.Time, repeat string) time.Time {
+	if repeat == "y" || strings.HasPrefix(repeat, "d ") {
+		return today
+	}
+	yesterday := today.AddDate(0, 0, -1).Format("20060102")
+	next, err := utils.NextDate(today.AddDate(0, 0, -1), yesterday, repeat)
+	if err != nil {
+		return today
+	}
+	date, err := time.Parse("20060102", next)
+	if err != nil {
+		return today
+	}
+	return date
+}
+
+// decodeTask decodes the JSON form of a task, so that imported tasks are
+// validated exactly as API requests are.
+func (s ImportService) decodeTask(value any) (model.Task, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return model.Task{}, err
+	}
+	t := model.Task{}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, err
+	}
+
+	t.Fields, err = s.taskService.normalizeFields(t.Fields)
+	return t, err
+}
+
+// commit adds the tasks of imported items unless the import is a dry run.
+func (s ImportService) commit(report *model.ImportReport) error {
+	if report.DryRun {
+		return nil
+	}
+
+	var tasks []model.Task
+	for _, item := range report.Items {
+		if item.Status == model.ImportItemImported {
+			tasks = append(tasks, item.Task)
+		}
+	}
+
+	ids, err := s.taskService.AddTasks(tasks)
+	if err != nil {
+		return err
+	}
+
+	next := 0
+	for idx := range report.Items {
+		if report.Items[idx].Status == model.ImportItemImported {
+			report.Items[idx].Task.ID = ids[next]
+			next++
+		}
+	}
+	report.Committed = true
+	return nil
+}
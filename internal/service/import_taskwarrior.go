@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+var taskwarriorInterval = regexp.MustCompile(`^(\d+)\s*(d|day|days|w|wk|wks|week|weeks|mo|mos|month|months|q|y|yr|yrs|year|years)$`)
+
+// ImportTaskwarrior imports the output of "task export": a JSON array or,
+// as older versions write it, one object per line. A recurring task is
+// imported from its template, the task with the "recurring" status; the
+// instances generated from it are skipped, as are completed and deleted
+// tasks.
+func (s ImportService) ImportTaskwarrior(r io.Reader, dryRun bool) (model.ImportReport, error) {
+	report := model.ImportReport{DryRun: dryRun}
+
+	tasks, err := decodeTaskwarrior(r)
+	if err != nil {
+		return report, errors.NewInvalidImportFile("invalid Taskwarrior file: "+err.Error(), err)
+	}
+	for _, t := range tasks {
+		report.Items = append(report.Items, s.taskwarriorImportItem(t))
+	}
+
+	return report, s.commit(&report)
+}
+
+func decodeTaskwarrior(r io.Reader) ([]model.TaskwarriorTaskDto, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var tasks []model.TaskwarriorTaskDto
+	if len(data) > 0 && data[0] == '[' {
+		return tasks, json.Unmarshal(data, &tasks)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		t := model.TaskwarriorTaskDto{}
+		if err := decoder.Decode(&t); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+func (s ImportService) taskwarriorImportItem(t model.TaskwarriorTaskDto) model.ImportItem {
+	item := model.ImportItem{Ref: t.UUID, Status: model.ImportItemSkipped}
+	switch {
+	case t.Status == "deleted":
+		item.Reason = "task is deleted"
+	case t.Status == "completed":
+		item.Reason = "task is completed"
+	case len(t.Parent) > 0:
+		item.Reason = "task is an instance of recurring task " + t.Parent
+	}
+	if len(item.Reason) > 0 {
+		return item
+	}
+
+	task := model.TaskDto{
+		Title:    strings.TrimSpace(t.Description),
+		Project:  t.Project,
+		Tags:     t.Tags,
+		Priority: taskwarriorPriority(t.Priority),
+	}
+	notes := make([]string, 0, len(t.Annotations))
+	for _, a := range t.Annotations {
+		notes = append(notes, strings.TrimSpace(a.Description))
+	}
+	task.Comment = strings.Join(notes, "\n\n")
+
+	due := time.Time{}
+	if len(t.Due) > 0 {
+		value, err := time.Parse("20060102T150405Z", t.Due)
+		if err != nil {
+			item.Warnings = append(item.Warnings, "due date "+t.Due+" is not imported")
+		} else {
+			due = value.In(time.Local)
+			task.Date = due.Format("20060102")
+		}
+	}
+
+	if len(t.Recur) > 0 {
+		repeat, err := taskwarriorRepeat(t.Recur, due)
+		if err != nil {
+			item.Warnings = append(item.Warnings, fmt.Sprintf("recurrence %q is not imported: %s", t.Recur, err))
+		}
+		task.Repeat = repeat
+		if len(t.Until) > 0 {
+			item.Warnings = append(item.Warnings, "end of recurrence "+t.Until+" is not imported")
+		}
+	}
+	return s.withTask(item, task)
+}
+
+func taskwarriorPriority(priority string) int {
+	switch strings.ToUpper(priority) {
+	case "H":
+		return 3
+	case "M":
+		return 2
+	case "L":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// taskwarriorRepeat maps a recur value to a repeat rule counted from the due
+// date, which Taskwarrior requires of recurring tasks.
+func taskwarriorRepeat(recur string, due time.Time) (string, error) {
+	if due.IsZero() {
+		return "", fmt.Errorf("recurring task has no due date")
+	}
+
+	value := strings.ToLower(strings.TrimSpace(recur))
+	switch value {
+	case "daily", "day":
+		return "d 1", nil
+	case "weekdays":
+		return "w 1,2,3,4,5", nil
+	case "weekly", "week", "sennight":
+		return "d 7", nil
+	case "biweekly", "fortnight":
+		return "d 14", nil
+	case "monthly", "month":
+		return monthlyRepeat(due, 1)
+	case "bimonthly":
+		return monthlyRepeat(due, 2)
+	case "quarterly", "quarter":
+		return monthlyRepeat(due, 3)
+	case "semiannual":
+		return monthlyRepeat(due, 6)
+	case "annual", "yearly", "year":
+		return "y", nil
+	}
+
+	match := taskwarriorInterval.FindStringSubmatch(value)
+	if match == nil {
+		return "", fmt.Errorf("unknown recurrence")
+	}
+	count, _ := strconv.Atoi(match[1])
+	switch match[2] {
+	case "d", "day", "days":
+		return dailyRepeat(count)
+	case "w", "wk", "wks", "week", "weeks":
+		return dailyRepeat(count * 7)
+	case "mo", "mos", "month", "months":
+		return monthlyRepeat(due, count)
+	case "q":
+		return monthlyRepeat(due, count*3)
+	default:
+		if count != 1 {
+			return "", fmt.Errorf("only yearly recurrence is supported")
+		}
+		return "y", nil
+	}
+}
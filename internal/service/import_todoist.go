@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+var (
+	todoistInterval = regexp.MustCompile(`^(\d+|other) (day|days|week|weeks|month|months)$`)
+	todoistOrdinal  = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+)
+
+// todoistDateLayouts are the English date forms of Todoist due strings.
+var todoistDateLayouts = []string{"2006-01-02", "Jan 2 2006", "January 2 2006", "2 Jan 2006", "2 January 2006"}
+
+// ImportTodoist imports a Todoist export: the CSV file of a project, the
+// tasks of the REST API or the data of the Sync API. Recurring due dates
+// become repeat rules where one matches; other recurrences are reported as
+// warnings. Completed and deleted tasks are skipped.
+func (s ImportService) ImportTodoist(r io.Reader, dryRun bool, now time.Time) (model.ImportReport, error) {
+	report := model.ImportReport{DryRun: dryRun}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return report, err
+	}
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	today := s.taskService.Today(now)
+	switch {
+	case len(data) == 0:
+		return report, errors.NewInvalidImportFile("Todoist file is empty", nil)
+	case data[0] == '[':
+		backup := model.TodoistBackupDto{}
+		if err := json.Unmarshal(data, &backup.Items); err != nil {
+			return report, errors.NewInvalidImportFile("invalid Todoist file: "+err.Error(), err)
+		}
+		report.Items = s.todoistItems(backup, today)
+	case data[0] == '{':
+		backup := model.TodoistBackupDto{}
+		if err := json.Unmarshal(data, &backup); err != nil {
+			return report, errors.NewInvalidImportFile("invalid Todoist file: "+err.Error(), err)
+		}
+		report.Items = s.todoistItems(backup, today)
+	default:
+		report.Items, err = s.todoistCSVItems(data, today)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, s.commit(&report)
+}
+
+func (s ImportService) todoistItems(backup model.TodoistBackupDto, today time.Time) []model.ImportItem {
+	projects := make(map[model.ExternalID]string, len(backup.Projects))
+	for _, p := range backup.Projects {
+		projects[p.ID] = p.Name
+	}
+	notes := make(map[model.ExternalID][]string)
+	for _, n := range backup.Notes {
+		notes[n.ItemID] = append(notes[n.ItemID], n.Content)
+	}
+
+	items := make([]model.ImportItem, 0, len(backup.Items))
+	for _, t := range backup.Items {
+		item := model.ImportItem{Ref: string(t.ID), Status: model.ImportItemSkipped}
+		switch {
+		case t.IsDeleted:
+			item.Reason = "task is deleted"
+		case t.Checked || t.IsCompleted:
+			item.Reason = "task is completed"
+		}
+		if len(item.Reason) > 0 {
+			items = append(items, item)
+			continue
+		}
+
+		task := model.TaskDto{
+			Title:    strings.TrimSpace(t.Content),
+			Comment:  strings.TrimSpace(strings.Join(append([]string{t.Description}, notes[t.ID]...), "\n\n")),
+			Project:  projects[t.ProjectID],
+			Priority: todoistPriority(t.Priority),
+			Tags:     t.Labels,
+		}
+		if t.Duration != nil {
+			task.Estimate = todoistEstimate(t.Duration.Amount, t.Duration.Unit)
+		}
+		if t.Due != nil {
+			recurrence := ""
+			if t.Due.IsRecurring {
+				recurrence = t.Due.String
+			}
+			task.Date, task.Repeat, item.Warnings = todoistDue(t.Due.Date, recurrence, today)
+		}
+		items = append(items, s.withTask(item, task))
+	}
+	return items
+}
+
+// todoistCSVItems imports the rows of a project CSV file. Notes follow the
+// task they belong to; sections and empty rows have no task value.
+func (s ImportService) todoistCSVItems(data []byte, today time.Time) ([]model.ImportItem, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.NewInvalidImportFile("invalid Todoist CSV file: "+err.Error(), err)
+	}
+	columns := make(map[string]int, len(header))
+	for idx, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = idx
+	}
+	if _, ok := columns["CONTENT"]; !ok {
+		return nil, errors.NewInvalidImportFile("Todoist CSV file has no CONTENT column", nil)
+	}
+	value := func(record []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var items []model.ImportItem
+	last := -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.NewInvalidImportFile("invalid Todoist CSV file: "+err.Error(), err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		switch strings.ToLower(value(record, "TYPE")) {
+		case "task":
+			item := model.ImportItem{Line: line}
+			task := model.TaskDto{Comment: value(record, "DESCRIPTION")}
+			var words []string
+			for _, word := range strings.Fields(value(record, "CONTENT")) {
+				if len(word) > 1 && word[0] == '@' {
+					task.Tags = append(task.Tags, word[1:])
+					continue
+				}
+				words = append(words, word)
+			}
+			task.Title = strings.Join(words, " ")
+
+			// Files number priorities as the app shows them: 1 is p1.
+			if priority, err := strconv.Atoi(value(record, "PRIORITY")); err == nil {
+				task.Priority = todoistPriority(5 - priority)
+			}
+			if amount, err := strconv.Atoi(value(record, "DURATION")); err == nil {
+				task.Estimate = todoistEstimate(amount, value(record, "DURATION_UNIT"))
+			}
+			if due := value(record, "DATE"); len(due) > 0 {
+				task.Date, task.Repeat, item.Warnings = todoistCSVDue(due, today)
+			}
+
+			items = append(items, s.withTask(item, task))
+			last = len(items) - 1
+		case "note":
+			if last >= 0 && items[last].Status == model.ImportItemImported {
+				note := value(record, "CONTENT")
+				items[last].Task.Comment = strings.TrimSpace(items[last].Task.Comment + "\n\n" + note)
+			}
+		case "section":
+			last = -1
+		}
+	}
+	return items, nil
+}
+
+// todoistPriority maps an API priority, from 1 (none) to 4 (p1).
+func todoistPriority(priority int) int {
+	if priority <= 1 || priority > 4 {
+		return 0
+	}
+	return priority - 1
+}
+
+func todoistEstimate(amount int, unit string) int {
+	if amount <= 0 {
+		return 0
+	}
+	if strings.EqualFold(unit, "day") {
+		return amount * 24 * 60
+	}
+	return amount
+}
+
+// todoistDue returns the task date and repeat rule of an API due date. The
+// date may have a time, which tasks don't keep.
+func todoistDue(date string, recurrence string, today time.Time) (string, string, []string) {
+	var warnings []string
+	start := today
+	if len(date) >= len("2006-01-02") {
+		value, err := time.Parse("2006-01-02", date[:len("2006-01-02")])
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("due date %s is not imported", date))
+		} else {
+			start = value
+		}
+	}
+
+	repeat := ""
+	if len(recurrence) > 0 {
+		var err error
+		repeat, err = todoistRepeat(recurrence, start)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("recurrence %q is not imported: %s", recurrence, err))
+		}
+	}
+	return start.Format("20060102"), repeat, warnings
+}
+
+// todoistCSVDue returns the task date and repeat rule of the DATE column,
+// which holds the due string as typed, e.g. "every monday" or "Jan 5 2030".
+func todoistCSVDue(due string, today time.Time) (string, string, []string) {
+	value := strings.ToLower(todoistWithoutTime(due))
+	if todoistIsRecurring(value) {
+		repeat, err := todoistRepeat(value, today)
+		if err != nil {
+			return "", "", []string{fmt.Sprintf("recurrence %q is not imported: %s", due, err)}
+		}
+		return firstOccurrence(today, repeat).Format("20060102"), repeat, nil
+	}
+
+	switch value {
+	case "today":
+		return today.Format("20060102"), "", nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1).Format("20060102"), "", nil
+	}
+	for _, layout := range todoistDateLayouts {
+		if date, err := time.Parse(layout, todoistWithoutTime(due)); err == nil {
+			return date.Format("20060102"), "", nil
+		}
+	}
+	return "", "", []string{fmt.Sprintf("due date %q is not imported", due)}
+}
+
+func todoistWithoutTime(value string) string {
+	value = strings.TrimSpace(value)
+	if idx := strings.Index(strings.ToLower(value), " at "); idx >= 0 {
+		value = value[:idx]
+	}
+	return strings.ReplaceAll(value, ",", "")
+}
+
+func todoistIsRecurring(value string) bool {
+	for _, prefix := range []string{"every", "ev ", "ev!", "daily", "weekly", "monthly", "yearly", "annually"} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// todoistRepeat maps an English recurring due string to a repeat rule. Rules
+// counted from the start date, such as "every month", use its day.
+func todoistRepeat(recurrence string, start time.Time) (string, error) {
+	value := strings.ToLower(todoistWithoutTime(recurrence))
+	for _, word := range []string{"starting", "from", "until", "ending", "for"} {
+		if strings.Contains(" "+value+" ", " "+word+" ") {
+			return "", fmt.Errorf("recurrences with start or end dates are not supported")
+		}
+	}
+
+	switch value {
+	case "daily":
+		return "d 1", nil
+	case "weekly":
+		return "d 7", nil
+	case "monthly":
+		return monthlyRepeat(start, 1)
+	case "yearly", "annually":
+		return "y", nil
+	}
+
+	rest := ""
+	for _, prefix := range []string{"every! ", "every ", "ev! ", "ev "} {
+		if value, ok := strings.CutPrefix(value, prefix); ok {
+			rest = strings.TrimSpace(value)
+			break
+		}
+	}
+	switch rest {
+	case "":
+		return "", fmt.Errorf("unknown recurrence")
+	case "day":
+		return "d 1", nil
+	case "week":
+		return "d 7", nil
+	case "month":
+		return monthlyRepeat(start, 1)
+	case "year":
+		return "y", nil
+	case "weekday", "workday":
+		return "w 1,2,3,4,5", nil
+	case "weekend":
+		return "w 6,7", nil
+	case "last day", "last day of the month":
+		return "m -1", nil
+	}
+
+	if match := todoistInterval.FindStringSubmatch(rest); match != nil {
+		count := 2
+		if match[1] != "other" {
+			count, _ = strconv.Atoi(match[1])
+		}
+		if strings.HasPrefix(match[2], "month") {
+			return monthlyRepeat(start, count)
+		}
+		if strings.HasPrefix(match[2], "week") {
+			count *= 7
+		}
+		return dailyRepeat(count)
+	}
+
+	words := strings.FieldsFunc(strings.ReplaceAll(rest, " and ", " "), func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	weekdays := make([]int, 0, len(words))
+	days := make([]int, 0, len(words))
+	for _, word := range words {
+		if weekday, ok := utils.ParseWeekdayName(word); ok {
+			weekdays = append(weekdays, utils.WeekdayNumber(weekday))
+			continue
+		}
+		if word == "last" {
+			days = append(days, -1)
+			continue
+		}
+		if match := todoistOrdinal.FindStringSubmatch(word); match != nil {
+			if day, _ := strconv.Atoi(match[1]); day >= 1 && day <= 31 {
+				days = append(days, day)
+				continue
+			}
+		}
+		return "", fmt.Errorf("unknown recurrence")
+	}
+	switch {
+	case len(weekdays) > 0 && len(days) == 0:
+		return "w " + joinInts(weekdays), nil
+	case len(days) > 0 && len(weekdays) == 0:
+		return "m " + joinInts(days), nil
+	default:
+		return "", fmt.Errorf("unknown recurrence")
+	}
+}
+
+// joinInts returns the sorted distinct numbers separated by commas, with
+// negative ones, meaning days from the end of the month, last.
+func joinInts(values []int) string {
+	sort.Slice(values, func(i, j int) bool {
+		if (values[i] < 0) != (values[j] < 0) {
+			return values[j] < 0
+		}
+		return values[i] < values[j]
+	})
+	parts := make([]string, 0, len(values))
+	for idx, value := range values {
+		if idx > 0 && values[idx-1] == value {
+			continue
+		}
+		parts = append(parts, strconv.Itoa(value))
+	}
+	return strings.Join(parts, ",")
+}
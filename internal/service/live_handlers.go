@@ -0,0 +1,305 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+const (
+	maxLiveMessageSize   = 1 << 20
+	maxLiveSubscriptions = 32
+	liveWriteTimeout     = 10 * time.Second
+)
+
+// The default origin check rejects cross-site connections, which matters
+// because the token cookie is sent with the handshake.
+var liveUpgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+var liveConnections atomic.Int64
+
+// liveFilter selects events by task project and date; nil Project and zero
+// dates match any.
+type liveFilter struct {
+	Project *string
+	From    time.Time
+	To      time.Time
+}
+
+func (f liveFilter) matches(t model.Task) bool {
+	if f.Project != nil {
+		project := ""
+		if t.Project != nil {
+			project = *t.Project
+		}
+		if project != *f.Project {
+			return false
+		}
+	}
+	if !f.From.IsZero() && t.Date.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && t.Date.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// liveSession serves one WebSocket connection. Mutations are made with the
+// session's own event source, so the events they cause are sent to other
+// sessions only.
+type liveSession struct {
+	server      *Server
+	conn        *websocket.Conn
+	taskService *TaskService
+	source      string
+	replies     chan model.LiveResponseDto
+	done        chan struct{}
+	writerDone  chan struct{}
+
+	mu               sync.Mutex
+	subscriptions    map[int]liveFilter
+	nextSubscription int
+}
+
+// LiveTasksHandler upgrades the request to a WebSocket connection through
+// which the client subscribes to task events and changes tasks.
+func (s *Server) LiveTasksHandler(res http.ResponseWriter, req *http.Request) {
+	conn, err := liveUpgrader.Upgrade(res, req, nil)
+	if err != nil {
+		s.Logger.Error("Error upgrading live connection", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	source := fmt.Sprintf("websocket:%d", liveConnections.Add(1))
+	session := &liveSession{
+		server:        s,
+		conn:          conn,
+		taskService:   s.TaskService.WithSource(source),
+		source:        source,
+		replies:       make(chan model.LiveResponseDto, streamBufferSize),
+		done:          make(chan struct{}),
+		writerDone:    make(chan struct{}),
+		subscriptions: make(map[int]liveFilter),
+	}
+
+	live, unsubscribe := s.StreamService.Subscribe()
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		session.write(live)
+	}()
+
+	session.read()
+	close(session.done)
+	wg.Wait()
+}
+
+func (l *liveSession) read() {
+	heartbeat := l.server.StreamService.Heartbeat()
+	l.conn.SetReadLimit(maxLiveMessageSize)
+	l.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	l.conn.SetPongHandler(func(string) error {
+		return l.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	})
+
+	for {
+		_, data, err := l.conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				l.server.Logger.Debug("Live connection closed", zap.Error(err))
+			}
+			return
+		}
+
+		req := model.LiveRequestDto{}
+		msg := model.LiveResponseDto{Type: model.LiveError, Error: "invalid message"}
+		if err := json.Unmarshal(data, &req); err == nil {
+			msg = l.handle(req)
+		}
+		if !l.reply(msg) {
+			return
+		}
+	}
+}
+
+// reply queues the message for the writer and reports false if the session
+// is over or the writer has stopped.
+func (l *liveSession) reply(msg model.LiveResponseDto) bool {
+	select {
+	case l.replies <- msg:
+		return true
+	case <-l.done:
+		return false
+	case <-l.writerDone:
+		return false
+	}
+}
+
+func (l *liveSession) write(live <-chan events.Event) {
+	defer close(l.writerDone)
+	ping := time.NewTicker(l.server.StreamService.Heartbeat())
+	defer ping.Stop()
+
+	for {
+		var err error
+		l.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		select {
+		case <-l.done:
+			return
+		case msg := <-l.replies:
+			err = l.conn.WriteJSON(msg)
+		case e, ok := <-live:
+			if !ok {
+				// The client fell behind or the server is shutting down.
+				l.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect"))
+				l.conn.Close()
+				return
+			}
+			if msg, ok := l.event(e); ok {
+				err = l.conn.WriteJSON(msg)
+			}
+		case <-ping.C:
+			err = l.conn.WriteMessage(websocket.PingMessage, nil)
+		}
+
+		if err != nil {
+			l.conn.Close()
+			return
+		}
+	}
+}
+
+// event returns the message for the event if the session is subscribed to
+// it.
+func (l *liveSession) event(e events.Event) (model.LiveResponseDto, bool) {
+	base := e.Base()
+	if base.Source == l.source {
+		return model.LiveResponseDto{}, false
+	}
+
+	l.mu.Lock()
+	subscription := 0
+	for id, f := range l.subscriptions {
+		if f.matches(base.Task) && (subscription == 0 || id < subscription) {
+			subscription = id
+		}
+	}
+	l.mu.Unlock()
+	if subscription == 0 {
+		return model.LiveResponseDto{}, false
+	}
+
+	payload := taskEventPayload(e)
+	return model.LiveResponseDto{
+		Type:         model.LiveEvent,
+		Subscription: subscription,
+		Event:        payload.Event,
+		EventID:      strconv.FormatInt(base.ID, 10),
+		Task:         &payload.Task,
+		From:         payload.From,
+	}, true
+}
+
+func (l *liveSession) handle(req model.LiveRequestDto) model.LiveResponseDto {
+	ack := model.LiveResponseDto{Type: model.LiveAck, ID: req.ID}
+	var err error
+	switch req.Type {
+	case model.LiveSubscribe:
+		ack.Subscription, err = l.subscribe(req)
+	case model.LiveUnsubscribe:
+		l.mu.Lock()
+		_, ok := l.subscriptions[req.Subscription]
+		delete(l.subscriptions, req.Subscription)
+		l.mu.Unlock()
+		if !ok {
+			err = errors.NewInvalidLiveMessage(fmt.Sprintf("subscription %d doesn`t exist", req.Subscription), nil)
+		}
+		ack.Subscription = req.Subscription
+	case model.LiveCreate, model.LiveUpdate:
+		t := model.Task{}
+		if err = json.Unmarshal(req.Task, &t); err != nil {
+			if !isClientError(err) {
+				err = errors.NewInvalidLiveMessage("invalid task: "+err.Error(), err)
+			}
+			break
+		}
+		if req.Type == model.LiveCreate {
+			t.ID, err = l.taskService.AddTask(t)
+		} else {
+			err = l.taskService.UpdateTask(t)
+		}
+		ack.TaskID = strconv.Itoa(t.ID)
+	case model.LiveComplete, model.LiveDelete:
+		var id int
+		if id, err = strconv.Atoi(req.TaskID); err != nil {
+			err = errors.NewInvalidLiveMessage("invalid task id: "+req.TaskID, err)
+			break
+		}
+		if req.Type == model.LiveComplete {
+			err = l.taskService.CompleteTask(id)
+		} else {
+			err = l.taskService.DeleteTask(id)
+		}
+		ack.TaskID = req.TaskID
+	default:
+		err = errors.NewInvalidLiveMessage("unknown message type: "+req.Type, nil)
+	}
+
+	if err != nil {
+		l.server.Logger.Error("Error handling live message", zap.String("type", req.Type), zap.Error(err))
+		msg := err.Error()
+		if !isClientError(err) {
+			msg = "Internal server error"
+		}
+		return model.LiveResponseDto{Type: model.LiveError, ID: req.ID, Error: msg}
+	}
+	return ack
+}
+
+func (l *liveSession) subscribe(req model.LiveRequestDto) (int, error) {
+	f := liveFilter{}
+	if req.Project != nil {
+		project := strings.TrimSpace(*req.Project)
+		f.Project = &project
+	}
+
+	var err error
+	if len(req.From) > 0 {
+		if f.From, err = utils.ParseDate(req.From); err != nil {
+			return 0, err
+		}
+	}
+	if len(req.To) > 0 {
+		if f.To, err = utils.ParseDate(req.To); err != nil {
+			return 0, err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.subscriptions) >= maxLiveSubscriptions {
+		return 0, errors.NewInvalidLiveMessage(fmt.Sprintf("at most %d subscriptions are allowed", maxLiveSubscriptions), nil)
+	}
+	l.nextSubscription++
+	l.subscriptions[l.nextSubscription] = f
+	return l.nextSubscription, nil
+}
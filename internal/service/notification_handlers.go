@@ -0,0 +1,181 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) GetNotificationChannelsHandler(res http.ResponseWriter, req *http.Request) {
+	channels, err := s.NotificationService.GetChannels()
+	if err != nil {
+		s.Logger.Error("Error getting notification channels", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	channelsDto := model.NotificationChannelsDto{
+		Channels: model.NotificationChannelsToNotificationChannelsDto(channels),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(channelsDto); err != nil {
+		s.Logger.Error("Error encoding get notification channels response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetNotificationChannelHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get notification channel id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	channel, err := s.NotificationService.GetChannel(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting notification channel", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	channelDto := model.NotificationChannelToNotificationChannelDto(channel)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(channelDto); err != nil {
+		s.Logger.Error("Error encoding get notification channel response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) AddNotificationChannelHandler(res http.ResponseWriter, req *http.Request) {
+	channel := model.NotificationChannel{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&channel); err != nil {
+		s.Logger.Error("Error decoding add notification channel", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.NotificationService.AddChannel(channel)
+	if err != nil {
+		s.Logger.Error("Error adding notification channel", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := model.CreateTaskSuccessDto{
+		ID: id,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding add notification channel response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) UpdateNotificationChannelHandler(res http.ResponseWriter, req *http.Request) {
+	channel := model.NotificationChannel{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&channel); err != nil {
+		s.Logger.Error("Error decoding update notification channel", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := s.NotificationService.UpdateChannel(channel)
+	if err != nil {
+		s.Logger.Error("Error updating notification channel", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding update notification channel response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DeleteNotificationChannelHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing delete notification channel id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.NotificationService.DeleteChannel(idNumber)
+	if err != nil {
+		s.Logger.Error("Error deleting notification channel", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding delete notification channel response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// TestNotificationHandler sends a test notification to the stored channel
+// with the given id or to the config channel of the given kind.
+func (s *Server) TestNotificationHandler(res http.ResponseWriter, req *http.Request) {
+	var err error
+	if kind := req.FormValue("kind"); len(kind) > 0 {
+		err = s.NotificationService.TestConfigChannel(req.Context(), kind)
+	} else {
+		idNumber, parseErr := strconv.Atoi(req.FormValue("id"))
+		if parseErr != nil {
+			s.Logger.Error("Error parsing test notification channel id", zap.Error(parseErr))
+			sendTaskError(res, http.StatusBadRequest, parseErr.Error())
+			return
+		}
+		err = s.NotificationService.TestChannel(req.Context(), idNumber)
+	}
+
+	if err != nil {
+		s.Logger.Error("Error sending test notification", zap.Error(err))
+		if isClientError(err) {
+			sendTaskError(res, http.StatusBadRequest, err.Error())
+			return
+		}
+		sendTaskError(res, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding test notification response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	goerrors "errors"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/notify"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+var testNotification = model.Notification{
+	Title:   "Test notification",
+	Message: "Notifications from the task manager are delivered to this channel.",
+}
+
+// NotificationService delivers notifications to every enabled channel, both
+// stored in the database and set up in the config. When there are no
+// channels, notifications are written to the log.
+type NotificationService struct {
+	store          storage.NotificationChannelStore
+	configChannels []model.NotificationChannel
+	logNotifier    notify.Notifier
+	logger         *zap.Logger
+}
+
+func NewNotificationService(store storage.NotificationChannelStore, configChannels []model.NotificationChannel,
+	logger *zap.Logger) *NotificationService {
+	return &NotificationService{
+		store:          store,
+		configChannels: configChannels,
+		logNotifier:    notify.NewLogNotifier(logger),
+		logger:         logger,
+	}
+}
+
+func (s NotificationService) AddChannel(c model.NotificationChannel) (int, error) {
+	return s.store.Create(c)
+}
+
+// UpdateChannel keeps the stored password and token when they are omitted,
+// since they are never returned to clients.
+func (s NotificationService) UpdateChannel(c model.NotificationChannel) error {
+	existing, err := s.store.GetByID(c.ID)
+	if err != nil {
+		return err
+	}
+	if len(c.Password) == 0 {
+		c.Password = existing.Password
+	}
+	if len(c.Token) == 0 {
+		c.Token = existing.Token
+	}
+	return s.store.Update(c)
+}
+
+func (s NotificationService) DeleteChannel(id int) error {
+	return s.store.Delete(id)
+}
+
+func (s NotificationService) GetChannel(id int) (model.NotificationChannel, error) {
+	return s.store.GetByID(id)
+}
+
+func (s NotificationService) GetChannels() ([]model.NotificationChannel, error) {
+	return s.store.GetAll()
+}
+
+// TestChannel sends a test notification to the stored channel, even if it
+// is disabled.
+func (s NotificationService) TestChannel(ctx context.Context, id int) error {
+	c, err := s.store.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return notify.New(c).Notify(ctx, testNotification)
+}
+
+// TestConfigChannel sends a test notification to the channel of the kind set
+// up in the config.
+func (s NotificationService) TestConfigChannel(ctx context.Context, kind string) error {
+	for _, c := range s.configChannels {
+		if c.Kind == kind {
+			return notify.New(c).Notify(ctx, testNotification)
+		}
+	}
+	return errors.NewInvalidNotificationChannel("notification channel "+kind+" is not configured", nil)
+}
+
+// Notify sends the notification to every enabled channel, or logs it when
+// there are none. A notification that reached at least one channel counts as
+// delivered: failures of the other channels are only logged, so that a retry
+// doesn't send it again to the channels that got it. The error joins the
+// channel errors when all of them fail.
+func (s NotificationService) Notify(ctx context.Context, n model.Notification) error {
+	stored, err := s.store.GetAll()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	sent := 0
+	for _, c := range append(stored, s.configChannels...) {
+		if !c.Enabled {
+			continue
+		}
+		sent++
+		if err := notify.New(c).Notify(ctx, n); err != nil {
+			s.logger.Error("Error sending notification", zap.String("channel", c.Name), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+
+	if sent == 0 {
+		return s.logNotifier.Notify(ctx, n)
+	}
+	if len(errs) < sent {
+		return nil
+	}
+	return goerrors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) GetRemindersHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get reminders task id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reminders, err := s.ReminderService.GetReminders(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting reminders", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	remindersDto := model.RemindersDto{
+		Reminders: model.RemindersToRemindersDto(reminders),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(remindersDto); err != nil {
+		s.Logger.Error("Error encoding get reminders response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) AddReminderHandler(res http.ResponseWriter, req *http.Request) {
+	reminder := model.Reminder{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&reminder); err != nil {
+		s.Logger.Error("Error decoding add reminder", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.ReminderService.AddReminder(reminder)
+	if err != nil {
+		s.Logger.Error("Error adding reminder", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := model.CreateTaskSuccessDto{
+		ID: id,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding add reminder response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DeleteReminderHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing delete reminder id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.ReminderService.DeleteReminder(idNumber)
+	if err != nil {
+		s.Logger.Error("Error deleting reminder", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding delete reminder response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
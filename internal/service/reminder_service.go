@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/notify"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+const (
+	// reminderLookback limits how late a missed reminder is still delivered,
+	// e.g. after the server was down.
+	reminderLookback     = 24 * time.Hour
+	maxReminderAttempts  = 3
+	maxReminderAheadDays = 8
+)
+
+type ReminderService struct {
+	store    storage.ReminderStore
+	notifier notify.Notifier
+	location *time.Location
+	logger   *zap.Logger
+}
+
+// NewReminderService returns a service that reads reminder times in
+// location.
+func NewReminderService(store storage.ReminderStore, notifier notify.Notifier, location *time.Location,
+	logger *zap.Logger) *ReminderService {
+	return &ReminderService{store: store, notifier: notifier, location: location, logger: logger}
+}
+
+func (s ReminderService) AddReminder(r model.Reminder) (int, error) {
+	return s.store.Create(r)
+}
+
+func (s ReminderService) DeleteReminder(id int) error {
+	return s.store.Delete(id)
+}
+
+func (s ReminderService) GetReminders(taskID int) ([]model.Reminder, error) {
+	return s.store.GetAllByTaskID(taskID)
+}
+
+// DeliverDue sends every reminder that is due at now and was not delivered
+// yet. Each occurrence of a reminder is claimed in the database before it is
+// sent, so it is delivered at most once. It returns the number of sent
+// reminders.
+func (s ReminderService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	now = now.In(s.location)
+	from := now.Add(-reminderLookback).Format("20060102")
+	to := now.AddDate(0, 0, maxReminderAheadDays).Format("20060102")
+	reminders, err := s.store.GetAllOnDates(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, r := range reminders {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		remindAt := r.RemindAt(r.TaskDate, s.location)
+		if remindAt.After(now) || remindAt.Before(now.Add(-reminderLookback)) {
+			continue
+		}
+
+		occurrence := r.TaskDate.Format("20060102")
+		claimed, err := s.store.Claim(r.ID, occurrence, maxReminderAttempts, now)
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		notifyErr := s.notifier.Notify(ctx, model.Notification{
+			Title:   r.TaskTitle,
+			Message: fmt.Sprintf("Reminder: %s on %s", r.TaskTitle, r.TaskDate.Format("02.01.2006")),
+			TaskID:  r.TaskID,
+		})
+		if notifyErr != nil {
+			s.logger.Error("Error delivering reminder", zap.Int("reminder_id", r.ID), zap.Error(notifyErr))
+		} else {
+			sent++
+		}
+
+		err = s.store.Finish(r.ID, occurrence, notifyErr, time.Now())
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) GetRulesHandler(res http.ResponseWriter, req *http.Request) {
+	rules, err := s.RuleService.GetRules()
+	if err != nil {
+		s.Logger.Error("Error getting rules", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rulesDto := model.RulesDto{
+		Rules: model.RulesToRulesDto(rules),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(rulesDto); err != nil {
+		s.Logger.Error("Error encoding get rules response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetRuleHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get rule id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := s.RuleService.GetRule(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting rule", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	ruleDto := model.RuleToRuleDto(rule)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(ruleDto); err != nil {
+		s.Logger.Error("Error encoding get rule response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) AddRuleHandler(res http.ResponseWriter, req *http.Request) {
+	rule := model.Rule{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&rule); err != nil {
+		s.Logger.Error("Error decoding add rule", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.RuleService.AddRule(rule)
+	if err != nil {
+		s.Logger.Error("Error adding rule", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := model.CreateTaskSuccessDto{
+		ID: id,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding add rule response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) UpdateRuleHandler(res http.ResponseWriter, req *http.Request) {
+	rule := model.Rule{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&rule); err != nil {
+		s.Logger.Error("Error decoding update rule", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := s.RuleService.UpdateRule(rule)
+	if err != nil {
+		s.Logger.Error("Error updating rule", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding update rule response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DeleteRuleHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing delete rule id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.RuleService.DeleteRule(idNumber)
+	if err != nil {
+		s.Logger.Error("Error deleting rule", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding delete rule response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetRuleExecutionsHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get rule executions id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	executions, err := s.RuleService.GetExecutions(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting rule executions", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	executionsDto := model.RuleExecutionsDto{
+		Executions: model.RuleExecutionsToRuleExecutionsDto(executions),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(executionsDto); err != nil {
+		s.Logger.Error("Error encoding get rule executions response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DryRunRuleHandler(res http.ResponseWriter, req *http.Request) {
+	taskID := req.FormValue("task_id")
+
+	taskIDNumber, err := strconv.Atoi(taskID)
+	if err != nil {
+		s.Logger.Error("Error parsing dry run rule task id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule := model.Rule{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&rule); err != nil {
+		s.Logger.Error("Error decoding dry run rule", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dryRunDto, err := s.RuleService.DryRun(rule, taskIDNumber)
+	if err != nil {
+		s.Logger.Error("Error running rule dry run", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(dryRunDto); err != nil {
+		s.Logger.Error("Error encoding dry run rule response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
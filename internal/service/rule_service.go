@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+const (
+	// RuleEventSource marks changes made by rule actions. Events with this
+	// source do not trigger rules, so rules cannot loop on each other.
+	RuleEventSource = "rules"
+
+	maxRuleExecutionsLog = 100
+)
+
+// RuleService runs automation rules on task events. Rule actions go through
+// TaskService, so they are validated and published like any other change.
+type RuleService struct {
+	store       storage.RuleStore
+	taskStore   storage.TaskStore
+	taskService *TaskService
+	logger      *zap.Logger
+}
+
+func NewRuleService(store storage.RuleStore, taskStore storage.TaskStore, taskService *TaskService,
+	logger *zap.Logger) *RuleService {
+	return &RuleService{
+		store:       store,
+		taskStore:   taskStore,
+		taskService: taskService.WithSource(RuleEventSource),
+		logger:      logger,
+	}
+}
+
+func (s RuleService) AddRule(r model.Rule) (int, error) {
+	return s.store.Create(r)
+}
+
+func (s RuleService) UpdateRule(r model.Rule) error {
+	return s.store.Update(r)
+}
+
+func (s RuleService) DeleteRule(id int) error {
+	return s.store.Delete(id)
+}
+
+func (s RuleService) GetRule(id int) (model.Rule, error) {
+	return s.store.GetByID(id)
+}
+
+func (s RuleService) GetRules() ([]model.Rule, error) {
+	return s.store.GetAll()
+}
+
+func (s RuleService) GetExecutions(id int) ([]model.RuleExecution, error) {
+	_, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetExecutions(id, maxRuleExecutionsLog)
+}
+
+// DryRun evaluates the rule against the stored task without running its
+// actions.
+func (s RuleService) DryRun(r model.Rule, taskID int) (model.RuleDryRunDto, error) {
+	t, err := s.taskService.GetTask(taskID)
+	if err != nil {
+		return model.RuleDryRunDto{}, err
+	}
+
+	facts, err := s.facts(t)
+	if err != nil {
+		return model.RuleDryRunDto{}, err
+	}
+
+	res := model.RuleDryRunDto{
+		Matched:    true,
+		Conditions: make([]model.RuleConditionResultDto, len(r.Conditions)),
+		Actions:    []string{},
+	}
+	for idx, c := range r.Conditions {
+		actual, matched := c.Evaluate(facts)
+		res.Conditions[idx] = model.RuleConditionResultDto{
+			Field:   c.Field,
+			Op:      c.Op,
+			Value:   c.Value,
+			Actual:  actual,
+			Matched: matched,
+		}
+		res.Matched = res.Matched && matched
+	}
+
+	if res.Matched {
+		for _, a := range r.Actions {
+			res.Actions = append(res.Actions, a.Describe())
+		}
+	}
+	return res, nil
+}
+
+// HandleEvent runs the enabled rules triggered by the event and logs every
+// run. It is subscribed to the event bus.
+func (s RuleService) HandleEvent(ctx context.Context, e events.Event) {
+	base := e.Base()
+	if base.Source == RuleEventSource || base.Source == BackupEventSource {
+		return
+	}
+
+	rules, err := s.store.GetAll()
+	if err != nil {
+		s.logger.Error("Error getting rules", zap.Error(err))
+		return
+	}
+
+	// Conditions see the task as it was when the event occurred, not as it
+	// is now: events may be dispatched some time after the change.
+	facts := model.RuleFacts{Task: base.Task, Postponed: base.Postponed}
+	for _, r := range rules {
+		if !r.Enabled || r.Trigger != e.Name() {
+			continue
+		}
+
+		if !r.Matches(facts) {
+			continue
+		}
+
+		done, err := s.store.HasExecution(r.ID, base.ID)
+		if err != nil {
+			s.logger.Error("Error getting rule executions", zap.Int("rule_id", r.ID), zap.Error(err))
+			continue
+		}
+		if done {
+			continue
+		}
+
+		execution := model.RuleExecution{
+			RuleID:    r.ID,
+			EventID:   base.ID,
+			Event:     e.Name(),
+			TaskID:    base.Task.ID,
+			TaskTitle: base.Task.Title,
+			Status:    model.RuleExecutionSuccess,
+		}
+
+		// The actions and the execution are stored together, so an event
+		// delivered again does not run the rule twice. A failed run is
+		// rolled back and logged on its own.
+		var actionErr error
+		err = s.taskService.InTx(func(tasks *TaskService, tx *sql.Tx) error {
+			for _, a := range r.Actions {
+				actionErr = s.runAction(tasks, a, base.Task)
+				if actionErr != nil {
+					s.logger.Error("Error running rule action", zap.Int("rule_id", r.ID),
+						zap.String("action", a.Type), zap.Error(actionErr))
+					return actionErr
+				}
+				execution.Actions = append(execution.Actions, a.Describe())
+			}
+
+			execution.CreatedAt = time.Now()
+			_, err := s.store.WithTx(tx).AddExecution(execution)
+			return err
+		})
+		if err == nil {
+			continue
+		}
+		if actionErr == nil {
+			s.logger.Error("Error logging rule execution", zap.Int("rule_id", r.ID), zap.Error(err))
+			continue
+		}
+
+		execution.Status, execution.Error = model.RuleExecutionFailed, actionErr.Error()
+		execution.CreatedAt = time.Now()
+		_, err = s.store.AddExecution(execution)
+		if err != nil {
+			s.logger.Error("Error logging rule execution", zap.Int("rule_id", r.ID), zap.Error(err))
+		}
+	}
+}
+
+func (s RuleService) facts(t model.Task) (model.RuleFacts, error) {
+	postponed, err := s.taskStore.CountEvents(t.ID, model.TaskEventPostponed)
+	if err != nil {
+		return model.RuleFacts{}, err
+	}
+	return model.RuleFacts{Task: t, Postponed: postponed}, nil
+}
+
+// runAction applies the action to the task of the event through tasks.
+// Actions changing the task read it again, so they see the changes of the
+// previous ones.
+func (s RuleService) runAction(tasks *TaskService, a model.RuleAction, trigger model.Task) error {
+	switch a.Type {
+	case model.RuleActionCreateTask:
+		today := tasks.Today(time.Now())
+		date := today
+		if len(a.Date) > 0 {
+			var err error
+			date, err = utils.PostponeDate(today, today, a.Date)
+			if err != nil {
+				return err
+			}
+		}
+
+		t := model.Task{
+			Date:    date,
+			Title:   strings.ReplaceAll(a.Title, "{{title}}", trigger.Title),
+			Comment: strings.ReplaceAll(a.Comment, "{{title}}", trigger.Title),
+			Tags:    a.Tags,
+		}
+		if len(a.Project) > 0 {
+			t.Project = &a.Project
+		}
+		_, err := tasks.AddTask(t)
+		return err
+	case model.RuleActionAddTag, model.RuleActionSetProject:
+		op := model.BulkOperation{IDs: []int{trigger.ID}, Operation: model.BulkProject, Project: a.Project, Atomic: true}
+		if a.Type == model.RuleActionAddTag {
+			op.Operation, op.Tags = model.BulkTag, []string{a.Tag}
+		}
+		results, err := tasks.BulkUpdate(op)
+		if err != nil {
+			return err
+		}
+		return results.Results[0].Err
+	case model.RuleActionSetPriority, model.RuleActionRaisePriority:
+		t, err := tasks.GetTask(trigger.ID)
+		if err != nil {
+			return err
+		}
+
+		priority := a.Priority
+		if a.Type == model.RuleActionRaisePriority {
+			priority = min(*t.Priority+a.By, model.MaxPriority)
+		}
+		t.Priority = &priority
+		return tasks.UpdateTask(t)
+	case model.RuleActionPostpone:
+		_, err := tasks.PostponeTask(trigger.ID, a.To)
+		return err
+	case model.RuleActionComplete:
+		return tasks.CompleteTask(trigger.ID)
+	}
+	return nil
+}
@@ -7,10 +7,26 @@ import (
 )
 
 type Server struct {
-	AuthService *AuthService
-	TaskService *TaskService
-	Config      *config.ServerConfig
-	Logger      *zap.Logger
+	AuthService         *AuthService
+	TaskService         *TaskService
+	TimeService         *TimeService
+	CustomFieldService  *CustomFieldService
+	TemplateService     *TemplateService
+	ReminderService     *ReminderService
+	NotificationService *NotificationService
+	DigestService       *DigestService
+	WebhookService      *WebhookService
+	RuleService         *RuleService
+	StreamService       *StreamService
+	CalendarFeedService *CalendarFeedService
+	ImportService       *ImportService
+	ExportService       *ExportService
+	CalDAVService       *CalDAVService
+	BackupService       *BackupService
+	AgendaService       *AgendaService
+	StatsService        *StatsService
+	Config              *config.ServerConfig
+	Logger              *zap.Logger
 }
 
 func NewServer(authService *AuthService, taskService *TaskService, config *config.ServerConfig,
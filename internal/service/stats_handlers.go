@@ -0,0 +1,32 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// GetStatsHandler returns the stats of the from and to dates, see
+// StatsService.GetStats.
+func (s *Server) GetStatsHandler(res http.ResponseWriter, req *http.Request) {
+	stats, err := s.StatsService.GetStats(req.FormValue("from"), req.FormValue("to"), time.Now())
+	if err != nil {
+		s.Logger.Error("Error getting stats", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	statsDto := model.StatsToStatsDto(stats)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(statsDto); err != nil {
+		s.Logger.Error("Error encoding get stats response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
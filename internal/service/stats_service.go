@@ -0,0 +1,139 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+	maxPostponedStat = 10
+)
+
+// StatsService sums up the completion history. Stats take two indexed
+// queries over the history of the range, so they can be loaded with every
+// dashboard. Days are those of location, and weeks start on weekStart.
+type StatsService struct {
+	store     storage.TaskStore
+	location  *time.Location
+	weekStart time.Weekday
+	logger    *zap.Logger
+}
+
+func NewStatsService(store storage.TaskStore, location *time.Location, weekStart time.Weekday,
+	logger *zap.Logger) *StatsService {
+	return &StatsService{store: store, location: location, weekStart: weekStart, logger: logger}
+}
+
+// GetStats returns the stats of the inclusive date range, the last 30 days
+// by default.
+func (s StatsService) GetStats(from string, to string, now time.Time) (model.Stats, error) {
+	now = now.In(s.location)
+	fromDate, toDate, err := parseReportRange(from, to, now, defaultStatsDays)
+	if err != nil {
+		return model.Stats{}, err
+	}
+	if toDate.Sub(fromDate) >= maxStatsDays*24*time.Hour {
+		return model.Stats{}, errors.NewInvalidStatsRange(fmt.Sprintf("stats range can't be longer than %d days", maxStatsDays), nil)
+	}
+
+	stats := model.Stats{From: fromDate, To: toDate}
+	rangeEnd := toDate.AddDate(0, 0, 1)
+	completions, err := s.store.GetCompletions(fromDate, rangeEnd)
+	if err != nil {
+		return stats, err
+	}
+	stats.Postponed, err = s.store.GetPostponedInRange(fromDate, rangeEnd, maxPostponedStat)
+	if err != nil {
+		return stats, err
+	}
+	if stats.Postponed == nil {
+		stats.Postponed = []model.PostponedStat{}
+	}
+
+	days := make(map[string]int)
+	weeks := make(map[string]int)
+	for _, c := range completions {
+		stats.Completed++
+		if c.OnTime(s.location) {
+			stats.OnTime++
+		} else {
+			stats.Late++
+		}
+
+		done := c.CreatedAt.In(s.location)
+		days[done.Format("20060102")]++
+		weeks[s.weekOf(done).Format("20060102")]++
+	}
+
+	for day := fromDate; day.Before(rangeEnd); day = day.AddDate(0, 0, 1) {
+		stats.Days = append(stats.Days, model.StatsPeriod{Start: day, Completed: days[day.Format("20060102")]})
+	}
+	for week := s.weekOf(fromDate); week.Before(rangeEnd); week = week.AddDate(0, 0, 7) {
+		stats.Weeks = append(stats.Weeks, model.StatsPeriod{Start: week, Completed: weeks[week.Format("20060102")]})
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	stats.Streaks = streaks(completions, s.location, today, !toDate.Before(today))
+	return stats, nil
+}
+
+// weekOf returns the first day of the week of the date.
+func (s StatsService) weekOf(date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(s.weekStart) + 7) % 7))
+}
+
+// streaks returns the streaks of recurring tasks that still exist. When the
+// range reaches today, an overdue task has no current streak.
+func streaks(completions []model.Completion, location *time.Location, today time.Time,
+	untilToday bool) []model.Streak {
+	byTask := make(map[int]*model.Streak)
+	overdue := make(map[int]bool)
+	var order []int
+	for _, c := range completions {
+		if c.TaskID == 0 || len(c.Repeat) == 0 {
+			continue
+		}
+		streak, ok := byTask[c.TaskID]
+		if !ok {
+			streak = &model.Streak{TaskID: c.TaskID, Repeat: c.Repeat}
+			byTask[c.TaskID] = streak
+			order = append(order, c.TaskID)
+			// Task dates are days without a time zone.
+			overdue[c.TaskID] = c.TaskDate.Format("20060102") < today.Format("20060102")
+		}
+		streak.Title = c.TaskTitle
+
+		if c.OnTime(location) {
+			streak.Current++
+			streak.Longest = max(streak.Longest, streak.Current)
+		} else {
+			streak.Current = 0
+		}
+	}
+
+	res := make([]model.Streak, 0, len(order))
+	for _, id := range order {
+		streak := *byTask[id]
+		if untilToday && overdue[id] {
+			streak.Current = 0
+		}
+		res = append(res, streak)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Current != res[j].Current {
+			return res[i].Current > res[j].Current
+		}
+		return res[i].Longest > res[j].Longest
+	})
+	return res
+}
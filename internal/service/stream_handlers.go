@@ -0,0 +1,119 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+)
+
+// StreamTasksHandler sends task events as Server-Sent Events. Each event
+// carries its outbox ID, so a reconnecting client resumes after the last
+// one it got through the Last-Event-ID header or the last_event_id
+// parameter. When more events were missed than can be replayed, a reset
+// event tells the client to reload the task list.
+func (s *Server) StreamTasksHandler(res http.ResponseWriter, req *http.Request) {
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		sendTaskError(res, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	lastEventID := req.Header.Get("Last-Event-ID")
+	if len(lastEventID) == 0 {
+		lastEventID = req.FormValue("last_event_id")
+	}
+
+	var lastID int64
+	if len(strings.TrimSpace(lastEventID)) != 0 {
+		var err error
+		lastID, err = strconv.ParseInt(strings.TrimSpace(lastEventID), 10, 64)
+		if err != nil || lastID < 0 {
+			s.Logger.Error("Error parsing stream last event id", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, "invalid last event id: "+lastEventID)
+			return
+		}
+	}
+
+	// Subscribe before replaying, so no event falls between the two.
+	live, unsubscribe := s.StreamService.Subscribe()
+	defer unsubscribe()
+
+	var missed []events.Event
+	complete := true
+	if lastID > 0 {
+		var err error
+		missed, complete, err = s.StreamService.Replay(lastID)
+		if err != nil {
+			s.Logger.Error("Error replaying task events", zap.Error(err))
+			sendTaskServiceError(res, err)
+			return
+		}
+	}
+
+	res.Header().Set("Content-Type", "text/event-stream; charset=UTF-8")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprint(res, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	if !complete {
+		if _, err := fmt.Fprint(res, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+		missed = nil
+	}
+	for _, e := range missed {
+		if err := s.writeStreamEvent(res, e); err != nil {
+			return
+		}
+		lastID = e.Base().ID
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(s.StreamService.Heartbeat())
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-live:
+			if !ok {
+				return
+			}
+			if e.Base().ID <= lastID {
+				continue
+			}
+			if err := s.writeStreamEvent(res, e); err != nil {
+				return
+			}
+			lastID = e.Base().ID
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *Server) writeStreamEvent(res http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(taskEventPayload(e))
+	if err != nil {
+		s.Logger.Error("Error encoding stream event", zap.Error(err))
+		return err
+	}
+
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.Base().ID, e.Name(), data)
+	return err
+}
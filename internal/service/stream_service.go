@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const (
+	// maxStreamReplay bounds the number of events replayed to a client
+	// resuming with Last-Event-ID; a client further behind should reload.
+	maxStreamReplay  = 1000
+	streamBufferSize = 64
+)
+
+// StreamService fans task events out to connected stream clients. A client
+// that doesn't keep up is disconnected and resumes with Last-Event-ID.
+type StreamService struct {
+	outbox    *events.Outbox
+	heartbeat time.Duration
+
+	mu      sync.Mutex
+	clients map[chan events.Event]struct{}
+	closed  bool
+}
+
+func NewStreamService(outbox *events.Outbox, heartbeat time.Duration) *StreamService {
+	return &StreamService{
+		outbox:    outbox,
+		heartbeat: heartbeat,
+		clients:   make(map[chan events.Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel of live events, which is closed when the
+// client falls behind or the service is closed, and a function releasing it.
+func (s *StreamService) Subscribe() (<-chan events.Event, func()) {
+	ch := make(chan events.Event, streamBufferSize)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	s.clients[ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.clients[ch]; ok {
+			delete(s.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// Replay returns events missed by a client that has seen events up to
+// lastID. The second result is false when more events were missed than can
+// be replayed.
+func (s *StreamService) Replay(lastID int64) ([]events.Event, bool, error) {
+	missed, err := s.outbox.Replay(lastID, maxStreamReplay+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(missed) > maxStreamReplay {
+		return missed[:maxStreamReplay], false, nil
+	}
+	return missed, true, nil
+}
+
+func (s *StreamService) Heartbeat() time.Duration {
+	return s.heartbeat
+}
+
+// HandleEvent passes the event to connected clients. It is subscribed to
+// the event bus.
+func (s *StreamService) HandleEvent(ctx context.Context, e events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.clients {
+		select {
+		case ch <- e:
+		default:
+			delete(s.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close disconnects all clients, so that the server can shut down.
+func (s *StreamService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for ch := range s.clients {
+		delete(s.clients, ch)
+		close(ch)
+	}
+}
+
+// taskEventPayload is the representation of an event sent to webhooks and
+// stream clients.
+func taskEventPayload(e events.Event) model.TaskEventPayloadDto {
+	base := e.Base()
+	dto := model.TaskEventPayloadDto{
+		Event:     e.Name(),
+		CreatedAt: base.OccurredAt.UTC().Format(time.RFC3339),
+		Task:      model.TaskToTaskDto(base.Task),
+	}
+	if rescheduled, ok := e.(events.TaskRescheduled); ok {
+		dto.From = rescheduled.From.Format("20060102")
+	}
+	return dto
+}
@@ -1,24 +1,59 @@
 package service
 
 import (
+	"database/sql"
+	goerrors "errors"
+	"sort"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
 	"github.com/Stern-Ritter/go_task_manager/internal/model"
 	"github.com/Stern-Ritter/go_task_manager/internal/storage"
 	"github.com/Stern-Ritter/go_task_manager/internal/utils"
 )
 
+const (
+	maxPostponedTasks = 100
+	// maxCalendarViewOccurrences caps the occurrences of a calendar view,
+	// since a daily task alone has one for every day.
+	maxCalendarViewOccurrences = 1000
+)
+
+// TaskService records a lifecycle event in the outbox with every change,
+// in the same transaction; subscribers of the event bus receive it after
+// the commit.
 type TaskService struct {
-	store  storage.TaskStore
-	logger *zap.Logger
+	store      storage.TaskStore
+	fieldStore storage.CustomFieldStore
+	outbox     *events.Outbox
+	source     string
+	tx         *sql.Tx
+	location   *time.Location
+	logger     *zap.Logger
+}
+
+// NewTaskService returns a service whose days, e.g. which tasks are
+// overdue, are those of location.
+func NewTaskService(store storage.TaskStore, fieldStore storage.CustomFieldStore, outbox *events.Outbox,
+	location *time.Location, logger *zap.Logger) *TaskService {
+	return &TaskService{store: store, fieldStore: fieldStore, outbox: outbox, location: location, logger: logger}
+}
+
+// WithSource returns a service whose changes are published with the given
+// event source, so subscribers can tell them from changes made by users.
+func (s *TaskService) WithSource(source string) *TaskService {
+	c := *s
+	c.source = source
+	return &c
 }
 
-func NewTaskService(store storage.TaskStore, logger *zap.Logger) *TaskService {
-	return &TaskService{store: store, logger: logger}
+// Today returns the current day in the location of the service.
+func (s TaskService) Today(now time.Time) time.Time {
+	return utils.Today(now, s.location)
 }
 
 func (s TaskService) GetNextDate(now string, date string, repeat string) (string, error) {
@@ -30,32 +65,188 @@ func (s TaskService) GetNextDate(now string, date string, repeat string) (string
 }
 
 func (s TaskService) AddTask(t model.Task) (int, error) {
-	return s.store.Create(t)
+	return s.AddTaskWith(t, nil)
+}
+
+// AddTaskWith adds the task like AddTask and, if fn isn't nil, calls it with
+// the transaction and the task ID before committing, so that records bound to
+// the task are stored together with it or not at all.
+func (s TaskService) AddTaskWith(t model.Task, fn func(tx *sql.Tx, id int) error) (int, error) {
+	fields, err := s.normalizeFields(t.Fields)
+	if err != nil {
+		return 0, err
+	}
+	t.Fields = fields
+
+	tx, err := s.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	store := s.store.WithTx(tx.Tx)
+	t.ID, err = store.Create(t)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.record(store, t, func(base events.TaskEvent) events.Event {
+		return events.TaskCreated{TaskEvent: base}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if fn != nil {
+		err = fn(tx.Tx, t.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	s.outbox.Wake()
+	return t.ID, nil
+}
+
+// AddTasks adds all tasks in one transaction and returns their IDs.
+func (s TaskService) AddTasks(tasks []model.Task) ([]int, error) {
+	ids := make([]int, len(tasks))
+	err := s.inTx(func(_ *sql.Tx, store storage.TaskStore) error {
+		for idx, t := range tasks {
+			fields, err := s.normalizeFields(t.Fields)
+			if err != nil {
+				return err
+			}
+			t.Fields = fields
+
+			t.ID, err = store.Create(t)
+			if err != nil {
+				return err
+			}
+			ids[idx] = t.ID
+
+			err = s.record(store, t, func(base events.TaskEvent) events.Event {
+				return events.TaskCreated{TaskEvent: base}
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return ids, err
 }
 
 func (s TaskService) UpdateTask(t model.Task) error {
-	return s.store.Update(t)
+	return s.UpdateTaskWith(t, nil)
 }
 
-func (s TaskService) CompleteTask(id int) error {
-	t, err := s.store.GetByID(id)
+// UpdateTaskWith updates the task like UpdateTask and, if check isn't nil,
+// calls it with the transaction and the stored task before writing, so that
+// a change made against an outdated copy of the task can be refused.
+func (s TaskService) UpdateTaskWith(t model.Task, check func(tx *sql.Tx, current model.Task) error) error {
+	fields, err := s.normalizeFields(t.Fields)
 	if err != nil {
 		return err
 	}
+	t.Fields = fields
 
-	if len(strings.TrimSpace(t.Repeat)) != 0 {
-		return s.store.Complete(t)
-	} else {
-		return s.store.Delete(t.ID)
-	}
+	return s.inTx(func(tx *sql.Tx, store storage.TaskStore) error {
+		old, err := store.GetByID(t.ID)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(tx, old); err != nil {
+				return err
+			}
+		}
+
+		err = store.Update(t)
+		if err != nil {
+			return err
+		}
+
+		return s.record(store, t, func(base events.TaskEvent) events.Event {
+			if !old.Date.Equal(t.Date) {
+				return events.TaskRescheduled{TaskEvent: base, From: old.Date}
+			}
+			return events.TaskUpdated{TaskEvent: base}
+		})
+	})
+}
+
+func (s TaskService) CompleteTask(id int) error {
+	return s.CompleteTaskWith(id, nil)
+}
+
+// CompleteTaskWith completes the task like CompleteTask, calling check as
+// UpdateTaskWith does.
+func (s TaskService) CompleteTaskWith(id int, check func(tx *sql.Tx, current model.Task) error) error {
+	return s.inTx(func(tx *sql.Tx, store storage.TaskStore) error {
+		t, err := store.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(tx, t); err != nil {
+				return err
+			}
+		}
+
+		err = completeTask(store, t, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return s.record(store, t, func(base events.TaskEvent) events.Event {
+			return events.TaskCompleted{TaskEvent: base}
+		})
+	})
 }
 
 func (s TaskService) DeleteTask(id int) error {
-	return s.store.Delete(id)
+	return s.DeleteTaskWith(id, nil)
+}
+
+// DeleteTaskWith deletes the task like DeleteTask, calling check as
+// UpdateTaskWith does.
+func (s TaskService) DeleteTaskWith(id int, check func(tx *sql.Tx, current model.Task) error) error {
+	return s.inTx(func(tx *sql.Tx, store storage.TaskStore) error {
+		t, err := store.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(tx, t); err != nil {
+				return err
+			}
+		}
+
+		err = store.Delete(id)
+		if err != nil {
+			return err
+		}
+
+		return s.record(store, t, func(base events.TaskEvent) events.Event {
+			return events.TaskDeleted{TaskEvent: base}
+		})
+	})
 }
 
-func (s TaskService) GetTasks(search string) ([]model.Task, error) {
+// GetTasks returns tasks matching the search string and having all the given
+// custom field values.
+func (s TaskService) GetTasks(search string, fields map[string]string) ([]model.Task, error) {
 	var tasks []model.Task
+	filters, err := s.normalizeFields(fields)
+	if err != nil {
+		return tasks, err
+	}
+
 	isSearch := len(strings.TrimSpace(search)) > 0
 	isValidSearchDate, err := utils.ValidateSearchDate(search)
 	if err != nil {
@@ -73,9 +264,445 @@ func (s TaskService) GetTasks(search string) ([]model.Task, error) {
 		tasks, storeErr = s.store.GetAll()
 	}
 
-	return tasks, storeErr
+	if storeErr != nil || len(filters) == 0 {
+		return tasks, storeErr
+	}
+	return filterTasksByFields(tasks, filters), nil
 }
 
 func (s TaskService) GetTask(id int) (model.Task, error) {
 	return s.store.GetByID(id)
 }
+
+// BulkUpdate applies the operation to every task in one transaction. Missing
+// tasks are reported per ID and do not stop the others unless the operation
+// is atomic; any other error rolls the whole transaction back.
+func (s TaskService) BulkUpdate(op model.BulkOperation) (model.BulkResults, error) {
+	results := model.BulkResults{Results: make([]model.BulkResult, len(op.IDs))}
+
+	tx, err := s.begin()
+	if err != nil {
+		return results, err
+	}
+	defer tx.Rollback()
+
+	store := s.store.WithTx(tx.Tx)
+	failed := false
+	for idx, id := range op.IDs {
+		t, err := s.applyBulkOperation(store, id, op, time.Now())
+		var notExistsErr errors.TaskNotExists
+		if err != nil && !goerrors.As(err, &notExistsErr) {
+			return results, err
+		}
+		results.Results[idx] = model.BulkResult{ID: id, Err: err}
+		failed = failed || err != nil
+		if err != nil {
+			continue
+		}
+
+		err = s.record(store, t, bulkEvent(op.Operation, t))
+		if err != nil {
+			return results, err
+		}
+	}
+
+	if op.Atomic && failed {
+		return results, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return results, err
+	}
+	results.Committed = true
+	s.outbox.Wake()
+	return results, nil
+}
+
+// bulkEvent returns the constructor of the event for the operation applied
+// to the task t.
+func bulkEvent(operation string, t model.Task) func(base events.TaskEvent) events.Event {
+	return func(base events.TaskEvent) events.Event {
+		switch operation {
+		case model.BulkComplete:
+			return events.TaskCompleted{TaskEvent: base}
+		case model.BulkDelete:
+			return events.TaskDeleted{TaskEvent: base}
+		case model.BulkMove, model.BulkPostpone:
+			return events.TaskRescheduled{TaskEvent: base, From: t.Date}
+		default:
+			return events.TaskUpdated{TaskEvent: base}
+		}
+	}
+}
+
+// applyBulkOperation returns the task as it was before the operation.
+func (s TaskService) applyBulkOperation(store storage.TaskStore, id int, op model.BulkOperation,
+	now time.Time) (model.Task, error) {
+	t, err := store.GetByID(id)
+	if err != nil {
+		return t, err
+	}
+	return t, s.applyBulkOperationToTask(store, t, op, now)
+}
+
+func (s TaskService) applyBulkOperationToTask(store storage.TaskStore, t model.Task, op model.BulkOperation,
+	now time.Time) error {
+	today := s.Today(now)
+	switch op.Operation {
+	case model.BulkComplete:
+		return completeTask(store, t, now)
+	case model.BulkDelete:
+		return store.Delete(t.ID)
+	case model.BulkMove:
+		date := op.Date
+		if date.Before(today) {
+			date = today
+		}
+		return moveTask(store, t, date, now)
+	case model.BulkPostpone:
+		date := t.Date
+		if date.Before(today) {
+			date = today
+		}
+		return moveTask(store, t, date.AddDate(0, 0, op.Days), now)
+	case model.BulkTag:
+		return store.AddTags(t.ID, op.Tags)
+	case model.BulkProject:
+		return store.SetProject(t.ID, op.Project)
+	default:
+		return errors.NewInvalidBulkOperation("unknown bulk operation: "+op.Operation, nil)
+	}
+}
+
+// PostponeTask moves only the task date according to the postpone expression
+// and records the postponement in the task history.
+func (s TaskService) PostponeTask(id int, value string) (model.Task, error) {
+	tx, err := s.begin()
+	if err != nil {
+		return model.Task{}, err
+	}
+	defer tx.Rollback()
+
+	store := s.store.WithTx(tx.Tx)
+	t, err := store.GetByID(id)
+	if err != nil {
+		return t, err
+	}
+
+	now := time.Now()
+	date, err := utils.PostponeDate(s.Today(now), t.Date, value)
+	if err != nil {
+		return t, err
+	}
+
+	err = moveTask(store, t, date, now)
+	if err != nil {
+		return t, err
+	}
+
+	from := t.Date
+	t.Date = date
+	err = s.record(store, t, func(base events.TaskEvent) events.Event {
+		return events.TaskRescheduled{TaskEvent: base, From: from}
+	})
+	if err != nil {
+		return t, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return t, err
+	}
+	s.outbox.Wake()
+	return t, nil
+}
+
+func (s TaskService) GetOverdueTasks(now time.Time) ([]model.Task, error) {
+	return s.store.GetAllBefore(s.Today(now).Format("20060102"))
+}
+
+// RolloverTasks moves unfinished non-repeating overdue tasks to today and
+// records the rollover in the task history. It returns the number of moved
+// tasks.
+func (s TaskService) RolloverTasks(now time.Time) (int, error) {
+	tx, err := s.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	store := s.store.WithTx(tx.Tx)
+	today := s.Today(now)
+	tasks, err := store.GetAllBefore(today.Format("20060102"))
+	if err != nil {
+		return 0, err
+	}
+
+	rolled := 0
+	for _, t := range tasks {
+		if len(strings.TrimSpace(t.Repeat)) != 0 {
+			continue
+		}
+
+		err = store.SetDate(t.ID, today)
+		if err != nil {
+			return 0, err
+		}
+		err = store.AddEvent(model.TaskEvent{
+			TaskID:    t.ID,
+			TaskTitle: t.Title,
+			Event:     model.TaskEventRolled,
+			FromDate:  t.Date,
+			ToDate:    today,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		from := t.Date
+		err = s.record(store, t, func(base events.TaskEvent) events.Event {
+			return events.TaskRescheduled{TaskEvent: base, From: from}
+		})
+		if err != nil {
+			return 0, err
+		}
+		rolled++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	s.outbox.Wake()
+	return rolled, nil
+}
+
+func (s TaskService) GetPostponedTasks(minCount int) ([]model.PostponedTask, error) {
+	return s.store.GetMostPostponed(minCount, maxPostponedTasks)
+}
+
+// moveTask sets the task date; moving a task to a later date counts as a
+// postponement.
+func moveTask(store storage.TaskStore, t model.Task, date time.Time, now time.Time) error {
+	err := store.SetDate(t.ID, date)
+	if err != nil || !date.After(t.Date) {
+		return err
+	}
+
+	return store.AddEvent(model.TaskEvent{
+		TaskID:    t.ID,
+		TaskTitle: t.Title,
+		Event:     model.TaskEventPostponed,
+		FromDate:  t.Date,
+		ToDate:    date,
+		CreatedAt: now,
+	})
+}
+
+// completeTask moves a recurring task to its next date and deletes any other
+// task. The completion is recorded first, since deleting a task clears the
+// task id of its history.
+func completeTask(store storage.TaskStore, t model.Task, now time.Time) error {
+	err := store.AddEvent(model.TaskEvent{
+		TaskID:    t.ID,
+		TaskTitle: t.Title,
+		Event:     model.TaskEventCompleted,
+		FromDate:  t.Date,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(t.Repeat)) != 0 {
+		return store.Complete(t)
+	} else {
+		return store.Delete(t.ID)
+	}
+}
+
+// GetCalendar returns the occurrences of tasks from from to to: the stored
+// date of every task in the range and the projected later dates of
+// recurring tasks, including overdue ones, sorted by date.
+func (s TaskService) GetCalendar(from time.Time, to time.Time) (model.CalendarView, error) {
+	view := model.CalendarView{From: from, To: to}
+
+	tasks, err := s.store.GetAllBefore(to.AddDate(0, 0, 1).Format("20060102"))
+	if err != nil {
+		return view, err
+	}
+
+	days := int(to.Sub(from).Hours()/24) + 1
+	byID := make(map[int]model.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+		for _, date := range taskDates(t, from, to, min(days, maxCalendarViewOccurrences+1)) {
+			view.Occurrences = append(view.Occurrences, model.Occurrence{
+				TaskID:    t.ID,
+				Date:      date,
+				Projected: !date.Equal(t.Date),
+			})
+		}
+	}
+
+	sort.SliceStable(view.Occurrences, func(i, j int) bool {
+		return view.Occurrences[i].Date.Before(view.Occurrences[j].Date)
+	})
+	if len(view.Occurrences) > maxCalendarViewOccurrences {
+		view.Occurrences = view.Occurrences[:maxCalendarViewOccurrences]
+		view.Truncated = true
+	}
+
+	seen := make(map[int]bool)
+	view.Tasks = []model.Task{}
+	for _, o := range view.Occurrences {
+		if !seen[o.TaskID] {
+			seen[o.TaskID] = true
+			view.Tasks = append(view.Tasks, byID[o.TaskID])
+		}
+	}
+	return view, nil
+}
+
+// InTx calls fn with a service whose changes join one transaction, together
+// with fn's own records; the transaction is committed if fn succeeds.
+func (s *TaskService) InTx(fn func(tasks *TaskService, tx *sql.Tx) error) error {
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c := *s
+	c.store, c.tx = s.store.WithTx(tx.Tx), tx.Tx
+	err = fn(&c, tx.Tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	s.outbox.Wake()
+	return nil
+}
+
+// taskTx is a transaction begun by the service. Within InTx it is the
+// caller's transaction, which only InTx commits or rolls back.
+type taskTx struct {
+	*sql.Tx
+	joined bool
+}
+
+func (s TaskService) begin() (taskTx, error) {
+	if s.tx != nil {
+		return taskTx{Tx: s.tx, joined: true}, nil
+	}
+	tx, err := s.store.Begin()
+	return taskTx{Tx: tx}, err
+}
+
+func (tx taskTx) Commit() error {
+	if tx.joined {
+		return nil
+	}
+	return tx.Tx.Commit()
+}
+
+func (tx taskTx) Rollback() error {
+	if tx.joined {
+		return nil
+	}
+	return tx.Tx.Rollback()
+}
+
+// inTx runs fn in a new transaction and wakes the outbox after the commit.
+func (s TaskService) inTx(fn func(tx *sql.Tx, store storage.TaskStore) error) error {
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(tx.Tx, s.store.WithTx(tx.Tx))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	s.outbox.Wake()
+	return nil
+}
+
+// record adds the event built by newEvent to the outbox. The event carries
+// the task as it is stored now or t when the task no longer exists, e.g.
+// after completing a one-off task, and the postponement count at this point.
+func (s TaskService) record(store storage.TaskStore, t model.Task,
+	newEvent func(base events.TaskEvent) events.Event) error {
+	if current, err := store.GetByID(t.ID); err == nil {
+		t = current
+	}
+	postponed, err := store.CountEvents(t.ID, model.TaskEventPostponed)
+	if err != nil {
+		return err
+	}
+	return s.outbox.Record(store, newEvent(events.TaskEvent{OccurredAt: time.Now(), Source: s.source, Task: t,
+		Postponed: postponed}))
+}
+
+func (s TaskService) normalizeFields(fields map[string]string) (map[string]string, error) {
+	if len(fields) == 0 {
+		return fields, nil
+	}
+
+	definitions, err := s.fieldStore.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]model.CustomField, len(definitions))
+	for _, definition := range definitions {
+		byName[definition.Name] = definition
+	}
+
+	res := make(map[string]string, len(fields))
+	for name, value := range fields {
+		definition, ok := byName[name]
+		if !ok {
+			return nil, errors.NewInvalidCustomField("unknown custom field: "+name, nil)
+		}
+		if len(strings.TrimSpace(value)) == 0 {
+			res[name] = ""
+			continue
+		}
+
+		normalized, err := definition.NormalizeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = normalized
+	}
+	return res, nil
+}
+
+func filterTasksByFields(tasks []model.Task, filters map[string]string) []model.Task {
+	res := make([]model.Task, 0, len(tasks))
+	for _, t := range tasks {
+		matches := true
+		for name, value := range filters {
+			if t.Fields[name] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			res = append(res, t)
+		}
+	}
+	return res
+}
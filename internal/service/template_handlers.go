@@ -0,0 +1,184 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) GetTemplatesHandler(res http.ResponseWriter, req *http.Request) {
+	templates, err := s.TemplateService.GetTemplates()
+	if err != nil {
+		s.Logger.Error("Error getting templates", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	templatesDto := model.TemplatesDto{
+		Templates: model.TemplatesToTemplatesDto(templates),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(templatesDto); err != nil {
+		s.Logger.Error("Error encoding get templates response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetTemplateHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get template id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	template, err := s.TemplateService.GetTemplate(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting template", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	templateDto := model.TemplateToTemplateDto(template)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(templateDto); err != nil {
+		s.Logger.Error("Error encoding get template response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) AddTemplateHandler(res http.ResponseWriter, req *http.Request) {
+	template := model.Template{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&template); err != nil {
+		s.Logger.Error("Error decoding add template", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.TemplateService.AddTemplate(template)
+	if err != nil {
+		s.Logger.Error("Error adding template", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := model.CreateTaskSuccessDto{
+		ID: id,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding add template response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) UpdateTemplateHandler(res http.ResponseWriter, req *http.Request) {
+	template := model.Template{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&template); err != nil {
+		s.Logger.Error("Error decoding update template", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := s.TemplateService.UpdateTemplate(template)
+	if err != nil {
+		s.Logger.Error("Error updating template", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding update template response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DeleteTemplateHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing delete template id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.TemplateService.DeleteTemplate(idNumber)
+	if err != nil {
+		s.Logger.Error("Error deleting template", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding delete template response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) InstantiateTemplateHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing instantiate template id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	instance := model.TemplateInstance{}
+	if req.ContentLength > 0 {
+		dec := json.NewDecoder(req.Body)
+		if err := dec.Decode(&instance); err != nil {
+			s.Logger.Error("Error decoding instantiate template", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	taskID, err := s.TemplateService.Instantiate(idNumber, instance)
+	if err != nil {
+		s.Logger.Error("Error instantiating template", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	succesDto := model.CreateTaskSuccessDto{
+		ID: taskID,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding instantiate template response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+	"github.com/Stern-Ritter/go_task_manager/internal/utils"
+)
+
+var placeholderRegexp = regexp.MustCompile(`\{\{\s*([\p{L}\p{N}_]+)\s*\}\}`)
+
+type TemplateService struct {
+	store       storage.TemplateStore
+	taskService *TaskService
+	logger      *zap.Logger
+}
+
+func NewTemplateService(store storage.TemplateStore, taskService *TaskService, logger *zap.Logger) *TemplateService {
+	return &TemplateService{store: store, taskService: taskService, logger: logger}
+}
+
+func (s TemplateService) AddTemplate(t model.Template) (int, error) {
+	return s.store.Create(t)
+}
+
+func (s TemplateService) UpdateTemplate(t model.Template) error {
+	return s.store.Update(t)
+}
+
+func (s TemplateService) DeleteTemplate(id int) error {
+	return s.store.Delete(id)
+}
+
+func (s TemplateService) GetTemplate(id int) (model.Template, error) {
+	return s.store.GetByID(id)
+}
+
+func (s TemplateService) GetTemplates() ([]model.Template, error) {
+	return s.store.GetAll()
+}
+
+// Instantiate creates a task from the template. The task date is the
+// instance date (today by default) moved by the offset, which uses the repeat
+// rule grammar: "d 3" is three days later, "w 1" is the next Monday.
+func (s TemplateService) Instantiate(id int, instance model.TemplateInstance) (int, error) {
+	template, err := s.store.GetByID(id)
+	if err != nil {
+		return 0, err
+	}
+
+	today := s.taskService.Today(time.Now())
+	date, err := templateTaskDate(today, instance.Date, instance.Offset, template.Repeat)
+	if err != nil {
+		return 0, err
+	}
+
+	vars := map[string]string{
+		"date":  date.Format("02.01.2006"),
+		"today": today.Format("02.01.2006"),
+	}
+	for key, value := range instance.Vars {
+		vars[key] = value
+	}
+
+	comment := substitutePlaceholders(template.Comment, vars)
+	if len(template.Checklist) > 0 {
+		lines := make([]string, len(template.Checklist))
+		for idx, item := range template.Checklist {
+			lines[idx] = "- [ ] " + substitutePlaceholders(item, vars)
+		}
+		comment = strings.TrimSpace(strings.Join([]string{comment, strings.Join(lines, "\n")}, "\n\n"))
+	}
+
+	title := substitutePlaceholders(template.Title, vars)
+	if len(strings.TrimSpace(title)) == 0 {
+		return 0, errors.NewInvalidTitleFormat("task title is empty", nil)
+	}
+
+	task := model.Task{
+		Date:    date,
+		Title:   title,
+		Comment: comment,
+		Repeat:  template.Repeat,
+		Tags:    template.Tags,
+	}
+	return s.taskService.AddTask(task)
+}
+
+func templateTaskDate(today time.Time, date string, offset string, repeat string) (time.Time, error) {
+	res := today
+	if len(strings.TrimSpace(date)) != 0 {
+		value, err := time.Parse("20060102", date)
+		if err != nil {
+			return res, errors.NewInvalidDateFormat("invalid template instance date format", err)
+		}
+		res = value
+	}
+
+	if len(strings.TrimSpace(offset)) != 0 {
+		next, err := utils.NextDate(res, res.Format("20060102"), offset)
+		if err != nil {
+			return res, err
+		}
+		res, _ = time.Parse("20060102", next)
+	}
+
+	return alignTaskDate(today, res, repeat)
+}
+
+// alignTaskDate moves a past date the way a task added through the API is
+// moved: to today or, for a recurring task, to its next date.
+func alignTaskDate(today time.Time, date time.Time, repeat string) (time.Time, error) {
+	if !date.Before(today) {
+		return date, nil
+	}
+	if len(strings.TrimSpace(repeat)) == 0 {
+		return today, nil
+	}
+	next, err := utils.NextDate(today, date.Format("20060102"), repeat)
+	if err != nil {
+		return date, err
+	}
+	return time.Parse("20060102", next)
+}
+
+// substitutePlaceholders replaces {{key}} with the value of the variable and
+// leaves unknown placeholders as is.
+func substitutePlaceholders(text string, vars map[string]string) string {
+	return placeholderRegexp.ReplaceAllStringFunc(text, func(placeholder string) string {
+		key := placeholderRegexp.FindStringSubmatch(placeholder)[1]
+		if value, ok := vars[key]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) StartTimerHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing start timer task id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	startDto := model.StartTimerDto{}
+	if req.ContentLength > 0 {
+		dec := json.NewDecoder(req.Body)
+		if err := dec.Decode(&startDto); err != nil {
+			s.Logger.Error("Error decoding start timer", zap.Error(err))
+			sendTaskError(res, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	entry, err := s.TimeService.StartTimer(idNumber, startDto.Note)
+	if err != nil {
+		s.Logger.Error("Error starting timer", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendTimeEntry(res, entry, "start timer")
+}
+
+func (s *Server) StopTimerHandler(res http.ResponseWriter, req *http.Request) {
+	entry, err := s.TimeService.StopTimer()
+	if err != nil {
+		s.Logger.Error("Error stopping timer", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendTimeEntry(res, entry, "stop timer")
+}
+
+func (s *Server) GetRunningTimerHandler(res http.ResponseWriter, req *http.Request) {
+	entry, err := s.TimeService.GetRunningTimer()
+	if err != nil {
+		s.Logger.Error("Error getting running timer", zap.Error(err))
+		var notRunningErr errors.TimerNotRunning
+		if goerrors.As(err, &notRunningErr) {
+			sendTaskError(res, http.StatusNotFound, err.Error())
+			return
+		}
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	s.sendTimeEntry(res, entry, "get running timer")
+}
+
+func (s *Server) GetTimeEntriesHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing time entries task id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := s.TimeService.GetTimeEntries(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting time entries", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	entriesDto := model.TimeEntriesDto{
+		Entries: model.TimeEntriesToTimeEntriesDto(entries, time.Now()),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(entriesDto); err != nil {
+		s.Logger.Error("Error encoding get time entries response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetTimeReportHandler(res http.ResponseWriter, req *http.Request) {
+	from := req.FormValue("from")
+	to := req.FormValue("to")
+
+	report, err := s.TimeService.GetReport(from, to)
+	if err != nil {
+		s.Logger.Error("Error getting time report", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	reportDto := model.TimeReportToTimeReportDto(report)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(reportDto); err != nil {
+		s.Logger.Error("Error encoding get time report response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) sendTimeEntry(res http.ResponseWriter, entry model.TimeEntry, event string) {
+	entryDto := model.TimeEntryToTimeEntryDto(entry, time.Now())
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(entryDto); err != nil {
+		s.Logger.Error("Error encoding "+event+" response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"database/sql"
+	goerrors "errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+const defaultReportDays = 7
+
+type TimeService struct {
+	store     storage.TimeEntryStore
+	taskStore storage.TaskStore
+	logger    *zap.Logger
+}
+
+func NewTimeService(store storage.TimeEntryStore, taskStore storage.TaskStore, logger *zap.Logger) *TimeService {
+	return &TimeService{store: store, taskStore: taskStore, logger: logger}
+}
+
+// StartTimer starts tracking time for the task. The application has a single
+// user, so at most one timer may be running at a time.
+func (s TimeService) StartTimer(taskID int, note string) (model.TimeEntry, error) {
+	t, err := s.taskStore.GetByID(taskID)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+
+	running, err := s.store.GetRunning()
+	if err == nil {
+		return model.TimeEntry{}, errors.NewTimerAlreadyRunning(
+			"timer is already running for task: "+running.TaskTitle, nil)
+	}
+	if !goerrors.Is(err, sql.ErrNoRows) {
+		return model.TimeEntry{}, err
+	}
+
+	entry := model.TimeEntry{
+		TaskID: t.ID,
+		Start:  time.Now().Truncate(time.Second),
+		Note:   strings.TrimSpace(note),
+	}
+	setTimeEntryTask(&entry, t)
+
+	entry.ID, err = s.store.Start(entry)
+	return entry, err
+}
+
+func (s TimeService) StopTimer() (model.TimeEntry, error) {
+	entry, err := s.GetRunningTimer()
+	if err != nil {
+		return entry, err
+	}
+
+	if entry.TaskID != 0 {
+		t, err := s.taskStore.GetByID(entry.TaskID)
+		if err == nil {
+			setTimeEntryTask(&entry, t)
+		}
+	}
+
+	stop := time.Now().Truncate(time.Second)
+	entry.Stop = &stop
+	return entry, s.store.Stop(entry)
+}
+
+func (s TimeService) GetRunningTimer() (model.TimeEntry, error) {
+	entry, err := s.store.GetRunning()
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return entry, errors.NewTimerNotRunning("timer isn`t running", err)
+	}
+	return entry, err
+}
+
+func (s TimeService) GetTimeEntries(taskID int) ([]model.TimeEntry, error) {
+	return s.store.GetAllByTaskID(taskID)
+}
+
+// GetReport aggregates tracked time per task, day, tag and project for the
+// inclusive date range. Entries crossing the range bounds are clipped.
+func (s TimeService) GetReport(from string, to string) (model.TimeReport, error) {
+	report := model.TimeReport{}
+	fromDate, toDate, err := parseReportRange(from, to, time.Now(), defaultReportDays)
+	if err != nil {
+		return report, err
+	}
+	report.From, report.To = fromDate, toDate
+
+	rangeStart := fromDate
+	rangeEnd := toDate.AddDate(0, 0, 1)
+	entries, err := s.store.GetAllInRange(rangeStart, rangeEnd)
+	if err != nil {
+		return report, err
+	}
+
+	now := time.Now()
+	tasks := make(map[string]*model.TimeReportItem)
+	days := make(map[string]*model.TimeReportItem)
+	tags := make(map[string]*model.TimeReportItem)
+	projects := make(map[string]*model.TimeReportItem)
+
+	for _, entry := range entries {
+		start := entry.Start
+		stop := now
+		if entry.Stop != nil {
+			stop = *entry.Stop
+		}
+		if start.Before(rangeStart) {
+			start = rangeStart
+		}
+		if stop.After(rangeEnd) {
+			stop = rangeEnd
+		}
+		if !stop.After(start) {
+			continue
+		}
+		duration := stop.Sub(start)
+		report.Total += duration
+
+		taskKey := entry.TaskTitle
+		if entry.TaskID != 0 {
+			taskKey = strconv.Itoa(entry.TaskID)
+		}
+		addReportDuration(tasks, taskKey, entry.TaskTitle, duration)
+
+		for dayStart := start; dayStart.Before(stop); {
+			y, m, d := dayStart.Date()
+			dayEnd := time.Date(y, m, d+1, 0, 0, 0, 0, dayStart.Location())
+			if dayEnd.After(stop) {
+				dayEnd = stop
+			}
+			addReportDuration(days, dayStart.Format("20060102"), "", dayEnd.Sub(dayStart))
+			dayStart = dayEnd
+		}
+
+		for _, tag := range entry.Tags {
+			addReportDuration(tags, tag, "", duration)
+		}
+		if len(entry.Project) > 0 {
+			addReportDuration(projects, entry.Project, "", duration)
+		}
+	}
+
+	for key, item := range tasks {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		t, err := s.taskStore.GetByID(id)
+		if err == nil && t.Estimate != nil {
+			item.Estimate = *t.Estimate
+		}
+	}
+
+	report.Tasks = sortReportItems(tasks, false)
+	report.Days = sortReportItems(days, true)
+	report.Tags = sortReportItems(tags, false)
+	report.Projects = sortReportItems(projects, false)
+	return report, nil
+}
+
+func setTimeEntryTask(entry *model.TimeEntry, t model.Task) {
+	entry.TaskTitle = t.Title
+	entry.Tags = t.Tags
+	if t.Project != nil {
+		entry.Project = *t.Project
+	}
+}
+
+func parseReportRange(from string, to string, now time.Time, days int) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	toDate := today
+	if len(strings.TrimSpace(to)) != 0 {
+		value, err := time.ParseInLocation("20060102", to, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.NewInvalidDateFormat("invalid report to date format", err)
+		}
+		toDate = value
+	}
+
+	fromDate := toDate.AddDate(0, 0, -(days - 1))
+	if len(strings.TrimSpace(from)) != 0 {
+		value, err := time.ParseInLocation("20060102", from, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.NewInvalidDateFormat("invalid report from date format", err)
+		}
+		fromDate = value
+	}
+
+	if fromDate.After(toDate) {
+		return time.Time{}, time.Time{}, errors.NewInvalidDateFormat("report from date is after to date", nil)
+	}
+	return fromDate, toDate, nil
+}
+
+func addReportDuration(items map[string]*model.TimeReportItem, key string, title string,
+	duration time.Duration) {
+	item, ok := items[key]
+	if !ok {
+		item = &model.TimeReportItem{Key: key, Title: title}
+		items[key] = item
+	}
+	item.Duration += duration
+}
+
+func sortReportItems(items map[string]*model.TimeReportItem, byKey bool) []model.TimeReportItem {
+	res := make([]model.TimeReportItem, 0, len(items))
+	for _, item := range items {
+		res = append(res, *item)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if byKey || res[i].Duration == res[j].Duration {
+			return res[i].Key < res[j].Key
+		}
+		return res[i].Duration > res[j].Duration
+	})
+	return res
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+func (s *Server) GetWebhooksHandler(res http.ResponseWriter, req *http.Request) {
+	webhooks, err := s.WebhookService.GetWebhooks()
+	if err != nil {
+		s.Logger.Error("Error getting webhooks", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	webhooksDto := model.WebhookSubscriptionsDto{
+		Webhooks: model.WebhookSubscriptionsToWebhookSubscriptionsDto(webhooks),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(webhooksDto); err != nil {
+		s.Logger.Error("Error encoding get webhooks response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetWebhookHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get webhook id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	webhook, err := s.WebhookService.GetWebhook(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting webhook", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	webhookDto := model.WebhookSubscriptionToWebhookSubscriptionDto(webhook)
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(webhookDto); err != nil {
+		s.Logger.Error("Error encoding get webhook response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) AddWebhookHandler(res http.ResponseWriter, req *http.Request) {
+	webhook := model.WebhookSubscription{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&webhook); err != nil {
+		s.Logger.Error("Error decoding add webhook", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.WebhookService.AddWebhook(webhook)
+	if err != nil {
+		s.Logger.Error("Error adding webhook", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := model.CreateTaskSuccessDto{
+		ID: id,
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding add webhook response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) UpdateWebhookHandler(res http.ResponseWriter, req *http.Request) {
+	webhook := model.WebhookSubscription{}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&webhook); err != nil {
+		s.Logger.Error("Error decoding update webhook", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := s.WebhookService.UpdateWebhook(webhook)
+	if err != nil {
+		s.Logger.Error("Error updating webhook", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding update webhook response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) DeleteWebhookHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing delete webhook id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.WebhookService.DeleteWebhook(idNumber)
+	if err != nil {
+		s.Logger.Error("Error deleting webhook", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	succesDto := struct{}{}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(succesDto); err != nil {
+		s.Logger.Error("Error encoding delete webhook response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) GetWebhookDeliveriesHandler(res http.ResponseWriter, req *http.Request) {
+	id := req.FormValue("id")
+
+	idNumber, err := strconv.Atoi(id)
+	if err != nil {
+		s.Logger.Error("Error parsing get webhook deliveries id", zap.Error(err))
+		sendTaskError(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveries, err := s.WebhookService.GetDeliveries(idNumber)
+	if err != nil {
+		s.Logger.Error("Error getting webhook deliveries", zap.Error(err))
+		sendTaskServiceError(res, err)
+		return
+	}
+
+	deliveriesDto := model.WebhookDeliveriesDto{
+		Deliveries: model.WebhookDeliveriesToWebhookDeliveriesDto(deliveries),
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	enc := json.NewEncoder(res)
+	if err := enc.Encode(deliveriesDto); err != nil {
+		s.Logger.Error("Error encoding get webhook deliveries response", zap.Error(err))
+		http.Error(res, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
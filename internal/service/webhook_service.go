@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/events"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+	"github.com/Stern-Ritter/go_task_manager/internal/storage"
+)
+
+const (
+	maxWebhookAttempts       = 8
+	maxWebhookDeliveriesLog  = 100
+	webhookDeliveryBatchSize = 50
+	webhookRequestTimeout    = 10 * time.Second
+)
+
+// WebhookService queues task events for every matching subscription and
+// delivers them in the background. A failed delivery is retried with
+// exponential backoff: retryBase, 2*retryBase, 4*retryBase and so on.
+type WebhookService struct {
+	store     storage.WebhookStore
+	client    *http.Client
+	retryBase time.Duration
+	logger    *zap.Logger
+}
+
+func NewWebhookService(store storage.WebhookStore, retryBase time.Duration, logger *zap.Logger) *WebhookService {
+	return &WebhookService{
+		store:     store,
+		client:    &http.Client{Timeout: webhookRequestTimeout},
+		retryBase: retryBase,
+		logger:    logger,
+	}
+}
+
+// AddWebhook generates a secret when none is given.
+func (s WebhookService) AddWebhook(w model.WebhookSubscription) (int, error) {
+	if len(w.Secret) == 0 {
+		secret, err := model.NewWebhookSecret()
+		if err != nil {
+			return 0, err
+		}
+		w.Secret = secret
+	}
+	return s.store.Create(w)
+}
+
+// UpdateWebhook keeps the stored secret when it is omitted.
+func (s WebhookService) UpdateWebhook(w model.WebhookSubscription) error {
+	existing, err := s.store.GetByID(w.ID)
+	if err != nil {
+		return err
+	}
+	if len(w.Secret) == 0 {
+		w.Secret = existing.Secret
+	}
+	return s.store.Update(w)
+}
+
+func (s WebhookService) DeleteWebhook(id int) error {
+	return s.store.Delete(id)
+}
+
+func (s WebhookService) GetWebhook(id int) (model.WebhookSubscription, error) {
+	return s.store.GetByID(id)
+}
+
+func (s WebhookService) GetWebhooks() ([]model.WebhookSubscription, error) {
+	return s.store.GetAll()
+}
+
+func (s WebhookService) GetDeliveries(id int) ([]model.WebhookDelivery, error) {
+	_, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetDeliveries(id, maxWebhookDeliveriesLog)
+}
+
+// HandleEvent queues a delivery of the event for each enabled subscription
+// accepting it. It is subscribed to the event bus; an event the outbox
+// delivers again is queued at most once per subscription.
+func (s WebhookService) HandleEvent(ctx context.Context, e events.Event) {
+	webhooks, err := s.store.GetAll()
+	if err != nil {
+		s.logger.Error("Error getting webhooks", zap.Error(err))
+		return
+	}
+
+	var payload []byte
+	for _, w := range webhooks {
+		if !w.Enabled || !w.Accepts(e.Name()) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(taskEventPayload(e))
+			if err != nil {
+				s.logger.Error("Error encoding webhook payload", zap.Error(err))
+				return
+			}
+		}
+
+		_, err = s.store.AddDelivery(model.WebhookDelivery{
+			SubscriptionID: w.ID,
+			EventID:        e.Base().ID,
+			Event:          e.Name(),
+			Payload:        string(payload),
+			CreatedAt:      time.Now(),
+		})
+		if err != nil {
+			s.logger.Error("Error queueing webhook delivery", zap.Int("webhook_id", w.ID), zap.Error(err))
+		}
+	}
+}
+
+// DeliverPending sends due deliveries and returns the number of successful
+// ones.
+func (s WebhookService) DeliverPending(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := s.store.GetDueDeliveries(now, webhookDeliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, d := range deliveries {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		w, err := s.store.GetByID(d.SubscriptionID)
+		if err != nil {
+			s.logger.Error("Error getting webhook", zap.Int("webhook_id", d.SubscriptionID), zap.Error(err))
+			continue
+		}
+
+		d.ResponseStatus, err = s.post(ctx, w, d)
+		d.Attempts++
+		d.UpdatedAt = time.Now()
+		switch {
+		case err == nil:
+			d.Status, d.Error = model.DeliverySent, ""
+			sent++
+		case d.Attempts >= maxWebhookAttempts:
+			d.Status, d.Error = model.DeliveryFailed, err.Error()
+		default:
+			d.Status, d.Error = model.DeliveryPending, err.Error()
+			d.NextAttemptAt = d.UpdatedAt.Add(s.retryBase << (d.Attempts - 1))
+		}
+
+		err = s.store.UpdateDelivery(d)
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+func (s WebhookService) post(ctx context.Context, w model.WebhookSubscription, d model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Event-ID", strconv.FormatInt(d.EventID, 10))
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(d.ID))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(w.Secret, []byte(d.Payload)))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature header value: the hex
+// encoded HMAC-SHA256 of the payload prefixed with "sha256=".
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
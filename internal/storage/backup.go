@@ -0,0 +1,569 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// BackupStore reads and restores all backed up tables at once, each in a
+// single transaction, so a backup is a consistent snapshot and a failed
+// restore changes nothing.
+type BackupStore struct {
+	db *sql.DB
+}
+
+func NewBackupStore(db *sql.DB) BackupStore {
+	return BackupStore{db: db}
+}
+
+func (s BackupStore) Read() (model.Backup, error) {
+	b := model.Backup{Version: model.BackupVersion}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return b, err
+	}
+	defer tx.Rollback()
+
+	b.Tasks, err = TaskStore{db: s.db, tx: tx}.GetAll()
+	if err != nil {
+		return b, err
+	}
+
+	err = queryBackupRows(tx, `
+		SELECT id, name, type, options
+		FROM custom_fields
+		ORDER BY id
+	`, func(row rowScanner) error {
+		f, err := scanCustomField(row)
+		b.Fields = append(b.Fields, f)
+		return err
+	})
+	if err != nil {
+		return b, err
+	}
+
+	err = queryBackupRows(tx, `
+		SELECT id, name, title, comment, repeat, checklist, tags
+		FROM task_templates
+		ORDER BY id
+	`, func(row rowScanner) error {
+		t, err := scanTemplate(row)
+		b.Templates = append(b.Templates, t)
+		return err
+	})
+	if err != nil {
+		return b, err
+	}
+
+	err = queryBackupRows(tx, `
+		SELECT id, name, trigger, conditions, actions, enabled
+		FROM rules
+		ORDER BY id
+	`, func(row rowScanner) error {
+		r, err := scanRule(row)
+		b.Rules = append(b.Rules, r)
+		return err
+	})
+	if err != nil {
+		return b, err
+	}
+
+	err = queryBackupRows(tx, `
+		SELECT id, task_id, time, before
+		FROM reminders
+		ORDER BY id
+	`, func(row rowScanner) error {
+		r := model.Reminder{}
+		err := row.Scan(&r.ID, &r.TaskID, &r.Time, &r.Before)
+		b.Reminders = append(b.Reminders, r)
+		return err
+	})
+	if err != nil {
+		return b, err
+	}
+
+	err = queryBackupRows(tx, timeEntrySelect+`
+		ORDER BY id
+	`, func(row rowScanner) error {
+		e, err := scanTimeEntry(row)
+		b.TimeEntries = append(b.TimeEntries, e)
+		return err
+	})
+	if err != nil {
+		return b, err
+	}
+
+	err = queryBackupRows(tx, `
+		SELECT id, COALESCE(task_id, 0), task_title, event, from_date, to_date, created_at
+		FROM task_history
+		ORDER BY id
+	`, func(row rowScanner) error {
+		e, err := scanHistoryEvent(row)
+		b.History = append(b.History, e)
+		return err
+	})
+	if err != nil {
+		return b, err
+	}
+
+	err = queryBackupRows(tx, `
+		SELECT task_id, name, uid
+		FROM caldav_resources
+		ORDER BY task_id
+	`, func(row rowScanner) error {
+		r := model.CalDAVResource{}
+		err := row.Scan(&r.TaskID, &r.Name, &r.UID)
+		b.CalDAVResources = append(b.CalDAVResources, r)
+		return err
+	})
+	return b, err
+}
+
+// Restore writes the backup in the mode, model.RestoreModeReplace or
+// model.RestoreModeMerge. A dry run does all the work and rolls it back, so
+// its report is exactly what a real restore would do. If fn isn't nil, it
+// is called before committing with the transaction, the tasks removed by a
+// replace and the IDs of the restored tasks, so that events of the restore
+// are recorded with it.
+func (s BackupStore) Restore(b model.Backup, mode string, dryRun bool, now time.Time,
+	fn func(tx *sql.Tx, removed []model.Task, restored []int) error) (model.RestoreReport, error) {
+	report := model.RestoreReport{Mode: mode, DryRun: dryRun}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	r := backupRestore{
+		tx:      tx,
+		merge:   mode == model.RestoreModeMerge,
+		report:  &report,
+		taskIDs: make(map[int]int, len(b.Tasks)),
+		fields:  make(map[string]model.CustomField, len(b.Fields)),
+		now:     now,
+	}
+	var removed []model.Task
+	if !r.merge {
+		removed, err = TaskStore{db: s.db, tx: tx}.GetAll()
+		if err != nil {
+			return report, err
+		}
+		if err := r.clear(); err != nil {
+			return report, err
+		}
+	}
+
+	for _, step := range []func(b model.Backup) error{
+		r.restoreFields,
+		r.restoreTasks,
+		r.restoreTemplates,
+		r.restoreRules,
+		r.restoreReminders,
+		r.restoreTimeEntries,
+		r.restoreHistory,
+		r.restoreCalDAVResources,
+	} {
+		if err := step(b); err != nil {
+			return report, err
+		}
+	}
+
+	if fn != nil {
+		restored := make([]int, 0, len(b.Tasks))
+		for _, t := range b.Tasks {
+			restored = append(restored, r.taskIDs[t.ID])
+		}
+		if err := fn(tx, removed, restored); err != nil {
+			return report, err
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+type backupRestore struct {
+	tx     *sql.Tx
+	merge  bool
+	report *model.RestoreReport
+	// taskIDs maps task ids of the backup to the restored ones.
+	taskIDs map[int]int
+	fields  map[string]model.CustomField
+	now     time.Time
+}
+
+// clear deletes the backed up tables; triggers on scheduler remove task
+// details, tags, field values, reminders and CalDAV resources.
+func (r backupRestore) clear() error {
+	for _, table := range []string{"scheduler", "task_history", "time_entries", "custom_fields",
+		"task_templates", "rules"} {
+		if _, err := r.tx.Exec(`DELETE FROM ` + table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// id returns the id to insert a row with: the backed up one on replace, NULL
+// on merge so that a new one is assigned.
+func (r backupRestore) id(id int) any {
+	if r.merge {
+		return nil
+	}
+	return id
+}
+
+func (r backupRestore) warn(format string, args ...any) {
+	r.report.Warnings = append(r.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// restoreFields keeps an existing field with the same name on merge: values
+// of the restored tasks are then checked against the existing definition.
+func (r backupRestore) restoreFields(b model.Backup) error {
+	for _, f := range b.Fields {
+		if r.merge {
+			existing, err := scanCustomField(r.tx.QueryRow(`
+				SELECT id, name, type, options
+				FROM custom_fields
+				WHERE name = :name
+			`,
+				sql.Named("name", f.Name)))
+			if err == nil {
+				if existing.Type != f.Type || strings.Join(existing.Options, ",") != strings.Join(f.Options, ",") {
+					r.warn("field %s already exists with another definition, which is kept", f.Name)
+				}
+				r.fields[f.Name] = existing
+				continue
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+
+		_, err := r.tx.Exec(`
+			INSERT INTO custom_fields (id, name, type, options)
+			VALUES (:id, :name, :type, :options)
+		`,
+			sql.Named("id", r.id(f.ID)),
+			sql.Named("name", f.Name),
+			sql.Named("type", f.Type),
+			sql.Named("options", strings.Join(f.Options, ",")))
+		if err != nil {
+			return err
+		}
+		r.fields[f.Name] = f
+		r.report.Fields++
+	}
+	return nil
+}
+
+func (r backupRestore) restoreTasks(b model.Backup) error {
+	for _, t := range b.Tasks {
+		fields := make(map[string]string, len(t.Fields))
+		for name, value := range t.Fields {
+			f, ok := r.fields[name]
+			if !ok {
+				r.warn("value of unknown field %s of task %d is dropped", name, t.ID)
+				continue
+			}
+			normalized, err := f.NormalizeValue(value)
+			if err != nil {
+				r.warn("value of field %s of task %d is dropped: %s", name, t.ID, err)
+				continue
+			}
+			fields[name] = normalized
+		}
+
+		res, err := r.tx.Exec(`
+			INSERT INTO scheduler (id, date, title, comment, repeat)
+			VALUES (:id, :date, :title, :comment, :repeat)
+		`,
+			sql.Named("id", r.id(t.ID)),
+			sql.Named("date", t.Date.Format("20060102")),
+			sql.Named("title", t.Title),
+			sql.Named("comment", t.Comment),
+			sql.Named("repeat", t.Repeat))
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		_, err = r.tx.Exec(`
+			INSERT OR REPLACE INTO task_details (task_id, estimate, project, priority)
+			VALUES (:id, :estimate, :project, :priority)
+		`,
+			sql.Named("id", id),
+			sql.Named("estimate", *t.Estimate),
+			sql.Named("project", *t.Project),
+			sql.Named("priority", *t.Priority))
+		if err != nil {
+			return err
+		}
+		if err := saveTags(r.tx, int(id), t.Tags); err != nil {
+			return err
+		}
+		if err := saveFieldValues(r.tx, int(id), fields); err != nil {
+			return err
+		}
+
+		r.taskIDs[t.ID] = int(id)
+		r.report.Tasks++
+	}
+	return nil
+}
+
+func (r backupRestore) restoreTemplates(b model.Backup) error {
+	for _, t := range b.Templates {
+		if r.merge {
+			var exists bool
+			err := r.tx.QueryRow(`
+				SELECT EXISTS (SELECT 1 FROM task_templates WHERE name = :name)
+			`,
+				sql.Named("name", t.Name)).Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if exists {
+				r.warn("template %s already exists and is not restored", t.Name)
+				continue
+			}
+		}
+
+		_, err := r.tx.Exec(`
+			INSERT INTO task_templates (id, name, title, comment, repeat, checklist, tags)
+			VALUES (:id, :name, :title, :comment, :repeat, :checklist, :tags)
+		`,
+			sql.Named("id", r.id(t.ID)),
+			sql.Named("name", t.Name),
+			sql.Named("title", t.Title),
+			sql.Named("comment", t.Comment),
+			sql.Named("repeat", t.Repeat),
+			sql.Named("checklist", strings.Join(t.Checklist, "\n")),
+			sql.Named("tags", strings.Join(t.Tags, ",")))
+		if err != nil {
+			return err
+		}
+		r.report.Templates++
+	}
+	return nil
+}
+
+func (r backupRestore) restoreRules(b model.Backup) error {
+	for _, rule := range b.Rules {
+		conditions, actions, err := encodeRule(rule)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.tx.Exec(`
+			INSERT INTO rules (id, name, trigger, conditions, actions, enabled)
+			VALUES (:id, :name, :trigger, :conditions, :actions, :enabled)
+		`,
+			sql.Named("id", r.id(rule.ID)),
+			sql.Named("name", rule.Name),
+			sql.Named("trigger", rule.Trigger),
+			sql.Named("conditions", conditions),
+			sql.Named("actions", actions),
+			sql.Named("enabled", rule.Enabled))
+		if err != nil {
+			return err
+		}
+		r.report.Rules++
+	}
+	return nil
+}
+
+// restoreReminders marks reminders that were due before now as delivered:
+// delivery state is not backed up, and they would be sent again otherwise.
+func (r backupRestore) restoreReminders(b model.Backup) error {
+	dates := make(map[int]time.Time, len(b.Tasks))
+	for _, t := range b.Tasks {
+		dates[t.ID] = t.Date
+	}
+
+	for _, reminder := range b.Reminders {
+		taskID, ok := r.taskIDs[reminder.TaskID]
+		if !ok {
+			r.warn("reminder %d of task %d that is not in the backup is not restored", reminder.ID, reminder.TaskID)
+			continue
+		}
+
+		res, err := r.tx.Exec(`
+			INSERT INTO reminders (id, task_id, time, before)
+			VALUES (:id, :task_id, :time, :before)
+		`,
+			sql.Named("id", r.id(reminder.ID)),
+			sql.Named("task_id", taskID),
+			sql.Named("time", reminder.Time),
+			sql.Named("before", reminder.Before))
+		if err != nil {
+			return err
+		}
+		r.report.Reminders++
+
+		date := dates[reminder.TaskID]
+		if reminder.RemindAt(date, r.now.Location()).After(r.now) {
+			continue
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		_, err = r.tx.Exec(`
+			INSERT INTO reminder_deliveries (reminder_id, occurrence, status, updated_at)
+			VALUES (:reminder_id, :occurrence, :status, :now)
+			ON CONFLICT (reminder_id, occurrence) DO NOTHING
+		`,
+			sql.Named("reminder_id", id),
+			sql.Named("occurrence", date.Format("20060102")),
+			sql.Named("status", model.DeliverySent),
+			sql.Named("now", r.now.Unix()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreTimeEntries keeps entries of tasks that are not in the backup, as
+// entries of deleted tasks are kept. A running entry is not restored if
+// another timer is already running.
+func (r backupRestore) restoreTimeEntries(b model.Backup) error {
+	for _, e := range b.TimeEntries {
+		var stop any
+		if e.Stop != nil {
+			stop = e.Stop.Unix()
+		} else {
+			var running bool
+			err := r.tx.QueryRow(`
+				SELECT EXISTS (SELECT 1 FROM time_entries WHERE stop IS NULL)
+			`).Scan(&running)
+			if err != nil {
+				return err
+			}
+			if running {
+				r.warn("running time entry %d is not restored: another timer is running", e.ID)
+				continue
+			}
+		}
+
+		_, err := r.tx.Exec(`
+			INSERT INTO time_entries (id, task_id, task_title, project, tags, start, stop, note)
+			VALUES (:id, :task_id, :task_title, :project, :tags, :start, :stop, :note)
+		`,
+			sql.Named("id", r.id(e.ID)),
+			sql.Named("task_id", r.taskRef(e.TaskID)),
+			sql.Named("task_title", e.TaskTitle),
+			sql.Named("project", e.Project),
+			sql.Named("tags", strings.Join(e.Tags, ",")),
+			sql.Named("start", e.Start.Unix()),
+			sql.Named("stop", stop),
+			sql.Named("note", e.Note))
+		if err != nil {
+			return err
+		}
+		r.report.TimeEntries++
+	}
+	return nil
+}
+
+func (r backupRestore) restoreHistory(b model.Backup) error {
+	for _, e := range b.History {
+		_, err := r.tx.Exec(`
+			INSERT INTO task_history (task_id, task_title, event, from_date, to_date, created_at)
+			VALUES (:task_id, :task_title, :event, :from_date, :to_date, :created_at)
+		`,
+			sql.Named("task_id", r.taskRef(e.TaskID)),
+			sql.Named("task_title", e.TaskTitle),
+			sql.Named("event", e.Event),
+			sql.Named("from_date", formatHistoryDate(e.FromDate)),
+			sql.Named("to_date", formatHistoryDate(e.ToDate)),
+			sql.Named("created_at", e.CreatedAt.Unix()))
+		if err != nil {
+			return err
+		}
+		r.report.History++
+	}
+	return nil
+}
+
+func (r backupRestore) restoreCalDAVResources(b model.Backup) error {
+	for _, resource := range b.CalDAVResources {
+		taskID, ok := r.taskIDs[resource.TaskID]
+		if !ok {
+			continue
+		}
+
+		res, err := r.tx.Exec(`
+			INSERT OR IGNORE INTO caldav_resources (task_id, name, uid)
+			VALUES (:task_id, :name, :uid)
+		`,
+			sql.Named("task_id", taskID),
+			sql.Named("name", resource.Name),
+			sql.Named("uid", resource.UID))
+		if err != nil {
+			return err
+		}
+		if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+			if err != nil {
+				return err
+			}
+			r.warn("CalDAV resource %s already exists and is not restored", resource.Name)
+			continue
+		}
+		r.report.CalDAVResources++
+	}
+	return nil
+}
+
+// taskRef returns the restored id of a task a record refers to, NULL if the
+// task is not in the backup.
+func (r backupRestore) taskRef(id int) any {
+	if restored, ok := r.taskIDs[id]; ok {
+		return restored
+	}
+	return nil
+}
+
+func queryBackupRows(tx *sql.Tx, query string, scan func(row rowScanner) error) error {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanHistoryEvent(row rowScanner) (model.TaskEvent, error) {
+	e := model.TaskEvent{}
+	var from, to string
+	var createdAt int64
+	err := row.Scan(&e.ID, &e.TaskID, &e.TaskTitle, &e.Event, &from, &to, &createdAt)
+	if err != nil {
+		return e, err
+	}
+
+	e.CreatedAt = time.Unix(createdAt, 0)
+	if e.FromDate, err = parseHistoryDate(from); err != nil {
+		return e, err
+	}
+	e.ToDate, err = parseHistoryDate(to)
+	return e, err
+}
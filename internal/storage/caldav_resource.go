@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"database/sql"
+	goerrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// CalDAVResourceStore keeps resource names of tasks created by CalDAV
+// clients. Bindings are removed with their tasks.
+type CalDAVResourceStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewCalDAVResourceStore(db *sql.DB) CalDAVResourceStore {
+	return CalDAVResourceStore{db: db}
+}
+
+// WithTx returns a store that runs all queries in the transaction. The caller
+// is responsible for committing or rolling it back.
+func (s CalDAVResourceStore) WithTx(tx *sql.Tx) CalDAVResourceStore {
+	return CalDAVResourceStore{db: s.db, tx: tx}
+}
+
+func (s CalDAVResourceStore) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s CalDAVResourceStore) Create(r model.CalDAVResource) error {
+	_, err := s.q().Exec(`
+		INSERT INTO caldav_resources (task_id, name, uid)
+		VALUES (:task_id, :name, :uid)
+	`,
+		sql.Named("task_id", r.TaskID),
+		sql.Named("name", r.Name),
+		sql.Named("uid", r.UID))
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return errors.NewCalDAVPreconditionFailed("resource "+r.Name+" already exists", err)
+	}
+	return err
+}
+
+func (s CalDAVResourceStore) GetByName(name string) (model.CalDAVResource, error) {
+	row := s.q().QueryRow(`
+		SELECT task_id, name, uid
+		FROM caldav_resources
+		WHERE name = :name
+	`,
+		sql.Named("name", name))
+
+	r := model.CalDAVResource{}
+	err := row.Scan(&r.TaskID, &r.Name, &r.UID)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return r, errors.NewTaskNotExists(fmt.Sprintf("CalDAV resource %s doesn`t exist", name), err)
+	}
+	return r, err
+}
+
+func (s CalDAVResourceStore) GetByTaskID(taskID int) (model.CalDAVResource, bool, error) {
+	row := s.q().QueryRow(`
+		SELECT task_id, name, uid
+		FROM caldav_resources
+		WHERE task_id = :task_id
+	`,
+		sql.Named("task_id", taskID))
+
+	r := model.CalDAVResource{}
+	err := row.Scan(&r.TaskID, &r.Name, &r.UID)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return r, false, nil
+	}
+	return r, err == nil, err
+}
+
+func (s CalDAVResourceStore) GetAll() ([]model.CalDAVResource, error) {
+	rows, err := s.q().Query(`
+		SELECT task_id, name, uid
+		FROM caldav_resources
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.CalDAVResource
+	for rows.Next() {
+		r := model.CalDAVResource{}
+		if err := rows.Scan(&r.TaskID, &r.Name, &r.UID); err != nil {
+			return res, err
+		}
+		res = append(res, r)
+	}
+
+	err = rows.Err()
+	return res, err
+}
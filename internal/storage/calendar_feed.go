@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"database/sql"
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+type CalendarFeedStore struct {
+	db *sql.DB
+}
+
+func NewCalendarFeedStore(db *sql.DB) CalendarFeedStore {
+	return CalendarFeedStore{db: db}
+}
+
+func (s CalendarFeedStore) Create(f model.CalendarFeed, tokenHash string) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO calendar_feeds (name, token_hash, kind, project, expand, created_at)
+		VALUES (:name, :token_hash, :kind, :project, :expand, :created_at)
+	`,
+		sql.Named("name", f.Name),
+		sql.Named("token_hash", tokenHash),
+		sql.Named("kind", f.Kind),
+		sql.Named("project", f.Project),
+		sql.Named("expand", f.Expand),
+		sql.Named("created_at", f.CreatedAt.Unix()))
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s CalendarFeedStore) Update(f model.CalendarFeed) error {
+	res, err := s.db.Exec(`
+		UPDATE calendar_feeds
+		SET name = :name, kind = :kind, project = :project, expand = :expand
+		WHERE id = :id
+	`,
+		sql.Named("id", f.ID),
+		sql.Named("name", f.Name),
+		sql.Named("kind", f.Kind),
+		sql.Named("project", f.Project),
+		sql.Named("expand", f.Expand))
+
+	if err != nil {
+		return err
+	}
+
+	return feedAffected(res, f.ID)
+}
+
+// SetTokenHash replaces the feed token, revoking the previous one.
+func (s CalendarFeedStore) SetTokenHash(id int, tokenHash string) error {
+	res, err := s.db.Exec(`
+		UPDATE calendar_feeds
+		SET token_hash = :token_hash
+		WHERE id = :id
+	`,
+		sql.Named("id", id),
+		sql.Named("token_hash", tokenHash))
+
+	if err != nil {
+		return err
+	}
+
+	return feedAffected(res, id)
+}
+
+func (s CalendarFeedStore) SetAccessedAt(id int, now time.Time) error {
+	_, err := s.db.Exec(`
+		UPDATE calendar_feeds
+		SET accessed_at = :accessed_at
+		WHERE id = :id
+	`,
+		sql.Named("id", id),
+		sql.Named("accessed_at", now.Unix()))
+	return err
+}
+
+func (s CalendarFeedStore) Delete(id int) error {
+	res, err := s.db.Exec(`
+		DELETE FROM calendar_feeds
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	if err != nil {
+		return err
+	}
+
+	return feedAffected(res, id)
+}
+
+func (s CalendarFeedStore) GetByID(id int) (model.CalendarFeed, error) {
+	row := s.db.QueryRow(`
+		SELECT id, name, kind, project, expand, created_at, accessed_at
+		FROM calendar_feeds
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	f, err := scanCalendarFeed(row)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return f, errors.NewInvalidCalendarFeed(fmt.Sprintf("Calendar feed with id: %d doesn`t exist", id), err)
+	}
+	return f, err
+}
+
+func (s CalendarFeedStore) GetByTokenHash(tokenHash string) (model.CalendarFeed, error) {
+	row := s.db.QueryRow(`
+		SELECT id, name, kind, project, expand, created_at, accessed_at
+		FROM calendar_feeds
+		WHERE token_hash = :token_hash
+	`,
+		sql.Named("token_hash", tokenHash))
+
+	f, err := scanCalendarFeed(row)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return f, errors.NewInvalidCalendarFeed("Calendar feed doesn`t exist", err)
+	}
+	return f, err
+}
+
+func (s CalendarFeedStore) GetAll() ([]model.CalendarFeed, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, kind, project, expand, created_at, accessed_at
+		FROM calendar_feeds
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.CalendarFeed
+	for rows.Next() {
+		f, err := scanCalendarFeed(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, f)
+	}
+
+	err = rows.Err()
+	return res, err
+}
+
+func feedAffected(res sql.Result, id int) error {
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidCalendarFeed(fmt.Sprintf("Calendar feed with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func scanCalendarFeed(row rowScanner) (model.CalendarFeed, error) {
+	f := model.CalendarFeed{}
+	var project sql.NullString
+	var createdAt, accessedAt int64
+	err := row.Scan(&f.ID, &f.Name, &f.Kind, &project, &f.Expand, &createdAt, &accessedAt)
+	if err != nil {
+		return f, err
+	}
+
+	if project.Valid {
+		f.Project = &project.String
+	}
+	f.CreatedAt = time.Unix(createdAt, 0)
+	if accessedAt != 0 {
+		f.AccessedAt = time.Unix(accessedAt, 0)
+	}
+	return f, nil
+}
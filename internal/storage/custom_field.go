@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"database/sql"
+	goerrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+type CustomFieldStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewCustomFieldStore(db *sql.DB) CustomFieldStore {
+	return CustomFieldStore{db: db}
+}
+
+func (s CustomFieldStore) Begin() (*sql.Tx, error) {
+	return s.db.Begin()
+}
+
+// WithTx returns a store that runs all queries in the transaction. The caller
+// is responsible for committing or rolling it back.
+func (s CustomFieldStore) WithTx(tx *sql.Tx) CustomFieldStore {
+	return CustomFieldStore{db: s.db, tx: tx}
+}
+
+func (s CustomFieldStore) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s CustomFieldStore) Create(f model.CustomField) (int, error) {
+	res, err := s.q().Exec(`
+		INSERT INTO custom_fields (name, type, options)
+		VALUES (:name, :type, :options)
+	`,
+		sql.Named("name", f.Name),
+		sql.Named("type", f.Type),
+		sql.Named("options", strings.Join(f.Options, ",")))
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, errors.NewInvalidCustomField(fmt.Sprintf("Custom field %s already exists", f.Name), err)
+		}
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s CustomFieldStore) Update(f model.CustomField) error {
+	res, err := s.q().Exec(`
+		UPDATE custom_fields
+		SET name = :name, type = :type, options = :options
+		WHERE id = :id
+	`,
+		sql.Named("id", f.ID),
+		sql.Named("name", f.Name),
+		sql.Named("type", f.Type),
+		sql.Named("options", strings.Join(f.Options, ",")))
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return errors.NewInvalidCustomField(fmt.Sprintf("Custom field %s already exists", f.Name), err)
+		}
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewCustomFieldNotExists(fmt.Sprintf("Custom field with id: %d doesn`t exist", f.ID), err)
+	}
+	return nil
+}
+
+func (s CustomFieldStore) Delete(id int) error {
+	res, err := s.q().Exec(`
+		DELETE FROM custom_fields
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewCustomFieldNotExists(fmt.Sprintf("Custom field with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func (s CustomFieldStore) GetByID(id int) (model.CustomField, error) {
+	row := s.q().QueryRow(`
+		SELECT id, name, type, options
+		FROM custom_fields
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	f, err := scanCustomField(row)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return f, errors.NewCustomFieldNotExists(fmt.Sprintf("Custom field with id: %d doesn`t exist", id), err)
+	}
+	return f, err
+}
+
+func (s CustomFieldStore) GetAll() ([]model.CustomField, error) {
+	rows, err := s.q().Query(`
+		SELECT id, name, type, options
+		FROM custom_fields
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.CustomField
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, f)
+	}
+
+	err = rows.Err()
+	return res, err
+}
+
+// NormalizeValues brings stored values in line with a changed field
+// definition: values are converted to the canonical form of the new type and
+// removed when they are no longer valid, e.g. a dropped enum option. It
+// returns the number of removed values.
+func (s CustomFieldStore) NormalizeValues(f model.CustomField) (int, error) {
+	rows, err := s.q().Query(`
+		SELECT task_id, value
+		FROM task_field_values
+		WHERE field_id = :id
+	`,
+		sql.Named("id", f.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	values := make(map[int]string)
+	for rows.Next() {
+		var taskID int
+		var value string
+		err := rows.Scan(&taskID, &value)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		values[taskID] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+	for taskID, value := range values {
+		normalized, err := f.NormalizeValue(value)
+		switch {
+		case err != nil:
+			dropped++
+			_, err = s.q().Exec(`
+				DELETE FROM task_field_values
+				WHERE field_id = :id AND task_id = :task_id
+			`,
+				sql.Named("id", f.ID),
+				sql.Named("task_id", taskID))
+		case normalized != value:
+			_, err = s.q().Exec(`
+				UPDATE task_field_values
+				SET value = :value
+				WHERE field_id = :id AND task_id = :task_id
+			`,
+				sql.Named("id", f.ID),
+				sql.Named("task_id", taskID),
+				sql.Named("value", normalized))
+		}
+		if err != nil {
+			return dropped, err
+		}
+	}
+	return dropped, nil
+}
+
+func scanCustomField(row rowScanner) (model.CustomField, error) {
+	f := model.CustomField{}
+	var options string
+	err := row.Scan(&f.ID, &f.Name, &f.Type, &options)
+	if err != nil {
+		return f, err
+	}
+
+	if len(options) > 0 {
+		f.Options = strings.Split(options, ",")
+	}
+	return f, nil
+}
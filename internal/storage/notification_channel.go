@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// channelSettings holds the kind specific part of a channel, stored as JSON.
+type channelSettings struct {
+	URL      string   `json:"url,omitempty"`
+	Token    string   `json:"token,omitempty"`
+	Format   string   `json:"format,omitempty"`
+	Host     string   `json:"host,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+type NotificationChannelStore struct {
+	db *sql.DB
+}
+
+func NewNotificationChannelStore(db *sql.DB) NotificationChannelStore {
+	return NotificationChannelStore{db: db}
+}
+
+func (s NotificationChannelStore) Create(c model.NotificationChannel) (int, error) {
+	settings, err := marshalChannelSettings(c)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.db.Exec(`
+		INSERT INTO notification_channels (kind, name, enabled, settings)
+		VALUES (:kind, :name, :enabled, :settings)
+	`,
+		sql.Named("kind", c.Kind),
+		sql.Named("name", c.Name),
+		sql.Named("enabled", c.Enabled),
+		sql.Named("settings", settings))
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s NotificationChannelStore) Update(c model.NotificationChannel) error {
+	settings, err := marshalChannelSettings(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.Exec(`
+		UPDATE notification_channels
+		SET kind = :kind, name = :name, enabled = :enabled, settings = :settings
+		WHERE id = :id
+	`,
+		sql.Named("id", c.ID),
+		sql.Named("kind", c.Kind),
+		sql.Named("name", c.Name),
+		sql.Named("enabled", c.Enabled),
+		sql.Named("settings", settings))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidNotificationChannel(fmt.Sprintf("Notification channel with id: %d doesn`t exist", c.ID), err)
+	}
+	return nil
+}
+
+func (s NotificationChannelStore) Delete(id int) error {
+	res, err := s.db.Exec(`
+		DELETE FROM notification_channels
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidNotificationChannel(fmt.Sprintf("Notification channel with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func (s NotificationChannelStore) GetByID(id int) (model.NotificationChannel, error) {
+	row := s.db.QueryRow(`
+		SELECT id, kind, name, enabled, settings
+		FROM notification_channels
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	c, err := scanNotificationChannel(row)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return c, errors.NewInvalidNotificationChannel(fmt.Sprintf("Notification channel with id: %d doesn`t exist", id), err)
+	}
+	return c, err
+}
+
+func (s NotificationChannelStore) GetAll() ([]model.NotificationChannel, error) {
+	rows, err := s.db.Query(`
+		SELECT id, kind, name, enabled, settings
+		FROM notification_channels
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.NotificationChannel
+	for rows.Next() {
+		c, err := scanNotificationChannel(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, c)
+	}
+
+	err = rows.Err()
+	return res, err
+}
+
+func marshalChannelSettings(c model.NotificationChannel) (string, error) {
+	settings, err := json.Marshal(channelSettings{
+		URL:      c.URL,
+		Token:    c.Token,
+		Format:   c.Format,
+		Host:     c.Host,
+		Port:     c.Port,
+		Username: c.Username,
+		Password: c.Password,
+		From:     c.From,
+		To:       c.To,
+	})
+	return string(settings), err
+}
+
+func scanNotificationChannel(row rowScanner) (model.NotificationChannel, error) {
+	c := model.NotificationChannel{}
+	var settings string
+	err := row.Scan(&c.ID, &c.Kind, &c.Name, &c.Enabled, &settings)
+	if err != nil {
+		return c, err
+	}
+
+	cs := channelSettings{}
+	err = json.Unmarshal([]byte(settings), &cs)
+	if err != nil {
+		return c, err
+	}
+
+	c.URL, c.Token, c.Format = cs.URL, cs.Token, cs.Format
+	c.Host, c.Port, c.Username, c.Password = cs.Host, cs.Port, cs.Username, cs.Password
+	c.From, c.To = cs.From, cs.To
+	return c, nil
+}
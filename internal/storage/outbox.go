@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// AddOutboxRecord stores the event in the store transaction, so it is
+// dispatched only if the transaction commits. It returns the record ID.
+func (s TaskStore) AddOutboxRecord(name string, payload string, now time.Time) (int64, error) {
+	res, err := s.q().Exec(`
+		INSERT INTO event_outbox (name, payload, created_at)
+		VALUES (:name, :payload, :created_at)
+	`,
+		sql.Named("name", name),
+		sql.Named("payload", payload),
+		sql.Named("created_at", now.Unix()))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// GetPendingOutboxRecords returns not yet dispatched records in the order
+// they were added.
+func (s TaskStore) GetPendingOutboxRecords(limit int) ([]model.OutboxRecord, error) {
+	rows, err := s.q().Query(`
+		SELECT id, name, payload, created_at
+		FROM event_outbox
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT :limit
+	`,
+		sql.Named("limit", limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanOutboxRecords(rows)
+}
+
+// GetOutboxRecordsAfter returns records with IDs greater than id, including
+// ones not marked dispatched yet: a record is published before it is marked.
+func (s TaskStore) GetOutboxRecordsAfter(id int64, limit int) ([]model.OutboxRecord, error) {
+	rows, err := s.q().Query(`
+		SELECT id, name, payload, created_at
+		FROM event_outbox
+		WHERE id > :id
+		ORDER BY id
+		LIMIT :limit
+	`,
+		sql.Named("id", id),
+		sql.Named("limit", limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanOutboxRecords(rows)
+}
+
+func (s TaskStore) MarkOutboxRecordDispatched(id int64, now time.Time) error {
+	_, err := s.q().Exec(`
+		UPDATE event_outbox
+		SET dispatched_at = :now
+		WHERE id = :id
+	`,
+		sql.Named("id", id),
+		sql.Named("now", now.Unix()))
+	return err
+}
+
+// DeleteOutboxRecordsBefore removes dispatched records older than before.
+func (s TaskStore) DeleteOutboxRecordsBefore(before time.Time) error {
+	_, err := s.q().Exec(`
+		DELETE FROM event_outbox
+		WHERE dispatched_at IS NOT NULL AND created_at < :before
+	`,
+		sql.Named("before", before.Unix()))
+	return err
+}
+
+func scanOutboxRecords(rows *sql.Rows) ([]model.OutboxRecord, error) {
+	defer rows.Close()
+
+	var res []model.OutboxRecord
+	for rows.Next() {
+		r := model.OutboxRecord{}
+		var createdAt int64
+		err := rows.Scan(&r.ID, &r.Name, &r.Payload, &createdAt)
+		if err != nil {
+			return res, err
+		}
+		r.CreatedAt = time.Unix(createdAt, 0)
+		res = append(res, r)
+	}
+
+	err := rows.Err()
+	return res, err
+}
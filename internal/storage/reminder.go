@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const reminderSelect = `
+	SELECT r.id, r.task_id, r.time, r.before, s.title, s.date
+	FROM reminders r
+	JOIN scheduler s ON s.id = r.task_id
+`
+
+type ReminderStore struct {
+	db *sql.DB
+}
+
+func NewReminderStore(db *sql.DB) ReminderStore {
+	return ReminderStore{db: db}
+}
+
+func (s ReminderStore) Create(r model.Reminder) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO reminders (task_id, time, before)
+		SELECT id, :time, :before FROM scheduler WHERE id = :task_id
+	`,
+		sql.Named("task_id", r.TaskID),
+		sql.Named("time", r.Time),
+		sql.Named("before", r.Before))
+
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return 0, errors.NewTaskNotExists(fmt.Sprintf("Task with id: %d doesn`t exist", r.TaskID), err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s ReminderStore) Delete(id int) error {
+	res, err := s.db.Exec(`
+		DELETE FROM reminders
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewReminderNotExists(fmt.Sprintf("Reminder with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func (s ReminderStore) GetAllByTaskID(taskID int) ([]model.Reminder, error) {
+	rows, err := s.db.Query(reminderSelect+`
+		WHERE r.task_id = :task_id
+		ORDER BY r.time, r.before DESC
+	`,
+		sql.Named("task_id", taskID))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReminders(rows)
+}
+
+// GetAllOnDates returns reminders of tasks dated within the inclusive range.
+func (s ReminderStore) GetAllOnDates(from string, to string) ([]model.Reminder, error) {
+	rows, err := s.db.Query(reminderSelect+`
+		WHERE s.date >= :from AND s.date <= :to
+	`,
+		sql.Named("from", from),
+		sql.Named("to", to))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReminders(rows)
+}
+
+// Claim marks the reminder occurrence as being delivered. It returns false if
+// the occurrence was already sent, is being sent or ran out of attempts, so
+// a reminder is never delivered twice, even after a restart.
+func (s ReminderStore) Claim(reminderID int, occurrence string, maxAttempts int, now time.Time) (bool, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO reminder_deliveries (reminder_id, occurrence, status, attempts, updated_at)
+		VALUES (:reminder_id, :occurrence, :sending, 1, :now)
+		ON CONFLICT (reminder_id, occurrence) DO UPDATE
+		SET status = :sending, attempts = attempts + 1, updated_at = :now
+		WHERE status = :failed AND attempts < :max_attempts
+	`,
+		sql.Named("reminder_id", reminderID),
+		sql.Named("occurrence", occurrence),
+		sql.Named("sending", model.DeliverySending),
+		sql.Named("failed", model.DeliveryFailed),
+		sql.Named("max_attempts", maxAttempts),
+		sql.Named("now", now.Unix()))
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (s ReminderStore) Finish(reminderID int, occurrence string, deliveryErr error, now time.Time) error {
+	status, message := model.DeliverySent, ""
+	if deliveryErr != nil {
+		status, message = model.DeliveryFailed, deliveryErr.Error()
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE reminder_deliveries
+		SET status = :status, error = :error, updated_at = :now
+		WHERE reminder_id = :reminder_id AND occurrence = :occurrence
+	`,
+		sql.Named("reminder_id", reminderID),
+		sql.Named("occurrence", occurrence),
+		sql.Named("status", status),
+		sql.Named("error", message),
+		sql.Named("now", now.Unix()))
+	return err
+}
+
+func scanReminders(rows *sql.Rows) ([]model.Reminder, error) {
+	defer rows.Close()
+
+	var res []model.Reminder
+	for rows.Next() {
+		r := model.Reminder{}
+		var date string
+		err := rows.Scan(&r.ID, &r.TaskID, &r.Time, &r.Before, &r.TaskTitle, &date)
+		if err != nil {
+			return res, err
+		}
+		r.TaskDate, err = time.Parse("20060102", date)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, r)
+	}
+
+	err := rows.Err()
+	return res, err
+}
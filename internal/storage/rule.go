@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const maxRuleErrorLength = 1024
+
+type RuleStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewRuleStore(db *sql.DB) RuleStore {
+	return RuleStore{db: db}
+}
+
+// WithTx returns a store that runs all queries in the transaction. The caller
+// is responsible for committing or rolling it back.
+func (s RuleStore) WithTx(tx *sql.Tx) RuleStore {
+	return RuleStore{db: s.db, tx: tx}
+}
+
+func (s RuleStore) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s RuleStore) Create(r model.Rule) (int, error) {
+	conditions, actions, err := encodeRule(r)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.db.Exec(`
+		INSERT INTO rules (name, trigger, conditions, actions, enabled)
+		VALUES (:name, :trigger, :conditions, :actions, :enabled)
+	`,
+		sql.Named("name", r.Name),
+		sql.Named("trigger", r.Trigger),
+		sql.Named("conditions", conditions),
+		sql.Named("actions", actions),
+		sql.Named("enabled", r.Enabled))
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s RuleStore) Update(r model.Rule) error {
+	conditions, actions, err := encodeRule(r)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.Exec(`
+		UPDATE rules
+		SET name = :name, trigger = :trigger, conditions = :conditions, actions = :actions, enabled = :enabled
+		WHERE id = :id
+	`,
+		sql.Named("id", r.ID),
+		sql.Named("name", r.Name),
+		sql.Named("trigger", r.Trigger),
+		sql.Named("conditions", conditions),
+		sql.Named("actions", actions),
+		sql.Named("enabled", r.Enabled))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidRuleFormat(fmt.Sprintf("Rule with id: %d doesn`t exist", r.ID), err)
+	}
+	return nil
+}
+
+func (s RuleStore) Delete(id int) error {
+	res, err := s.db.Exec(`
+		DELETE FROM rules
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidRuleFormat(fmt.Sprintf("Rule with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func (s RuleStore) GetByID(id int) (model.Rule, error) {
+	row := s.db.QueryRow(`
+		SELECT id, name, trigger, conditions, actions, enabled
+		FROM rules
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	r, err := scanRule(row)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return r, errors.NewInvalidRuleFormat(fmt.Sprintf("Rule with id: %d doesn`t exist", id), err)
+	}
+	return r, err
+}
+
+func (s RuleStore) GetAll() ([]model.Rule, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, trigger, conditions, actions, enabled
+		FROM rules
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.Rule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, r)
+	}
+
+	err = rows.Err()
+	return res, err
+}
+
+func (s RuleStore) AddExecution(e model.RuleExecution) (int, error) {
+	actions, err := json.Marshal(e.Actions)
+	if err != nil {
+		return 0, err
+	}
+
+	message := e.Error
+	if len(message) > maxRuleErrorLength {
+		message = message[:maxRuleErrorLength]
+	}
+
+	res, err := s.q().Exec(`
+		INSERT INTO rule_executions (rule_id, event_id, event, task_id, task_title, status, actions, error, created_at)
+		VALUES (:rule_id, :event_id, :event, :task_id, :task_title, :status, :actions, :error, :created_at)
+	`,
+		sql.Named("rule_id", e.RuleID),
+		sql.Named("event_id", e.EventID),
+		sql.Named("event", e.Event),
+		sql.Named("task_id", e.TaskID),
+		sql.Named("task_title", e.TaskTitle),
+		sql.Named("status", e.Status),
+		sql.Named("actions", string(actions)),
+		sql.Named("error", message),
+		sql.Named("created_at", e.CreatedAt.Unix()))
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// HasExecution reports whether the rule has already run for the event.
+func (s RuleStore) HasExecution(ruleID int, eventID int64) (bool, error) {
+	var n int
+	err := s.q().QueryRow(`
+		SELECT count(*) FROM rule_executions WHERE rule_id = :rule_id AND event_id = :event_id
+	`,
+		sql.Named("rule_id", ruleID),
+		sql.Named("event_id", eventID)).Scan(&n)
+	return n > 0, err
+}
+
+// GetExecutions returns the latest executions of the rule.
+func (s RuleStore) GetExecutions(ruleID int, limit int) ([]model.RuleExecution, error) {
+	rows, err := s.q().Query(`
+		SELECT id, rule_id, event_id, event, task_id, task_title, status, actions, error, created_at
+		FROM rule_executions
+		WHERE rule_id = :rule_id
+		ORDER BY id DESC
+		LIMIT :limit
+	`,
+		sql.Named("rule_id", ruleID),
+		sql.Named("limit", limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.RuleExecution
+	for rows.Next() {
+		e := model.RuleExecution{}
+		var actions string
+		var createdAt int64
+		err := rows.Scan(&e.ID, &e.RuleID, &e.EventID, &e.Event, &e.TaskID, &e.TaskTitle, &e.Status,
+			&actions, &e.Error, &createdAt)
+		if err != nil {
+			return res, err
+		}
+		if len(actions) > 0 {
+			if err := json.Unmarshal([]byte(actions), &e.Actions); err != nil {
+				return res, err
+			}
+		}
+		e.CreatedAt = time.Unix(createdAt, 0)
+		res = append(res, e)
+	}
+
+	err = rows.Err()
+	return res, err
+}
+
+func encodeRule(r model.Rule) (string, string, error) {
+	conditions := r.Conditions
+	if conditions == nil {
+		conditions = []model.RuleCondition{}
+	}
+	encodedConditions, err := json.Marshal(conditions)
+	if err != nil {
+		return "", "", err
+	}
+
+	encodedActions, err := json.Marshal(r.Actions)
+	if err != nil {
+		return "", "", err
+	}
+	return string(encodedConditions), string(encodedActions), nil
+}
+
+func scanRule(row rowScanner) (model.Rule, error) {
+	r := model.Rule{}
+	var conditions, actions string
+	err := row.Scan(&r.ID, &r.Name, &r.Trigger, &conditions, &actions, &r.Enabled)
+	if err != nil {
+		return r, err
+	}
+
+	// Stored rules were validated on save, so decode them without
+	// UnmarshalJSON of the enclosing rule.
+	if err := json.Unmarshal([]byte(conditions), &r.Conditions); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal([]byte(actions), &r.Actions); err != nil {
+		return r, err
+	}
+	return r, nil
+}
@@ -2,7 +2,11 @@ package storage
 
 import (
 	"database/sql"
+	goerrors "errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Stern-Ritter/go_task_manager/internal/errors"
@@ -10,47 +14,190 @@ import (
 	"github.com/Stern-Ritter/go_task_manager/internal/utils"
 )
 
+const taskSelect = `
+	SELECT s.id, s.date, s.title, s.comment, s.repeat,
+	COALESCE(d.estimate, 0), COALESCE(d.project, ''), COALESCE(d.priority, 0),
+	COALESCE((SELECT GROUP_CONCAT(tt.tag, ',') FROM task_tags tt WHERE tt.task_id = s.id), '')
+	FROM scheduler s
+	LEFT JOIN task_details d ON d.task_id = s.id
+`
+
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 type TaskStore struct {
 	db *sql.DB
+	tx *sql.Tx
 }
 
 func NewTaskStore(db *sql.DB) TaskStore {
 	return TaskStore{db: db}
 }
 
-func (s TaskStore) Create(t model.Task) (int, error) {
-	res, err := s.db.Exec(`
-		INSERT INTO scheduler (date, title, comment, repeat) 
-		VALUES (:date, :title, :comment, :repeat)
-	`,
-		sql.Named("date", t.Date.Format("20060102")),
-		sql.Named("title", t.Title),
-		sql.Named("comment", t.Comment),
-		sql.Named("repeat", t.Repeat))
+func (s TaskStore) Begin() (*sql.Tx, error) {
+	return s.db.Begin()
+}
+
+// WithTx returns a store that runs all queries in the transaction. The caller
+// is responsible for committing or rolling it back.
+func (s TaskStore) WithTx(tx *sql.Tx) TaskStore {
+	return TaskStore{db: s.db, tx: tx}
+}
+
+func (s TaskStore) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// inTx runs fn in the store transaction or, if there is none, in a new one.
+func (s TaskStore) inTx(fn func(q querier) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
 
+	tx, err := s.db.Begin()
 	if err != nil {
-		return 0, err
+		return err
 	}
+	defer tx.Rollback()
 
-	id, err := res.LastInsertId()
+	err = fn(tx)
 	if err != nil {
-		return 0, err
+		return err
 	}
+	return tx.Commit()
+}
+
+func (s TaskStore) Create(t model.Task) (int, error) {
+	var id int64
+	err := s.inTx(func(q querier) error {
+		res, err := q.Exec(`
+			INSERT INTO scheduler (date, title, comment, repeat)
+			VALUES (:date, :title, :comment, :repeat)
+		`,
+			sql.Named("date", t.Date.Format("20060102")),
+			sql.Named("title", t.Title),
+			sql.Named("comment", t.Comment),
+			sql.Named("repeat", t.Repeat))
+
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		estimate, project, priority := 0, "", 0
+		if t.Estimate != nil {
+			estimate = *t.Estimate
+		}
+		if t.Project != nil {
+			project = *t.Project
+		}
+		if t.Priority != nil {
+			priority = *t.Priority
+		}
 
+		_, err = q.Exec(`
+			INSERT OR REPLACE INTO task_details (task_id, estimate, project, priority)
+			VALUES (:id, :estimate, :project, :priority)
+		`,
+			sql.Named("id", id),
+			sql.Named("estimate", estimate),
+			sql.Named("project", project),
+			sql.Named("priority", priority))
+
+		if err != nil {
+			return err
+		}
+
+		err = saveTags(q, int(id), t.Tags)
+		if err != nil {
+			return err
+		}
+
+		return saveFieldValues(q, int(id), t.Fields)
+	})
+
+	if err != nil {
+		return 0, err
+	}
 	return int(id), nil
 }
 
 func (s TaskStore) Update(t model.Task) error {
-	res, err := s.db.Exec(`
-		UPDATE scheduler 
-		SET date = :date, title = :title, comment = :comment, repeat = :repeat 
+	return s.inTx(func(q querier) error {
+		res, err := q.Exec(`
+			UPDATE scheduler
+			SET date = :date, title = :title, comment = :comment, repeat = :repeat
+			WHERE id = :id
+		`,
+			sql.Named("id", t.ID),
+			sql.Named("date", t.Date.Format("20060102")),
+			sql.Named("title", t.Title),
+			sql.Named("comment", t.Comment),
+			sql.Named("repeat", t.Repeat))
+
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil || rows == 0 {
+			return errors.NewTaskNotExists(fmt.Sprintf("Task with id: %d doesn`t exist", t.ID), err)
+		}
+
+		_, err = q.Exec(`
+			INSERT INTO task_details (task_id, estimate, project, priority)
+			VALUES (:id, COALESCE(:estimate, 0), COALESCE(:project, ''), COALESCE(:priority, 0))
+			ON CONFLICT (task_id) DO UPDATE
+			SET estimate = COALESCE(:estimate, estimate), project = COALESCE(:project, project),
+				priority = COALESCE(:priority, priority)
+		`,
+			sql.Named("id", t.ID),
+			sql.Named("estimate", t.Estimate),
+			sql.Named("project", t.Project),
+			sql.Named("priority", t.Priority))
+
+		if err != nil {
+			return err
+		}
+
+		if t.Tags != nil {
+			err = saveTags(q, t.ID, t.Tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		if t.Fields != nil {
+			return saveFieldValues(q, t.ID, t.Fields)
+		}
+		return nil
+	})
+}
+
+func (s TaskStore) Complete(t model.Task) error {
+	now := time.Now()
+	nextDate, err := utils.NextDate(now, t.Date.Format("20060102"), t.Repeat)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.q().Exec(`
+		UPDATE scheduler
+		SET date = :date
 		WHERE id = :id
 	`,
 		sql.Named("id", t.ID),
-		sql.Named("date", t.Date.Format("20060102")),
-		sql.Named("title", t.Title),
-		sql.Named("comment", t.Comment),
-		sql.Named("repeat", t.Repeat))
+		sql.Named("date", nextDate))
 
 	if err != nil {
 		return err
@@ -63,20 +210,14 @@ func (s TaskStore) Update(t model.Task) error {
 	return nil
 }
 
-func (s TaskStore) Complete(t model.Task) error {
-	now := time.Now()
-	nextDate, err := utils.NextDate(now, t.Date.Format("20060102"), t.Repeat)
-	if err != nil {
-		return err
-	}
-
-	res, err := s.db.Exec(`
-		UPDATE scheduler 
-		SET date = :date 
+func (s TaskStore) SetDate(id int, date time.Time) error {
+	res, err := s.q().Exec(`
+		UPDATE scheduler
+		SET date = :date
 		WHERE id = :id
 	`,
-		sql.Named("id", t.ID),
-		sql.Named("date", nextDate))
+		sql.Named("id", id),
+		sql.Named("date", date.Format("20060102")))
 
 	if err != nil {
 		return err
@@ -84,14 +225,41 @@ func (s TaskStore) Complete(t model.Task) error {
 
 	rows, err := res.RowsAffected()
 	if err != nil || rows == 0 {
-		return errors.NewTaskNotExists(fmt.Sprintf("Task with id: %d doesn`t exist", t.ID), err)
+		return errors.NewTaskNotExists(fmt.Sprintf("Task with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func (s TaskStore) AddTags(id int, tags []string) error {
+	for _, tag := range tags {
+		_, err := s.q().Exec(`
+			INSERT OR IGNORE INTO task_tags (task_id, tag)
+			VALUES (:id, :tag)
+		`,
+			sql.Named("id", id),
+			sql.Named("tag", tag))
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+func (s TaskStore) SetProject(id int, project string) error {
+	_, err := s.q().Exec(`
+		INSERT INTO task_details (task_id, project)
+		VALUES (:id, :project)
+		ON CONFLICT (task_id) DO UPDATE
+		SET project = :project
+	`,
+		sql.Named("id", id),
+		sql.Named("project", project))
+	return err
+}
+
 func (s TaskStore) Delete(id int) error {
-	res, err := s.db.Exec(`
-		DELETE FROM scheduler 
+	res, err := s.q().Exec(`
+		DELETE FROM scheduler
 		WHERE id = :id
 	`,
 		sql.Named("id", id))
@@ -108,122 +276,233 @@ func (s TaskStore) Delete(id int) error {
 }
 
 func (s TaskStore) GetByID(id int) (model.Task, error) {
-	row := s.db.QueryRow(`
-		SELECT id, date, title, comment, repeat 
-		FROM scheduler 
-		WHERE id = :id
+	row := s.q().QueryRow(taskSelect+`
+		WHERE s.id = :id
 	`,
 		sql.Named("id", id))
 
-	t := model.Task{}
-	var date string
-	err := row.Scan(&t.ID, &date, &t.Title, &t.Comment, &t.Repeat)
-	if err != nil {
-		return t, err
+	t, err := scanTask(row)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return t, errors.NewTaskNotExists(fmt.Sprintf("Task with id: %d doesn`t exist", id), err)
 	}
-	t.Date, err = time.Parse("20060102", date)
 	if err != nil {
 		return t, err
 	}
 
-	return t, nil
+	tasks := []model.Task{t}
+	err = s.loadFieldValues(tasks)
+	return tasks[0], err
 }
 
 func (s TaskStore) GetAll() ([]model.Task, error) {
-	rows, err := s.db.Query(`
-		SELECT id, date, title, comment, repeat 
-		FROM scheduler 
-		ORDER BY date
+	rows, err := s.q().Query(taskSelect + `
+		ORDER BY s.date
 	`)
+	if err != nil {
+		return nil, err
+	}
 
-	var res []model.Task
+	return s.scanTasksWithFields(rows)
+}
 
+func (s TaskStore) GetAllByTitleOrComment(search string) ([]model.Task, error) {
+	rows, err := s.q().Query(taskSelect+`
+		WHERE s.title LIKE :search OR
+		s.comment LIKE :search ORDER BY s.date
+	`,
+		sql.Named("search", search))
 	if err != nil {
-		return res, err
+		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		t := model.Task{}
-		var date string
-		err := rows.Scan(&t.ID, &date, &t.Title, &t.Comment, &t.Repeat)
-		if err != nil {
-			return res, err
-		}
-		t.Date, err = time.Parse("20060102", date)
-		if err != nil {
-			return res, err
-		}
-		res = append(res, t)
+	return s.scanTasksWithFields(rows)
+}
+
+func (s TaskStore) GetAllByDate(date string) ([]model.Task, error) {
+	rows, err := s.q().Query(taskSelect+`
+		WHERE s.date = :date
+	`,
+		sql.Named("date", date))
+	if err != nil {
+		return nil, err
 	}
 
-	err = rows.Err()
-	return res, err
+	return s.scanTasksWithFields(rows)
 }
 
-func (s TaskStore) GetAllByTitleOrComment(search string) ([]model.Task, error) {
-	rows, err := s.db.Query(`
-		SELECT id, date, title, comment, repeat 
-		FROM scheduler 
-		WHERE title LIKE :search OR 
-		comment LIKE :search ORDER BY date
+func (s TaskStore) GetAllBefore(date string) ([]model.Task, error) {
+	rows, err := s.q().Query(taskSelect+`
+		WHERE s.date < :date
+		ORDER BY s.date
 	`,
-		sql.Named("search", search))
+		sql.Named("date", date))
+	if err != nil {
+		return nil, err
+	}
 
-	var res []model.Task
+	return s.scanTasksWithFields(rows)
+}
+
+// GetAllInRange returns tasks dated within the inclusive range.
+func (s TaskStore) GetAllInRange(from string, to string) ([]model.Task, error) {
+	rows, err := s.q().Query(taskSelect+`
+		WHERE s.date >= :from AND s.date <= :to
+		ORDER BY s.date
+	`,
+		sql.Named("from", from),
+		sql.Named("to", to))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.scanTasksWithFields(rows)
+}
 
+func (s TaskStore) scanTasksWithFields(rows *sql.Rows) ([]model.Task, error) {
+	tasks, err := scanTasks(rows)
 	if err != nil {
-		return res, err
+		return tasks, err
+	}
+
+	err = s.loadFieldValues(tasks)
+	return tasks, err
+}
+
+func (s TaskStore) loadFieldValues(tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tasks))
+	idx := make(map[int]int, len(tasks))
+	for i := range tasks {
+		tasks[i].Fields = make(map[string]string)
+		ids[i] = strconv.Itoa(tasks[i].ID)
+		idx[tasks[i].ID] = i
+	}
+
+	rows, err := s.q().Query(`
+		SELECT v.task_id, f.name, v.value
+		FROM task_field_values v
+		JOIN custom_fields f ON f.id = v.field_id
+		WHERE v.task_id IN (` + strings.Join(ids, ",") + `)
+	`)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		t := model.Task{}
-		var date string
-		err := rows.Scan(&t.ID, &date, &t.Title, &t.Comment, &t.Repeat)
+		var taskID int
+		var name, value string
+		err := rows.Scan(&taskID, &name, &value)
 		if err != nil {
-			return res, err
+			return err
 		}
-		t.Date, err = time.Parse("20060102", date)
+		tasks[idx[taskID]].Fields[name] = value
+	}
+
+	return rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (model.Task, error) {
+	t := model.Task{}
+	var date, tags string
+	estimate, project, priority := 0, "", 0
+	err := row.Scan(&t.ID, &date, &t.Title, &t.Comment, &t.Repeat, &estimate, &project, &priority, &tags)
+	if err != nil {
+		return t, err
+	}
+	t.Date, err = time.Parse("20060102", date)
+	if err != nil {
+		return t, err
+	}
+
+	t.Estimate = &estimate
+	t.Project = &project
+	t.Priority = &priority
+	t.Tags = splitTags(tags)
+	return t, nil
+}
+
+func scanTasks(rows *sql.Rows) ([]model.Task, error) {
+	defer rows.Close()
+
+	var res []model.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
 		if err != nil {
 			return res, err
 		}
 		res = append(res, t)
 	}
 
-	err = rows.Err()
+	err := rows.Err()
 	return res, err
 }
 
-func (s TaskStore) GetAllByDate(date string) ([]model.Task, error) {
-	rows, err := s.db.Query(`
-		SELECT id, date, title, comment, repeat 
-		FROM scheduler 
-		WHERE date = :date
+func saveTags(q querier, taskID int, tags []string) error {
+	_, err := q.Exec(`
+		DELETE FROM task_tags
+		WHERE task_id = :id
 	`,
-		sql.Named("date", date))
+		sql.Named("id", taskID))
+	if err != nil {
+		return err
+	}
 
-	var res []model.Task
+	for _, tag := range tags {
+		_, err = q.Exec(`
+			INSERT INTO task_tags (task_id, tag)
+			VALUES (:id, :tag)
+		`,
+			sql.Named("id", taskID),
+			sql.Named("tag", tag))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func saveFieldValues(q querier, taskID int, fields map[string]string) error {
+	_, err := q.Exec(`
+		DELETE FROM task_field_values
+		WHERE task_id = :id
+	`,
+		sql.Named("id", taskID))
 	if err != nil {
-		return res, err
+		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		t := model.Task{}
-		var date string
-		err := rows.Scan(&t.ID, &date, &t.Title, &t.Comment, &t.Repeat)
-		if err != nil {
-			return res, err
+	for name, value := range fields {
+		if len(value) == 0 {
+			continue
 		}
-		t.Date, err = time.Parse("20060102", date)
+
+		_, err = q.Exec(`
+			INSERT INTO task_field_values (task_id, field_id, value)
+			SELECT :id, id, :value FROM custom_fields WHERE name = :name
+		`,
+			sql.Named("id", taskID),
+			sql.Named("name", name),
+			sql.Named("value", value))
 		if err != nil {
-			return res, err
+			return err
 		}
-		res = append(res, t)
 	}
+	return nil
+}
 
-	err = rows.Err()
-	return res, err
+func splitTags(tags string) []string {
+	if len(tags) == 0 {
+		return []string{}
+	}
+	res := strings.Split(tags, ",")
+	sort.Strings(res)
+	return res
 }
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+// AddEvent records an event in the task history. History rows outlive the
+// task: on delete they keep the title but lose the task id.
+func (s TaskStore) AddEvent(e model.TaskEvent) error {
+	_, err := s.q().Exec(`
+		INSERT INTO task_history (task_id, task_title, event, from_date, to_date, created_at)
+		VALUES (:task_id, :task_title, :event, :from_date, :to_date, :created_at)
+	`,
+		sql.Named("task_id", e.TaskID),
+		sql.Named("task_title", e.TaskTitle),
+		sql.Named("event", e.Event),
+		sql.Named("from_date", formatHistoryDate(e.FromDate)),
+		sql.Named("to_date", formatHistoryDate(e.ToDate)),
+		sql.Named("created_at", e.CreatedAt.Unix()))
+	return err
+}
+
+// CountEvents returns how many times the event is recorded for the task.
+func (s TaskStore) CountEvents(taskID int, event string) (int, error) {
+	var count int
+	err := s.q().QueryRow(`
+		SELECT count(id)
+		FROM task_history
+		WHERE task_id = :task_id AND event = :event
+	`,
+		sql.Named("task_id", taskID),
+		sql.Named("event", event)).Scan(&count)
+	return count, err
+}
+
+func (s TaskStore) GetMostPostponed(minCount int, limit int) ([]model.PostponedTask, error) {
+	rows, err := s.q().Query(`
+		SELECT task_id, count(id) AS postponed
+		FROM task_history
+		WHERE event = :event AND task_id IS NOT NULL
+		GROUP BY task_id
+		HAVING postponed >= :min_count
+		ORDER BY postponed DESC, task_id
+		LIMIT :limit
+	`,
+		sql.Named("event", model.TaskEventPostponed),
+		sql.Named("min_count", minCount),
+		sql.Named("limit", limit))
+	if err != nil {
+		return nil, err
+	}
+
+	type postponedCount struct {
+		taskID int
+		count  int
+	}
+	var counts []postponedCount
+	for rows.Next() {
+		var c postponedCount
+		err := rows.Scan(&c.taskID, &c.count)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]model.PostponedTask, 0, len(counts))
+	for _, c := range counts {
+		t, err := s.GetByID(c.taskID)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, model.PostponedTask{Task: t, Postponed: c.count})
+	}
+	return res, nil
+}
+
+// GetCompletions returns the completions recorded from from until to, oldest
+// first, with the current repeat rule and date of their tasks.
+func (s TaskStore) GetCompletions(from time.Time, to time.Time) ([]model.Completion, error) {
+	rows, err := s.q().Query(`
+		SELECT h.id, COALESCE(h.task_id, 0), h.task_title, h.from_date, h.created_at,
+			COALESCE(s.repeat, ''), COALESCE(s.date, '')
+		FROM task_history h
+		LEFT JOIN scheduler s ON s.id = h.task_id
+		WHERE h.event = :event AND h.created_at >= :from AND h.created_at < :to
+		ORDER BY h.created_at, h.id
+	`,
+		sql.Named("event", model.TaskEventCompleted),
+		sql.Named("from", from.Unix()),
+		sql.Named("to", to.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.Completion
+	for rows.Next() {
+		c := model.Completion{TaskEvent: model.TaskEvent{Event: model.TaskEventCompleted}}
+		var fromDate, taskDate string
+		var createdAt int64
+		err := rows.Scan(&c.ID, &c.TaskID, &c.TaskTitle, &fromDate, &createdAt, &c.Repeat, &taskDate)
+		if err != nil {
+			return nil, err
+		}
+
+		c.CreatedAt = time.Unix(createdAt, 0)
+		if c.FromDate, err = parseHistoryDate(fromDate); err != nil {
+			return nil, err
+		}
+		if c.TaskDate, err = parseHistoryDate(taskDate); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// GetPostponedInRange returns the tasks postponed most often from from
+// until to. Postponements of deleted tasks are counted by title.
+func (s TaskStore) GetPostponedInRange(from time.Time, to time.Time, limit int) ([]model.PostponedStat, error) {
+	rows, err := s.q().Query(`
+		SELECT COALESCE(h.task_id, 0), COALESCE(s.title, MAX(h.task_title)), count(*) AS postponed
+		FROM task_history h
+		LEFT JOIN scheduler s ON s.id = h.task_id
+		WHERE h.event = :event AND h.created_at >= :from AND h.created_at < :to
+		GROUP BY h.task_id, CASE WHEN h.task_id IS NULL THEN h.task_title END
+		ORDER BY postponed DESC, h.task_id
+		LIMIT :limit
+	`,
+		sql.Named("event", model.TaskEventPostponed),
+		sql.Named("from", from.Unix()),
+		sql.Named("to", to.Unix()),
+		sql.Named("limit", limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.PostponedStat
+	for rows.Next() {
+		p := model.PostponedStat{}
+		if err := rows.Scan(&p.TaskID, &p.Title, &p.Postponed); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+func formatHistoryDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("20060102")
+}
+
+func parseHistoryDate(value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	return time.Parse("20060102", value)
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+type TemplateStore struct {
+	db *sql.DB
+}
+
+func NewTemplateStore(db *sql.DB) TemplateStore {
+	return TemplateStore{db: db}
+}
+
+func (s TemplateStore) Create(t model.Template) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO task_templates (name, title, comment, repeat, checklist, tags)
+		VALUES (:name, :title, :comment, :repeat, :checklist, :tags)
+	`,
+		sql.Named("name", t.Name),
+		sql.Named("title", t.Title),
+		sql.Named("comment", t.Comment),
+		sql.Named("repeat", t.Repeat),
+		sql.Named("checklist", strings.Join(t.Checklist, "\n")),
+		sql.Named("tags", strings.Join(t.Tags, ",")))
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, errors.NewInvalidTemplateFormat(fmt.Sprintf("Template %s already exists", t.Name), err)
+		}
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s TemplateStore) Update(t model.Template) error {
+	res, err := s.db.Exec(`
+		UPDATE task_templates
+		SET name = :name, title = :title, comment = :comment, repeat = :repeat, checklist = :checklist,
+		tags = :tags
+		WHERE id = :id
+	`,
+		sql.Named("id", t.ID),
+		sql.Named("name", t.Name),
+		sql.Named("title", t.Title),
+		sql.Named("comment", t.Comment),
+		sql.Named("repeat", t.Repeat),
+		sql.Named("checklist", strings.Join(t.Checklist, "\n")),
+		sql.Named("tags", strings.Join(t.Tags, ",")))
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return errors.NewInvalidTemplateFormat(fmt.Sprintf("Template %s already exists", t.Name), err)
+		}
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidTemplateFormat(fmt.Sprintf("Template with id: %d doesn`t exist", t.ID), err)
+	}
+	return nil
+}
+
+func (s TemplateStore) Delete(id int) error {
+	res, err := s.db.Exec(`
+		DELETE FROM task_templates
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidTemplateFormat(fmt.Sprintf("Template with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func (s TemplateStore) GetByID(id int) (model.Template, error) {
+	row := s.db.QueryRow(`
+		SELECT id, name, title, comment, repeat, checklist, tags
+		FROM task_templates
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	return scanTemplate(row)
+}
+
+func (s TemplateStore) GetAll() ([]model.Template, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, title, comment, repeat, checklist, tags
+		FROM task_templates
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, t)
+	}
+
+	err = rows.Err()
+	return res, err
+}
+
+func scanTemplate(row rowScanner) (model.Template, error) {
+	t := model.Template{}
+	var checklist, tags string
+	err := row.Scan(&t.ID, &t.Name, &t.Title, &t.Comment, &t.Repeat, &checklist, &tags)
+	if err != nil {
+		return t, err
+	}
+
+	t.Checklist = []string{}
+	if len(checklist) > 0 {
+		t.Checklist = strings.Split(checklist, "\n")
+	}
+	t.Tags = splitTags(tags)
+	return t, nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const timeEntrySelect = `
+	SELECT id, COALESCE(task_id, 0), task_title, project, tags, start, stop, note
+	FROM time_entries
+`
+
+type TimeEntryStore struct {
+	db *sql.DB
+}
+
+func NewTimeEntryStore(db *sql.DB) TimeEntryStore {
+	return TimeEntryStore{db: db}
+}
+
+func (s TimeEntryStore) Start(e model.TimeEntry) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO time_entries (task_id, task_title, project, tags, start, note)
+		VALUES (:task_id, :task_title, :project, :tags, :start, :note)
+	`,
+		sql.Named("task_id", e.TaskID),
+		sql.Named("task_title", e.TaskTitle),
+		sql.Named("project", e.Project),
+		sql.Named("tags", strings.Join(e.Tags, ",")),
+		sql.Named("start", e.Start.Unix()),
+		sql.Named("note", e.Note))
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, errors.NewTimerAlreadyRunning("another timer is already running", err)
+		}
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s TimeEntryStore) Stop(e model.TimeEntry) error {
+	if e.Stop == nil {
+		return fmt.Errorf("time entry %d has no stop time", e.ID)
+	}
+
+	res, err := s.db.Exec(`
+		UPDATE time_entries
+		SET stop = :stop, task_title = :task_title, project = :project, tags = :tags
+		WHERE id = :id AND stop IS NULL
+	`,
+		sql.Named("id", e.ID),
+		sql.Named("stop", e.Stop.Unix()),
+		sql.Named("task_title", e.TaskTitle),
+		sql.Named("project", e.Project),
+		sql.Named("tags", strings.Join(e.Tags, ",")))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewTimerNotRunning(fmt.Sprintf("Timer with id: %d isn`t running", e.ID), err)
+	}
+	return nil
+}
+
+func (s TimeEntryStore) GetRunning() (model.TimeEntry, error) {
+	row := s.db.QueryRow(timeEntrySelect + `
+		WHERE stop IS NULL
+	`)
+
+	return scanTimeEntry(row)
+}
+
+func (s TimeEntryStore) GetAllByTaskID(taskID int) ([]model.TimeEntry, error) {
+	rows, err := s.db.Query(timeEntrySelect+`
+		WHERE task_id = :task_id
+		ORDER BY start
+	`,
+		sql.Named("task_id", taskID))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTimeEntries(rows)
+}
+
+// GetAllInRange returns entries overlapping the half-open interval [from, to),
+// including a running timer started before to.
+func (s TimeEntryStore) GetAllInRange(from time.Time, to time.Time) ([]model.TimeEntry, error) {
+	rows, err := s.db.Query(timeEntrySelect+`
+		WHERE start < :to AND (stop IS NULL OR stop > :from)
+		ORDER BY start
+	`,
+		sql.Named("from", from.Unix()),
+		sql.Named("to", to.Unix()))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTimeEntries(rows)
+}
+
+func scanTimeEntry(row rowScanner) (model.TimeEntry, error) {
+	e := model.TimeEntry{}
+	var tags string
+	var start int64
+	var stop sql.NullInt64
+	err := row.Scan(&e.ID, &e.TaskID, &e.TaskTitle, &e.Project, &tags, &start, &stop, &e.Note)
+	if err != nil {
+		return e, err
+	}
+
+	e.Tags = splitTags(tags)
+	e.Start = time.Unix(start, 0)
+	if stop.Valid {
+		value := time.Unix(stop.Int64, 0)
+		e.Stop = &value
+	}
+	return e, nil
+}
+
+func scanTimeEntries(rows *sql.Rows) ([]model.TimeEntry, error) {
+	defer rows.Close()
+
+	var res []model.TimeEntry
+	for rows.Next() {
+		e, err := scanTimeEntry(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, e)
+	}
+
+	err := rows.Err()
+	return res, err
+}
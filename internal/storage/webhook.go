@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"database/sql"
+	goerrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+	"github.com/Stern-Ritter/go_task_manager/internal/model"
+)
+
+const maxDeliveryErrorLength = 1024
+
+type WebhookStore struct {
+	db *sql.DB
+}
+
+func NewWebhookStore(db *sql.DB) WebhookStore {
+	return WebhookStore{db: db}
+}
+
+func (s WebhookStore) Create(w model.WebhookSubscription) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO webhook_subscriptions (url, secret, events, enabled)
+		VALUES (:url, :secret, :events, :enabled)
+	`,
+		sql.Named("url", w.URL),
+		sql.Named("secret", w.Secret),
+		sql.Named("events", strings.Join(w.Events, ",")),
+		sql.Named("enabled", w.Enabled))
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s WebhookStore) Update(w model.WebhookSubscription) error {
+	res, err := s.db.Exec(`
+		UPDATE webhook_subscriptions
+		SET url = :url, secret = :secret, events = :events, enabled = :enabled
+		WHERE id = :id
+	`,
+		sql.Named("id", w.ID),
+		sql.Named("url", w.URL),
+		sql.Named("secret", w.Secret),
+		sql.Named("events", strings.Join(w.Events, ",")),
+		sql.Named("enabled", w.Enabled))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidWebhookSubscription(fmt.Sprintf("Webhook with id: %d doesn`t exist", w.ID), err)
+	}
+	return nil
+}
+
+func (s WebhookStore) Delete(id int) error {
+	res, err := s.db.Exec(`
+		DELETE FROM webhook_subscriptions
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.NewInvalidWebhookSubscription(fmt.Sprintf("Webhook with id: %d doesn`t exist", id), err)
+	}
+	return nil
+}
+
+func (s WebhookStore) GetByID(id int) (model.WebhookSubscription, error) {
+	row := s.db.QueryRow(`
+		SELECT id, url, secret, events, enabled
+		FROM webhook_subscriptions
+		WHERE id = :id
+	`,
+		sql.Named("id", id))
+
+	w, err := scanWebhookSubscription(row)
+	if goerrors.Is(err, sql.ErrNoRows) {
+		return w, errors.NewInvalidWebhookSubscription(fmt.Sprintf("Webhook with id: %d doesn`t exist", id), err)
+	}
+	return w, err
+}
+
+func (s WebhookStore) GetAll() ([]model.WebhookSubscription, error) {
+	rows, err := s.db.Query(`
+		SELECT id, url, secret, events, enabled
+		FROM webhook_subscriptions
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.WebhookSubscription
+	for rows.Next() {
+		w, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, w)
+	}
+
+	err = rows.Err()
+	return res, err
+}
+
+// AddDelivery queues a delivery and returns its ID, or 0 when the event is
+// already queued for the subscription.
+func (s WebhookStore) AddDelivery(d model.WebhookDelivery) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event, payload, status, next_attempt_at, created_at, updated_at)
+		VALUES (:subscription_id, :event_id, :event, :payload, :status, :created_at, :created_at, :created_at)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`,
+		sql.Named("subscription_id", d.SubscriptionID),
+		sql.Named("event_id", d.EventID),
+		sql.Named("event", d.Event),
+		sql.Named("payload", d.Payload),
+		sql.Named("status", model.DeliveryPending),
+		sql.Named("created_at", d.CreatedAt.Unix()))
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// GetDueDeliveries returns pending deliveries whose next attempt is due.
+func (s WebhookStore) GetDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	rows, err := s.db.Query(`
+		SELECT id, subscription_id, event_id, event, payload, status, attempts, response_status, error,
+			next_attempt_at, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE status = :status AND next_attempt_at <= :now
+		ORDER BY next_attempt_at, id
+		LIMIT :limit
+	`,
+		sql.Named("status", model.DeliveryPending),
+		sql.Named("now", now.Unix()),
+		sql.Named("limit", limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// GetDeliveries returns the latest deliveries of the subscription.
+func (s WebhookStore) GetDeliveries(subscriptionID int, limit int) ([]model.WebhookDelivery, error) {
+	rows, err := s.db.Query(`
+		SELECT id, subscription_id, event_id, event, payload, status, attempts, response_status, error,
+			next_attempt_at, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE subscription_id = :subscription_id
+		ORDER BY id DESC
+		LIMIT :limit
+	`,
+		sql.Named("subscription_id", subscriptionID),
+		sql.Named("limit", limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt.
+func (s WebhookStore) UpdateDelivery(d model.WebhookDelivery) error {
+	message := d.Error
+	if len(message) > maxDeliveryErrorLength {
+		message = message[:maxDeliveryErrorLength]
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = :status, attempts = :attempts, response_status = :response_status, error = :error,
+			next_attempt_at = :next_attempt_at, updated_at = :updated_at
+		WHERE id = :id
+	`,
+		sql.Named("id", d.ID),
+		sql.Named("status", d.Status),
+		sql.Named("attempts", d.Attempts),
+		sql.Named("response_status", d.ResponseStatus),
+		sql.Named("error", message),
+		sql.Named("next_attempt_at", d.NextAttemptAt.Unix()),
+		sql.Named("updated_at", d.UpdatedAt.Unix()))
+	return err
+}
+
+func scanWebhookSubscription(row rowScanner) (model.WebhookSubscription, error) {
+	w := model.WebhookSubscription{}
+	var events string
+	err := row.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.Enabled)
+	if err != nil {
+		return w, err
+	}
+
+	if len(events) > 0 {
+		w.Events = strings.Split(events, ",")
+	}
+	return w, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var res []model.WebhookDelivery
+	for rows.Next() {
+		d := model.WebhookDelivery{}
+		var nextAttemptAt, createdAt, updatedAt int64
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.Event, &d.Payload, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.Error, &nextAttemptAt, &createdAt, &updatedAt)
+		if err != nil {
+			return res, err
+		}
+		d.NextAttemptAt = time.Unix(nextAttemptAt, 0)
+		d.CreatedAt = time.Unix(createdAt, 0)
+		d.UpdatedAt = time.Unix(updatedAt, 0)
+		res = append(res, d)
+	}
+
+	err := rows.Err()
+	return res, err
+}
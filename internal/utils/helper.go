@@ -21,14 +21,6 @@ func getMaxDate(dates ...time.Time) time.Time {
 	return max
 }
 
-func parseWeekDay(weekDay time.Weekday) int {
-	if weekDay == time.Sunday {
-		return 7
-	} else {
-		return int(weekDay)
-	}
-}
-
 func daysIn(m time.Month, year int) int {
 	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
 }
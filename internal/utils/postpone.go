@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+)
+
+var postponeOffsetRegexp = regexp.MustCompile(`^\+(\d{1,3})([dwm])$`)
+
+// PostponeDate computes the new task date from an expression: an offset from
+// the task date ("+1d", "+2w", "+1m"), "tomorrow", "next monday" or an
+// explicit date. Offsets are counted from today for overdue tasks.
+func PostponeDate(today time.Time, date time.Time, value string) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	var res time.Time
+	if parts := postponeOffsetRegexp.FindStringSubmatch(value); parts != nil {
+		count, _ := strconv.Atoi(parts[1])
+		if count == 0 {
+			return res, errors.NewInvalidDateFormat("postpone offset must be positive", nil)
+		}
+
+		res = getMaxDate(today, date)
+		switch parts[2] {
+		case "d":
+			res = res.AddDate(0, 0, count)
+		case "w":
+			res = res.AddDate(0, 0, 7*count)
+		case "m":
+			res = res.AddDate(0, count, 0)
+		}
+		return res, nil
+	}
+
+	switch {
+	case value == "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case strings.HasPrefix(value, "next "):
+		weekDay, ok := ParseWeekdayName(strings.TrimPrefix(value, "next "))
+		if !ok {
+			return res, errors.NewInvalidDateFormat("invalid postpone week day: "+value, nil)
+		}
+		next, err := NextDate(today, today.Format("20060102"), "w "+strconv.Itoa(WeekdayNumber(weekDay)))
+		if err != nil {
+			return res, err
+		}
+		return ParseDate(next)
+	}
+
+	res, err := ParseDate(value)
+	if err != nil {
+		return res, err
+	}
+	if res.Before(today) {
+		return res, errors.NewInvalidDateFormat("postpone date is in the past", nil)
+	}
+	return res, nil
+}
@@ -13,6 +13,9 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 	if err != nil || !isRepeatValid {
 		return "", errors.NewInvalidRepeatFormat("invalid task repeat format", err)
 	}
+	if !RepeatHasDates(repeat) {
+		return "", errors.NewInvalidRepeatFormat("task repeat rule has no dates", nil)
+	}
 
 	d, err := time.Parse("20060102", date)
 	if err != nil {
@@ -34,6 +37,40 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 	}
 }
 
+// RepeatHasDates reports whether a valid repeat rule has any date at all.
+// Monthly rules may list only days that none of their months have, e.g.
+// "m 30 2", and NextDate would search for their next date forever.
+func RepeatHasDates(repeat string) bool {
+	parts := parseRepeat(repeat)
+	switch parts["type"] {
+	case "y", "w":
+		return true
+	case "d":
+		days, err := strconv.Atoi(parts["value"])
+		return err == nil && days > 0
+	case "m":
+		days, months, err := parseMonthsDaysValue(parts["value"])
+		if err != nil {
+			return false
+		}
+		if len(months) == 0 {
+			months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		}
+		for _, day := range days {
+			if day < 0 {
+				return true
+			}
+			for _, month := range months {
+				// February has a 29th in leap years.
+				if day > 0 && month >= 1 && month <= 12 && day <= daysIn(time.Month(month), 2024) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func nextY(now time.Time, date time.Time) (string, error) {
 	res := date.AddDate(1, 0, 0)
 	for res.Before(now) {
@@ -66,7 +103,7 @@ func nextW(now time.Time, date time.Time, value string) (string, error) {
 	}
 
 	res = res.AddDate(0, 0, 1)
-	for !contains(weekDays, parseWeekDay(res.Weekday())) {
+	for !contains(weekDays, WeekdayNumber(res.Weekday())) {
 		res = res.AddDate(0, 0, 1)
 	}
 
@@ -148,3 +185,81 @@ func checkMonthDays(days []int, currentDay int, monthDaysCount int) bool {
 	}
 	return false
 }
+
+var (
+	weekDayNames = []string{"пн", "вт", "ср", "чт", "пт", "сб", "вс"}
+	monthNames   = []string{"январь", "февраль", "март", "апрель", "май", "июнь", "июль", "август",
+		"сентябрь", "октябрь", "ноябрь", "декабрь"}
+)
+
+// DescribeRepeat returns the repeat rule in words, as the web interface
+// shows it, e.g. "по дням недели: пн, чт" for "w 1,4". Invalid rules are
+// returned as is.
+func DescribeRepeat(repeat string) string {
+	if len(repeat) == 0 {
+		return ""
+	}
+	if isValid, err := ValidateRepeat(repeat); err != nil || !isValid {
+		return repeat
+	}
+
+	parts := parseRepeat(repeat)
+	switch parts["type"] {
+	case "y":
+		return "ежегодно"
+	case "d":
+		days, err := strconv.Atoi(parts["value"])
+		if err != nil {
+			return repeat
+		}
+		switch {
+		case days == 1:
+			return "ежедневно"
+		case days%10 == 1 && days%100 != 11:
+			return "каждый " + strconv.Itoa(days) + " день"
+		case days%10 >= 2 && days%10 <= 4 && (days%100 < 12 || days%100 > 14):
+			return "каждые " + strconv.Itoa(days) + " дня"
+		default:
+			return "каждые " + strconv.Itoa(days) + " дней"
+		}
+	case "w":
+		weekDays, err := parseWeekDaysValue(parts["value"])
+		if err != nil {
+			return repeat
+		}
+		names := make([]string, len(weekDays))
+		for idx, day := range weekDays {
+			names[idx] = weekDayNames[day-1]
+		}
+		return "по дням недели: " + strings.Join(names, ", ")
+	case "m":
+		days, months, err := parseMonthsDaysValue(parts["value"])
+		if err != nil {
+			return repeat
+		}
+		dayNames := make([]string, len(days))
+		for idx, day := range days {
+			switch day {
+			case -1:
+				dayNames[idx] = "последний день"
+			case -2:
+				dayNames[idx] = "предпоследний день"
+			default:
+				dayNames[idx] = strconv.Itoa(day)
+			}
+		}
+		res := "по дням месяца: " + strings.Join(dayNames, ", ")
+		if len(months) > 0 {
+			names := make([]string, len(months))
+			for idx, month := range months {
+				if month < 1 || month > len(monthNames) {
+					return repeat
+				}
+				names[idx] = monthNames[month-1]
+			}
+			res += " (" + strings.Join(names, ", ") + ")"
+		}
+		return res
+	}
+	return repeat
+}
@@ -1,6 +1,14 @@
 package utils
 
-import "regexp"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stern-Ritter/go_task_manager/internal/errors"
+)
 
 const (
 	RepeatPattern = "(^y$)|" +
@@ -8,6 +16,8 @@ const (
 		"(^w\\s[1-7]{1}(,[1-7]){0,6}$)|" +
 		"(^m\\s([012]?[0-9]?|3[01]|-[12]{1}){1}(,([012]?[0-9]?|3[01]|-[12]{1})){0,30}(\\s(([0]?[0-9])|1[012]){1}(,(([0]?[0-9])|1[012])){0,11})?$)"
 	SearchDatePatter = "(0[1-9]|[12][0-9]|3[01])\\.(0[1-9]|1[1,2])\\.(19|20)\\d{2}"
+	TagPattern       = "^[^\\s,#]{1,64}$"
+	FieldNamePattern = "^[\\p{L}\\p{N}_-]{1,64}$"
 )
 
 func ValidateRepeat(repeat string) (bool, error) {
@@ -17,3 +27,71 @@ func ValidateRepeat(repeat string) (bool, error) {
 func ValidateSearchDate(searchDate string) (bool, error) {
 	return regexp.MatchString(SearchDatePatter, searchDate)
 }
+
+func ValidateTag(tag string) (bool, error) {
+	return regexp.MatchString(TagPattern, tag)
+}
+
+func ValidateFieldName(name string) (bool, error) {
+	return regexp.MatchString(FieldNamePattern, name)
+}
+
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse("20060102", value)
+	if err != nil {
+		return date, errors.NewInvalidDateFormat("invalid task date format", err)
+	}
+	return date, nil
+}
+
+// Today returns the day of now in location as a task date: task dates are
+// days, stored as midnight UTC.
+func Today(now time.Time, location *time.Location) time.Time {
+	now = now.In(location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekdayNames are the English names of the days of the week, full and
+// short, with the short forms Todoist also uses.
+var weekdayNames = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday,
+}
+
+// ParseWeekdayName parses the English name of a day of the week, full or
+// short, e.g. "monday", "mon" or "thur".
+func ParseWeekdayName(value string) (time.Weekday, bool) {
+	day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(value))]
+	return day, ok
+}
+
+// ParseWeekday parses a day of the week: its English name, see
+// ParseWeekdayName, or its number from 1 (Monday) to 7 (Sunday), as in
+// repeat rules.
+func ParseWeekday(value string) (time.Weekday, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if number, err := strconv.Atoi(value); err == nil {
+		if number < 1 || number > 7 {
+			return time.Monday, fmt.Errorf("invalid day of the week: %s", value)
+		}
+		return time.Weekday(number % 7), nil
+	}
+	if day, ok := ParseWeekdayName(value); ok {
+		return day, nil
+	}
+	return time.Monday, fmt.Errorf("invalid day of the week: %s", value)
+}
+
+// WeekdayNumber returns the number of the day in repeat rules: from 1
+// (Monday) to 7 (Sunday).
+func WeekdayNumber(day time.Weekday) int {
+	if day == time.Sunday {
+		return 7
+	}
+	return int(day)
+}
@@ -0,0 +1,37 @@
+CREATE TABLE task_details (
+    task_id INTEGER PRIMARY KEY,
+    estimate INTEGER NOT NULL DEFAULT 0,
+    project VARCHAR (128) NOT NULL DEFAULT ""
+);
+
+CREATE TABLE task_tags (
+    task_id INTEGER NOT NULL,
+    tag VARCHAR (64) NOT NULL,
+    PRIMARY KEY (task_id, tag)
+);
+
+CREATE INDEX task_tags_tag_idx ON task_tags(tag);
+
+CREATE TABLE time_entries (
+    id INTEGER PRIMARY KEY,
+    task_id INTEGER,
+    task_title VARCHAR (512) NOT NULL,
+    project VARCHAR (128) NOT NULL DEFAULT "",
+    tags VARCHAR (1024) NOT NULL DEFAULT "",
+    start INTEGER NOT NULL,
+    stop INTEGER,
+    note VARCHAR (1024) NOT NULL DEFAULT ""
+);
+
+CREATE INDEX time_entries_task_idx ON time_entries(task_id);
+CREATE INDEX time_entries_start_idx ON time_entries(start);
+CREATE UNIQUE INDEX time_entries_running_idx ON time_entries((stop IS NULL)) WHERE stop IS NULL;
+
+CREATE TRIGGER scheduler_delete_details AFTER DELETE ON scheduler
+BEGIN
+    DELETE FROM task_details WHERE task_id = OLD.id;
+    DELETE FROM task_tags WHERE task_id = OLD.id;
+    UPDATE time_entries
+    SET stop = COALESCE(stop, CAST(strftime('%s', 'now') AS INTEGER)), task_id = NULL
+    WHERE task_id = OLD.id;
+END;
@@ -0,0 +1,25 @@
+CREATE TABLE custom_fields (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR (64) NOT NULL UNIQUE,
+    type VARCHAR (16) NOT NULL,
+    options VARCHAR (1024) NOT NULL DEFAULT ""
+);
+
+CREATE TABLE task_field_values (
+    task_id INTEGER NOT NULL,
+    field_id INTEGER NOT NULL,
+    value VARCHAR (1024) NOT NULL,
+    PRIMARY KEY (task_id, field_id)
+);
+
+CREATE INDEX task_field_values_field_idx ON task_field_values(field_id, value);
+
+CREATE TRIGGER scheduler_delete_field_values AFTER DELETE ON scheduler
+BEGIN
+    DELETE FROM task_field_values WHERE task_id = OLD.id;
+END;
+
+CREATE TRIGGER custom_fields_delete_values AFTER DELETE ON custom_fields
+BEGIN
+    DELETE FROM task_field_values WHERE field_id = OLD.id;
+END;
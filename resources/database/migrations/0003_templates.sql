@@ -0,0 +1,9 @@
+CREATE TABLE task_templates (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR (128) NOT NULL UNIQUE,
+    title VARCHAR (512) NOT NULL,
+    comment VARCHAR (1024) NOT NULL DEFAULT "",
+    repeat VARCHAR (128) NOT NULL DEFAULT "",
+    checklist VARCHAR (2048) NOT NULL DEFAULT "",
+    tags VARCHAR (1024) NOT NULL DEFAULT ""
+);
@@ -0,0 +1,17 @@
+CREATE TABLE task_history (
+    id INTEGER PRIMARY KEY,
+    task_id INTEGER,
+    task_title VARCHAR (512) NOT NULL,
+    event VARCHAR (32) NOT NULL,
+    from_date CHAR(8) NOT NULL DEFAULT "",
+    to_date CHAR(8) NOT NULL DEFAULT "",
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX task_history_task_idx ON task_history(task_id, event);
+CREATE INDEX task_history_event_idx ON task_history(event, created_at);
+
+CREATE TRIGGER scheduler_delete_history AFTER DELETE ON scheduler
+BEGIN
+    UPDATE task_history SET task_id = NULL WHERE task_id = OLD.id;
+END;
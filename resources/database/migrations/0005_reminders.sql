@@ -0,0 +1,29 @@
+CREATE TABLE reminders (
+    id INTEGER PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    time CHAR(5) NOT NULL,
+    before INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX reminders_task_idx ON reminders(task_id);
+
+CREATE TABLE reminder_deliveries (
+    id INTEGER PRIMARY KEY,
+    reminder_id INTEGER NOT NULL,
+    occurrence CHAR(8) NOT NULL,
+    status VARCHAR (16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error VARCHAR (1024) NOT NULL DEFAULT "",
+    updated_at INTEGER NOT NULL,
+    UNIQUE (reminder_id, occurrence)
+);
+
+CREATE TRIGGER scheduler_delete_reminders AFTER DELETE ON scheduler
+BEGIN
+    DELETE FROM reminders WHERE task_id = OLD.id;
+END;
+
+CREATE TRIGGER reminders_delete_deliveries AFTER DELETE ON reminders
+BEGIN
+    DELETE FROM reminder_deliveries WHERE reminder_id = OLD.id;
+END;
@@ -0,0 +1,7 @@
+CREATE TABLE notification_channels (
+    id INTEGER PRIMARY KEY,
+    kind VARCHAR (16) NOT NULL,
+    name VARCHAR (64) NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    settings TEXT NOT NULL DEFAULT "{}"
+);
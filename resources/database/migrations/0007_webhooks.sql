@@ -0,0 +1,31 @@
+CREATE TABLE webhook_subscriptions (
+    id INTEGER PRIMARY KEY,
+    url VARCHAR (2048) NOT NULL,
+    secret VARCHAR (128) NOT NULL,
+    events VARCHAR (256) NOT NULL DEFAULT "",
+    enabled INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    event VARCHAR (32) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR (16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER NOT NULL DEFAULT 0,
+    error VARCHAR (1024) NOT NULL DEFAULT "",
+    next_attempt_at INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries(subscription_id);
+
+CREATE TRIGGER webhook_subscriptions_delete_deliveries AFTER DELETE ON webhook_subscriptions
+BEGIN
+    DELETE FROM webhook_deliveries WHERE subscription_id = OLD.id;
+END;
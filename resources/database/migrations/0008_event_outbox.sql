@@ -0,0 +1,9 @@
+CREATE TABLE event_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR (32) NOT NULL,
+    payload TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    dispatched_at INTEGER
+);
+
+CREATE INDEX event_outbox_pending_idx ON event_outbox(dispatched_at, id);
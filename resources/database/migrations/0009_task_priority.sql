@@ -0,0 +1 @@
+ALTER TABLE task_details ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
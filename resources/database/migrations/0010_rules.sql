@@ -0,0 +1,29 @@
+CREATE TABLE rules (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR (128) NOT NULL,
+    trigger VARCHAR (32) NOT NULL,
+    conditions TEXT NOT NULL DEFAULT "[]",
+    actions TEXT NOT NULL DEFAULT "[]",
+    enabled INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE rule_executions (
+    id INTEGER PRIMARY KEY,
+    rule_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    event VARCHAR (32) NOT NULL,
+    task_id INTEGER NOT NULL,
+    task_title VARCHAR (512) NOT NULL,
+    status VARCHAR (16) NOT NULL,
+    actions TEXT NOT NULL DEFAULT "",
+    error VARCHAR (1024) NOT NULL DEFAULT "",
+    created_at INTEGER NOT NULL,
+    UNIQUE (rule_id, event_id)
+);
+
+CREATE INDEX rule_executions_rule_idx ON rule_executions(rule_id);
+
+CREATE TRIGGER rules_delete_executions AFTER DELETE ON rules
+BEGIN
+    DELETE FROM rule_executions WHERE rule_id = OLD.id;
+END;
@@ -0,0 +1,12 @@
+CREATE TABLE calendar_feeds (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR (128) NOT NULL,
+    token_hash VARCHAR (64) NOT NULL,
+    kind VARCHAR (8) NOT NULL DEFAULT "event",
+    project VARCHAR (128) NULL,
+    expand INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,
+    accessed_at INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX calendar_feeds_token_idx ON calendar_feeds(token_hash);
@@ -0,0 +1,12 @@
+CREATE TABLE caldav_resources (
+    task_id INTEGER PRIMARY KEY,
+    name VARCHAR (255) NOT NULL,
+    uid VARCHAR (255) NOT NULL
+);
+
+CREATE UNIQUE INDEX caldav_resources_name_idx ON caldav_resources(name);
+
+CREATE TRIGGER scheduler_delete_caldav_resources AFTER DELETE ON scheduler
+BEGIN
+    DELETE FROM caldav_resources WHERE task_id = OLD.id;
+END;
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -74,6 +75,42 @@ func postJSON(apipath string, values map[string]any, method string) (map[string]
 	return m, err
 }
 
+// rawRequest sends the body as is and returns the status, headers and body
+// of the response.
+func rawRequest(t *testing.T, method string, path string, body string,
+	headers map[string]string) (int, http.Header, string) {
+	req, err := http.NewRequest(method, getURL(strings.TrimPrefix(path, "/")), strings.NewReader(body))
+	assert.NoError(t, err)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{}
+	if len(Token) > 0 {
+		jar, err := cookiejar.New(nil)
+		assert.NoError(t, err)
+		jar.SetCookies(req.URL, []*http.Cookie{{Name: "token", Value: Token}})
+		client.Jar = jar
+	}
+
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp.StatusCode, resp.Header, string(data)
+}
+
+// importFile posts the file to the import endpoint and returns the decoded
+// response.
+func importFile(t *testing.T, path string, body string) map[string]any {
+	_, _, data := rawRequest(t, http.MethodPost, path, body, nil)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(data), &m))
+	return m
+}
+
 type task struct {
 	date    string
 	title   string
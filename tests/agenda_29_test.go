@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// agendaDays splits a Markdown agenda into the text of its days.
+func agendaDays(body string) map[string]string {
+	days := make(map[string]string)
+	for _, section := range strings.Split(body, "\n## ")[1:] {
+		heading, text, _ := strings.Cut(section, "\n")
+		days[heading] = text
+	}
+	return days
+}
+
+func TestAgendaExport(t *testing.T) {
+	var ids []string
+	for _, task := range []map[string]any{
+		{"date": "20300107", "title": "Планёрка *важно*", "repeat": "w 1,3", "comment": "Повестка:\nитоги недели",
+			"project": "Работа"},
+		{"date": "20300109", "title": "Разовая <b>", "priority": 3},
+		{"date": "20300102", "title": "Полив", "repeat": "d 3"},
+	} {
+		ret, err := postJSON("api/task", task, http.MethodPost)
+		assert.NoError(t, err)
+		ids = append(ids, fmt.Sprint(ret["id"]))
+	}
+
+	status, header, body := rawRequest(t, http.MethodGet,
+		"api/agenda/export?from=20300107&to=20300110&format=markdown", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, header.Get("Content-Type"), "text/markdown")
+	assert.Contains(t, body, "# Agenda 07.01.2030 – 10.01.2030")
+	days := agendaDays(body)
+	assert.Contains(t, days["Monday, 07.01.2030"], "- **Планёрка \\*важно\\*** · Работа · repeats по дням недели: пн, ср\n"+
+		"  > Повестка:\n  > итоги недели\n")
+	assert.Contains(t, days["Tuesday, 08.01.2030"], "- *(projected)* **Полив** · repeats каждые 3 дня\n")
+	assert.Contains(t, days["Wednesday, 09.01.2030"], "- **Разовая \\<b\\>**\n")
+	assert.Contains(t, days["Wednesday, 09.01.2030"], "- *(projected)* **Планёрка")
+	assert.NotContains(t, days["Thursday, 10.01.2030"], "Полив")
+	assert.NotContains(t, days["Thursday, 10.01.2030"], "Планёрка")
+
+	status, header, body = rawRequest(t, http.MethodGet, "api/agenda/export?from=20300107&to=20300109", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, header.Get("Content-Type"), "text/html")
+	assert.Contains(t, body, "<h2>Wednesday, 09.01.2030</h2>")
+	assert.Contains(t, body, "<b>Разовая &lt;b&gt;</b>")
+	assert.Contains(t, body, `<li class="projected"><b>Полив</b>`)
+	assert.Contains(t, body, "<p class=\"comment\">Повестка:\nитоги недели</p>")
+
+	status, _, _ = rawRequest(t, http.MethodGet, "api/agenda/export?from=20300107&to=20310107", "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _, _ = rawRequest(t, http.MethodGet, "api/agenda/export?format=pdf", "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	for _, id := range ids {
+		ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgendaBuckets(t *testing.T) {
+	// 02.01.2030 is a Wednesday; weeks start on Monday by default.
+	expected := map[string]string{
+		"20300101": "overdue",
+		"20300102": "today",
+		"20300103": "tomorrow",
+		"20300106": "this_week",
+		"20300107": "next_week",
+		"20300113": "next_week",
+		"20300114": "later",
+	}
+	var ids []string
+	buckets := make(map[string]string)
+	for date := range expected {
+		ret, err := postJSON("api/task", map[string]any{"date": date, "title": "Корзина " + date}, http.MethodPost)
+		assert.NoError(t, err)
+		ids = append(ids, fmt.Sprint(ret["id"]))
+		buckets[fmt.Sprint(ret["id"])] = date
+	}
+
+	status, _, body := rawRequest(t, http.MethodGet, "api/agenda?date=20300102", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	var ret map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(body), &ret))
+	assert.Equal(t, "20300102", ret["date"])
+	assert.Equal(t, "Monday", ret["week_start"])
+
+	var names []string
+	total := 0
+	counts := ret["counts"].(map[string]any)
+	for _, item := range ret["buckets"].([]any) {
+		bucket := item.(map[string]any)
+		name := fmt.Sprint(bucket["name"])
+		names = append(names, name)
+		tasks := bucket["tasks"].([]any)
+		assert.Len(t, tasks, int(bucket["count"].(float64)))
+		assert.EqualValues(t, bucket["count"], counts[name])
+		total += len(tasks)
+
+		for _, task := range tasks {
+			id := fmt.Sprint(task.(map[string]any)["id"])
+			if date, ok := buckets[id]; ok {
+				assert.Equal(t, expected[date], name, "Задача на "+date)
+				delete(buckets, id)
+			}
+		}
+	}
+	assert.Equal(t, []string{"overdue", "today", "tomorrow", "this_week", "next_week", "later"}, names)
+	assert.EqualValues(t, total, ret["total"])
+	assert.Empty(t, buckets, "Все задачи должны попасть в корзины")
+
+	status, _, _ = rawRequest(t, http.MethodGet, "api/agenda?date=2030-01-02", "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	for _, id := range ids {
+		ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+}
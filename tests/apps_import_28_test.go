@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const importTodoistCSV = "TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE,DURATION,DURATION_UNIT\n" +
+	"task,Оплатить аренду @дом,Каждый месяц,1,1,,,every 5th,en,,30,minute\n" +
+	"note,Через приложение банка,,,,,,,,,,\n" +
+	",,,,,,,,,,,\n" +
+	"task,Спортзал,,4,1,,,\"every mon, wed at 7pm\",en,,,\n" +
+	"task,Странный повтор,,2,1,,,every 3rd friday,en,,,\n" +
+	"task,Поездка,,3,1,,,Jan 5 2031,en,,,\n"
+
+const importTodoistJSON = `{
+	"projects": [{"id": "1", "name": "Дом"}],
+	"items": [
+		{"id": "10", "project_id": "1", "content": "Полить цветы", "priority": 4, "labels": ["цветы"],
+			"due": {"date": "2030-01-03", "string": "every 3 days", "is_recurring": true}},
+		{"id": 11, "content": "Уже сделано", "checked": true},
+		{"id": 12, "content": "Отчёт", "due": {"date": "2030-02-10", "string": "every 3 months", "is_recurring": true}}
+	],
+	"notes": [{"item_id": "10", "content": "Дождевой водой"}]
+}`
+
+const importGoogleTasksJSON = `{"kind": "tasks#taskLists", "items": [{"id": "l1", "title": "Работа", "items": [
+	{"id": "a", "title": "Квартальный отчёт", "notes": "Q3", "status": "needsAction", "due": "2030-03-01T00:00:00.000Z"},
+	{"id": "b", "title": "Старое", "status": "completed"}
+]}]}`
+
+const importTaskwarriorJSON = `{"uuid":"u1","description":"Резервная копия","status":"recurring","due":"20300115T120000Z","recur":"quarterly","priority":"H","tags":["ops"],"until":"20310101T000000Z"}
+{"uuid":"u2","description":"Резервная копия","status":"pending","parent":"u1","due":"20300115T120000Z"}
+{"uuid":"u3","description":"Прочитать главу","status":"pending","annotations":[{"description":"вторая глава"}]}
+`
+
+func importedTasks(ret map[string]any) map[string]map[string]any {
+	tasks := make(map[string]map[string]any)
+	for _, item := range ret["items"].([]any) {
+		if task, ok := item.(map[string]any)["task"].(map[string]any); ok {
+			tasks[fmt.Sprint(task["title"])] = task
+		}
+	}
+	return tasks
+}
+
+func TestAppsImport(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	before, err := count(db)
+	assert.NoError(t, err)
+
+	ret := importFile(t, "api/import/todoist?dry_run=true", importTodoistCSV)
+	assert.Empty(t, ret["error"])
+	assert.EqualValues(t, 4, ret["imported"])
+	tasks := importedTasks(ret)
+	assert.Equal(t, "m 5", tasks["Оплатить аренду"]["repeat"])
+	assert.Equal(t, "Каждый месяц\n\nЧерез приложение банка", tasks["Оплатить аренду"]["comment"])
+	assert.Equal(t, []any{"дом"}, tasks["Оплатить аренду"]["tags"])
+	assert.EqualValues(t, 3, tasks["Оплатить аренду"]["priority"])
+	assert.EqualValues(t, 30, tasks["Оплатить аренду"]["estimate"])
+	assert.Equal(t, "w 1,3", tasks["Спортзал"]["repeat"])
+	assert.Equal(t, "", tasks["Странный повтор"]["repeat"])
+	assert.Equal(t, "20310105", tasks["Поездка"]["date"])
+	warnings := ret["items"].([]any)[2].(map[string]any)["warnings"].([]any)
+	assert.Contains(t, warnings[0], "every 3rd friday")
+
+	ret = importFile(t, "api/import/todoist?dry_run=true", importTodoistJSON)
+	assert.Empty(t, ret["error"])
+	assert.EqualValues(t, 2, ret["imported"])
+	assert.EqualValues(t, 1, ret["skipped"])
+	tasks = importedTasks(ret)
+	assert.Equal(t, "d 3", tasks["Полить цветы"]["repeat"])
+	assert.Equal(t, "Дом", tasks["Полить цветы"]["project"])
+	assert.Equal(t, "Дождевой водой", tasks["Полить цветы"]["comment"])
+	assert.Equal(t, "m 10 2,5,8,11", tasks["Отчёт"]["repeat"])
+
+	ret = importFile(t, "api/import/google-tasks?dry_run=true", importGoogleTasksJSON)
+	assert.Empty(t, ret["error"])
+	assert.EqualValues(t, 1, ret["imported"])
+	assert.EqualValues(t, 1, ret["skipped"])
+	tasks = importedTasks(ret)
+	assert.Equal(t, "20300301", tasks["Квартальный отчёт"]["date"])
+	assert.Equal(t, "Работа", tasks["Квартальный отчёт"]["project"])
+
+	after, err := count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after, "Пробный импорт не должен добавлять задачи")
+
+	ret = importFile(t, "api/import/taskwarrior", importTaskwarriorJSON)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, true, ret["committed"])
+	assert.EqualValues(t, 2, ret["imported"])
+	assert.EqualValues(t, 1, ret["skipped"])
+	tasks = importedTasks(ret)
+	assert.Equal(t, "m 15 1,4,7,10", tasks["Резервная копия"]["repeat"])
+	assert.EqualValues(t, 3, tasks["Резервная копия"]["priority"])
+	assert.Equal(t, "вторая глава", tasks["Прочитать главу"]["comment"])
+
+	after, err = count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before+2, after)
+
+	for _, task := range tasks {
+		ret, err := postJSON("api/task?id="+fmt.Sprint(task["id"]), nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+
+	ret = importFile(t, "api/import/taskwarrior", "{not json")
+	assert.NotEmpty(t, ret["error"])
+}
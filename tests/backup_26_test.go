@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func restoreBackup(t *testing.T, query string, body string) map[string]any {
+	return importFile(t, "api/backup/restore"+query, body)
+}
+
+func TestBackup(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     "20300105",
+		"title":    "Задача для резервной копии",
+		"repeat":   "d 5",
+		"priority": 1,
+		"tags":     []string{"backup"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	status, header, body := rawRequest(t, http.MethodGet, "api/backup", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, header.Get("Content-Disposition"), "attachment")
+
+	var backup map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(body), &backup))
+	assert.EqualValues(t, 1, backup["version"])
+	var saved map[string]any
+	for _, task := range backup["tasks"].([]any) {
+		if task.(map[string]any)["id"] == id {
+			saved = task.(map[string]any)
+		}
+	}
+	assert.Equal(t, "Задача для резервной копии", saved["title"])
+	assert.Equal(t, []any{"backup"}, saved["tags"])
+
+	before, err := count(db)
+	assert.NoError(t, err)
+	assert.Len(t, backup["tasks"], before)
+
+	ret = restoreBackup(t, "?mode=replace", strings.Replace(body, `"version": 1`, `"version": 99`, 1))
+	assert.Equal(t, "backup version 99 is not supported, expected 1", ret["error"])
+	ret = restoreBackup(t, "?mode=wipe", body)
+	assert.NotEmpty(t, ret["error"])
+
+	ret = restoreBackup(t, "?mode=replace&dry_run=true", body)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, true, ret["dry_run"])
+	assert.EqualValues(t, before, ret["tasks"])
+
+	after, err := count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after, "Пробное восстановление не должно менять задачи")
+
+	// Merged tasks get new ids, and records of them follow.
+	merged := `{"version": 1, "tasks": [
+		{"id": "1000", "date": "20200101", "title": "Восстановленная задача", "repeat": "", "tags": ["old"]},
+		{"id": "1001", "date": "20200101", "title": ""}
+	]}`
+	ret = restoreBackup(t, "", merged)
+	assert.Contains(t, ret["error"], "task #2")
+
+	impossible := `{"version": 1, "tasks": [{"id": "1000", "date": "20200101", "title": "Тридцатое февраля", "repeat": "m 30 2"}]}`
+	ret = restoreBackup(t, "?dry_run=true", impossible)
+	assert.Contains(t, ret["error"], "task #1")
+
+	merged = `{"version": 1,
+		"tasks": [{"id": "1000", "date": "20200101", "title": "Восстановленная задача", "tags": ["old"]}],
+		"reminders": [{"id": "1", "task_id": "1000", "time": "08:30"}, {"id": "2", "task_id": "999", "time": "08:30"}],
+		"history": [{"task_id": "1000", "task_title": "Восстановленная задача", "event": "postponed",
+			"from_date": "20191231", "to_date": "20200101", "created_at": "2019-12-31T10:00:00Z"}]}`
+	ch, cancel := openStream(t, "")
+	defer cancel()
+	ret = restoreBackup(t, "?mode=merge", merged)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, "merge", ret["mode"])
+	assert.EqualValues(t, 1, ret["tasks"])
+	assert.EqualValues(t, 1, ret["reminders"])
+	assert.EqualValues(t, 1, ret["history"])
+	assert.Len(t, ret["warnings"], 1)
+
+	after, err = count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, after)
+
+	ret, err = postJSON("api/tasks?search="+url.QueryEscape("Восстановленная задача"), nil, http.MethodGet)
+	assert.NoError(t, err)
+	tasks := ret["tasks"].([]any)
+	assert.Len(t, tasks, 1)
+	restored := tasks[0].(map[string]any)
+	restoredID := fmt.Sprint(restored["id"])
+	assert.NotEqual(t, "1000", restoredID)
+	assert.Equal(t, "20200101", restored["date"], "Восстановление сохраняет дату просроченной задачи")
+	assert.Equal(t, "task.created", waitStreamEvent(t, ch, restoredID).event)
+
+	ret, err = postJSON("api/reminders?id="+restoredID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["reminders"], 1)
+
+	// Reminders that were due before the restore are not sent again.
+	var sent int
+	err = db.Get(&sent, `SELECT count(d.id) FROM reminder_deliveries d JOIN reminders r ON r.id = d.reminder_id
+		WHERE r.task_id = ? AND d.occurrence = '20200101' AND d.status = 'sent'`, restoredID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	for _, taskID := range []string{id, restoredID} {
+		ret, err := postJSON("api/task?id="+taskID, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+	for waitStreamEvent(t, ch, restoredID).event != "task.deleted" {
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bulk(t *testing.T, values map[string]any) map[string]any {
+	ret, err := postJSON("api/tasks/bulk", values, http.MethodPost)
+	assert.NoError(t, err)
+	return ret
+}
+
+func TestBulkTasks(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	first := addTask(t, task{date: now.Format(`20060102`), title: "Первая"})
+	second := addTask(t, task{date: now.Format(`20060102`), title: "Вторая", repeat: "d 2"})
+	missing := "999999999"
+
+	tbl := []map[string]any{
+		{"ids": []string{}, "operation": "delete"},
+		{"ids": []string{first}, "operation": "archive"},
+		{"ids": []string{first}, "operation": "move", "date": "tomorrow"},
+		{"ids": []string{first}, "operation": "postpone", "days": 0},
+		{"ids": []string{first}, "operation": "tag"},
+		{"ids": []any{1.9}, "operation": "delete"},
+	}
+	for _, v := range tbl {
+		ret := bulk(t, v)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для операции %v", v)
+	}
+
+	ret := bulk(t, map[string]any{
+		"ids":       []string{first, second, missing},
+		"operation": "postpone",
+		"days":      3,
+		"atomic":    true,
+	})
+	assert.Equal(t, false, ret["committed"])
+	assert.Equal(t, float64(1), ret["failed"])
+
+	var task Task
+	err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, first)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Format(`20060102`), task.Date)
+
+	ret = bulk(t, map[string]any{
+		"ids":       []string{first, second, missing},
+		"operation": "postpone",
+		"days":      3,
+	})
+	assert.Equal(t, true, ret["committed"])
+	assert.Equal(t, float64(2), ret["succeeded"])
+	results := ret["results"].([]any)
+	assert.Len(t, results, 3)
+	assert.Equal(t, false, results[2].(map[string]any)["ok"])
+	assert.NotEmpty(t, results[2].(map[string]any)["error"])
+
+	for _, id := range []string{first, second} {
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), task.Date)
+	}
+
+	ret = bulk(t, map[string]any{
+		"ids":       []any{first, second},
+		"operation": "tag",
+		"tags":      []string{"bulk"},
+	})
+	assert.Equal(t, float64(2), ret["succeeded"])
+	body, err := postJSON("api/task?id="+first, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"bulk"}, body["tags"])
+
+	ret = bulk(t, map[string]any{
+		"ids":       []string{first, second},
+		"operation": "complete",
+	})
+	assert.Equal(t, float64(2), ret["succeeded"])
+	notFoundTask(t, first)
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, second)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 5).Format(`20060102`), task.Date)
+
+	ret = bulk(t, map[string]any{
+		"ids":       []string{second},
+		"operation": "delete",
+	})
+	assert.Equal(t, float64(1), ret["succeeded"])
+	notFoundTask(t, second)
+}
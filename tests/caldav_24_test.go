@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func davTodo(uid string, props ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\n" +
+		strings.Join(props, "\r\n") + "\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+}
+
+func TestCalDAV(t *testing.T) {
+	status, header, _ := rawRequest(t, http.MethodOptions, "caldav/tasks/", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, header.Get("DAV"), "calendar-access")
+
+	status, _, body := rawRequest(t, "PROPFIND", "caldav/", `<?xml version="1.0"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:current-user-principal/><c:calendar-home-set/></d:prop>
+</d:propfind>`, map[string]string{"Depth": "0"})
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.Contains(t, body, "<C:calendar-home-set><D:href>/caldav/</D:href></C:calendar-home-set>")
+
+	now := time.Now()
+	date := now.AddDate(0, 0, 3).Format(`20060102`)
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     date,
+		"title":    "Задача из API",
+		"repeat":   "d 2",
+		"project":  "CalDAV",
+		"priority": 3,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	apiID := fmt.Sprint(ret["id"])
+	apiHref := "/caldav/tasks/task-" + apiID + ".ics"
+
+	propfind := `<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/">
+  <d:prop><d:resourcetype/><d:getetag/><cs:getctag/></d:prop>
+</d:propfind>`
+	status, _, body = rawRequest(t, "PROPFIND", "caldav/tasks/", propfind, map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.Contains(t, body, "<C:calendar/>")
+	assert.Contains(t, body, "<D:href>"+apiHref+"</D:href>")
+	ctag := between(body, "<CS:getctag>", "</CS:getctag>")
+	assert.NotEmpty(t, ctag)
+
+	status, header, body = rawRequest(t, http.MethodGet, apiHref, "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, header.Get("Content-Type"), "text/calendar")
+	etag := header.Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Contains(t, body, "SUMMARY:Задача из API\r\n")
+	assert.Contains(t, body, "DUE;VALUE=DATE:"+date+"\r\n")
+	assert.Contains(t, body, "RRULE:FREQ=DAILY;INTERVAL=2\r\n")
+
+	status, _, _ = rawRequest(t, http.MethodGet, apiHref, "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, status)
+
+	// Edits keep the project, which has no iCalendar form.
+	edited := davTodo("task-"+apiID+"@go-task-manager", "SUMMARY:Изменено в клиенте",
+		"DUE;VALUE=DATE:"+date, "RRULE:FREQ=WEEKLY;BYDAY=MO", "CATEGORIES:sync")
+	status, _, _ = rawRequest(t, http.MethodPut, apiHref, edited, map[string]string{"If-Match": `"stale"`})
+	assert.Equal(t, http.StatusPreconditionFailed, status)
+	status, _, _ = rawRequest(t, http.MethodPut, apiHref, edited, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusNoContent, status)
+
+	task, err := postJSON("api/task?id="+apiID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Изменено в клиенте", task["title"])
+	assert.Equal(t, "w 1", task["repeat"])
+	assert.Equal(t, "CalDAV", task["project"])
+	assert.Equal(t, []any{"sync"}, task["tags"])
+
+	status, header, _ = rawRequest(t, http.MethodGet, apiHref, "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEqual(t, etag, header.Get("ETag"))
+
+	// Validation and repeat rules of the API still apply.
+	for _, todo := range []string{
+		davTodo("caldav-test-invalid", "DUE;VALUE=DATE:"+date),
+		davTodo("caldav-test-invalid", "SUMMARY:Ограниченный", "RRULE:FREQ=DAILY;COUNT=3"),
+		strings.ReplaceAll(davTodo("caldav-test-invalid", "SUMMARY:Событие"), "VTODO", "VEVENT"),
+	} {
+		status, _, _ = rawRequest(t, http.MethodPut, "caldav/tasks/caldav-test-invalid.ics", todo, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	}
+
+	newHref := "/caldav/tasks/caldav-test-1.ics"
+	created := davTodo("caldav-test-1", "SUMMARY:Создано в клиенте", "DESCRIPTION:Описание",
+		"DUE;VALUE=DATE:"+date, "PRIORITY:1")
+	status, _, _ = rawRequest(t, http.MethodPut, newHref, created, map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusCreated, status)
+	status, _, _ = rawRequest(t, http.MethodPut, newHref, created, map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusPreconditionFailed, status)
+
+	status, _, body = rawRequest(t, "REPORT", "caldav/tasks/", `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <d:href>`+newHref+`</d:href>
+  <d:href>/caldav/tasks/caldav-test-missing.ics</d:href>
+</c:calendar-multiget>`, map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.Contains(t, body, "UID:caldav-test-1&#xD;&#xA;")
+	assert.Contains(t, body, "SUMMARY:Создано в клиенте")
+	assert.Contains(t, body, "PRIORITY:1&#xD;&#xA;")
+	assert.Contains(t, body, "<D:href>/caldav/tasks/caldav-test-missing.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+
+	status, _, body = rawRequest(t, "REPORT", "caldav/tasks/", `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/></d:prop>
+  <c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO">
+    <c:time-range start="`+date+`T000000Z" end="`+now.AddDate(0, 0, 4).Format(`20060102`)+`T000000Z"/>
+  </c:comp-filter></c:comp-filter></c:filter>
+</c:calendar-query>`, map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.Contains(t, body, "<D:href>"+newHref+"</D:href>")
+	assert.Contains(t, body, "<D:href>"+apiHref+"</D:href>")
+
+	status, _, body = rawRequest(t, "PROPFIND", "caldav/tasks/", propfind, map[string]string{"Depth": "0"})
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.NotEqual(t, ctag, between(body, "<CS:getctag>", "</CS:getctag>"))
+
+	// Completing a one-off to-do removes the task.
+	ret, err = postJSON("api/tasks?search="+url.QueryEscape("Создано в клиенте"), nil, http.MethodGet)
+	assert.NoError(t, err)
+	tasks := ret["tasks"].([]any)
+	assert.Len(t, tasks, 1)
+	newID := fmt.Sprint(tasks[0].(map[string]any)["id"])
+
+	status, _, _ = rawRequest(t, http.MethodPut, newHref,
+		davTodo("caldav-test-1", "SUMMARY:Создано в клиенте", "STATUS:COMPLETED"), nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	ret, err = postJSON("api/task?id="+newID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	status, _, _ = rawRequest(t, http.MethodGet, newHref, "", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _, _ = rawRequest(t, http.MethodDelete, apiHref, "", nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _, _ = rawRequest(t, http.MethodGet, apiHref, "", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func between(s string, start string, end string) string {
+	idx := strings.Index(s, start)
+	if idx < 0 {
+		return ""
+	}
+	s = s[idx+len(start):]
+	if idx = strings.Index(s, end); idx < 0 {
+		return ""
+	}
+	return s[:idx]
+}
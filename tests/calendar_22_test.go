@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getCalendar(t *testing.T, token string) (int, string) {
+	resp, err := http.Get(getURL("api/calendar.ics?token=" + url.QueryEscape(token)))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	if resp.StatusCode == http.StatusOK {
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/calendar")
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestCalendarFeed(t *testing.T) {
+	for _, v := range []map[string]any{
+		{"name": ""},
+		{"name": "Задачи", "kind": "journal"},
+	} {
+		ret, err := postJSON("api/calendar/feed", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для %v", v)
+	}
+
+	now := time.Now()
+	today := now.Format(`20060102`)
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     today,
+		"title":    "Планёрка",
+		"repeat":   "w 1,3",
+		"project":  "Календарь",
+		"priority": 3,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	weekly := fmt.Sprint(ret["id"])
+	title := "Очень длинное название задачи, чтобы строка календаря была перенесена; и со спецсимволами"
+	oneOff := addTask(t, task{date: today, title: title, comment: "Первая строка\nвторая"})
+
+	ret, err = postJSON("api/calendar/feed", map[string]any{"name": "Все задачи"}, http.MethodPost)
+	assert.NoError(t, err)
+	feedID := fmt.Sprint(ret["id"])
+	token := ret["token"].(string)
+	assert.Len(t, token, 64)
+	assert.Contains(t, ret["url"], "/api/calendar.ics?token="+token)
+
+	status, body := getCalendar(t, token)
+	assert.Equal(t, http.StatusOK, status)
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "Строка длиннее 75 байт: %q", line)
+		assert.NotContains(t, line, "\n")
+	}
+	body = strings.ReplaceAll(body, "\r\n ", "")
+	assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+	assert.Contains(t, body, "UID:task-"+weekly+"@")
+	assert.Contains(t, body, "DTSTART;VALUE=DATE:"+today+"\r\n")
+	assert.Contains(t, body, "RRULE:FREQ=WEEKLY;BYDAY=MO,WE\r\n")
+	assert.Contains(t, body, "PRIORITY:1\r\n")
+	assert.Contains(t, body, "CATEGORIES:Календарь\r\n")
+	assert.Contains(t, body, "UID:task-"+oneOff+"@")
+	assert.Contains(t, body, `SUMMARY:Очень длинное название задачи\, чтобы строка календаря была перенесена\; и со спецсимволами`)
+	assert.Contains(t, body, `DESCRIPTION:Первая строка\nвторая`)
+
+	ret, err = postJSON("api/calendar/feed", map[string]any{
+		"name":    "Календарь",
+		"kind":    "todo",
+		"project": "Календарь",
+		"expand":  true,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	todoID := fmt.Sprint(ret["id"])
+	todoToken := ret["token"].(string)
+
+	status, body = getCalendar(t, todoToken)
+	assert.Equal(t, http.StatusOK, status)
+	body = strings.ReplaceAll(body, "\r\n ", "")
+	assert.NotContains(t, body, "RRULE")
+	assert.NotContains(t, body, "UID:task-"+oneOff+"@")
+	assert.Contains(t, body, "BEGIN:VTODO")
+	assert.Contains(t, body, "UID:task-"+weekly+"-"+today+"@")
+	// Two occurrences a week for about 90 days.
+	occurrences := strings.Count(body, "BEGIN:VTODO")
+	assert.GreaterOrEqual(t, occurrences, 24)
+	assert.LessOrEqual(t, occurrences, 28)
+
+	ret, err = postJSON("api/calendar/feeds", nil, http.MethodGet)
+	assert.NoError(t, err)
+	feeds := ret["feeds"].([]any)
+	assert.Len(t, feeds, 2)
+	assert.NotEmpty(t, feeds[0].(map[string]any)["accessed_at"])
+	assert.Nil(t, feeds[0].(map[string]any)["token"])
+
+	ret, err = postJSON("api/calendar/feed/token?id="+feedID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	newToken := ret["token"].(string)
+	assert.NotEqual(t, token, newToken)
+
+	status, _ = getCalendar(t, token)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = getCalendar(t, newToken)
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = getCalendar(t, "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	for _, id := range []string{feedID, todoID} {
+		ret, err = postJSON("api/calendar/feed?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+	}
+	status, _ = getCalendar(t, newToken)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	for _, id := range []string{weekly, oneOff} {
+		_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getCalendarView(t *testing.T, query string) (int, map[string]any) {
+	status, _, body := rawRequest(t, http.MethodGet, "api/calendar"+query, "", nil)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(body), &m))
+	return status, m
+}
+
+func TestCalendarView(t *testing.T) {
+	var ids []string
+	for _, task := range []map[string]any{
+		{"date": "20300107", "title": "Планёрка", "repeat": "w 1,3"},
+		{"date": "20300109", "title": "Разовая"},
+	} {
+		ret, err := postJSON("api/task", task, http.MethodPost)
+		assert.NoError(t, err)
+		ids = append(ids, fmt.Sprint(ret["id"]))
+	}
+
+	status, ret := getCalendarView(t, "?from=20300107&to=20300110")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "20300107", ret["from"])
+	assert.Equal(t, "20300110", ret["to"])
+	assert.Equal(t, false, ret["truncated"])
+
+	occurrences := make(map[string][]string)
+	for _, item := range ret["occurrences"].([]any) {
+		o := item.(map[string]any)
+		if o["task_id"] == ids[0] || o["task_id"] == ids[1] {
+			occurrences[fmt.Sprint(o["task_id"])] = append(occurrences[fmt.Sprint(o["task_id"])],
+				fmt.Sprintf("%s %v", o["date"], o["projected"]))
+		}
+	}
+	assert.Equal(t, []string{"20300107 false", "20300109 true"}, occurrences[ids[0]])
+	assert.Equal(t, []string{"20300109 false"}, occurrences[ids[1]])
+
+	// Stored tasks keep their own date; projections don't change them.
+	for _, item := range ret["tasks"].([]any) {
+		task := item.(map[string]any)
+		if task["id"] == ids[0] {
+			assert.Equal(t, "20300107", task["date"])
+		}
+	}
+
+	// Daily tasks over a quarter exceed the cap on occurrences.
+	for i := 0; i < 11; i++ {
+		ret, err := postJSON("api/task", map[string]any{
+			"date": "20300101", "title": fmt.Sprintf("Каждый день %d", i), "repeat": "d 1",
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		ids = append(ids, fmt.Sprint(ret["id"]))
+	}
+	status, ret = getCalendarView(t, "?from=20300101&to=20300401")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, ret["truncated"])
+	assert.Len(t, ret["occurrences"], 1000)
+
+	status, ret = getCalendarView(t, "?from=20300110&to=20300101")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.NotEmpty(t, ret["error"])
+	status, _ = getCalendarView(t, "?from=20300101&to=20301231")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	for _, id := range ids {
+		ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const importCSVFile = "Название;Срок;Приоритет;Метки;Лишнее\r\n" +
+	"Купить корм;20300110;1;дом кот;x\r\n" +
+	";;;;\r\n" +
+	"Неверная дата;2030-01-10;;;\r\n" +
+	"Неверный приоритет;20300110;высокий;;\r\n" +
+	"\"Многострочная;\nзаметка\";20300111;;;\r\n"
+
+func importCSV(t *testing.T, query string, body string) map[string]any {
+	return importFile(t, "api/import/csv"+query, body)
+}
+
+func TestCSV(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     "20300105",
+		"title":    "=Экспорт, \"CSV\"",
+		"comment":  "Две\nстроки",
+		"repeat":   "d 3",
+		"priority": 2,
+		"tags":     []string{"csv"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	exportID := fmt.Sprint(ret["id"])
+
+	status, header, body := rawRequest(t, http.MethodGet, "api/tasks?format=csv&search="+
+		url.QueryEscape("Экспорт"), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, header.Get("Content-Type"), "text/csv")
+	assert.True(t, strings.HasPrefix(body, "\xef\xbb\xbfid,date,title,comment,repeat,repeat_text,"))
+	assert.Contains(t, body, exportID+`,20300105,"'=Экспорт, ""CSV""","Две`+"\n"+`строки",d 3,каждые 3 дня,`)
+
+	status, _, _ = rawRequest(t, http.MethodGet, "api/tasks?format=xml", "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// The export imports back as the same task.
+	ret = importCSV(t, "?dry_run=1", body)
+	assert.Empty(t, ret["error"])
+	assert.EqualValues(t, 1, ret["imported"])
+	task := ret["items"].([]any)[0].(map[string]any)["task"].(map[string]any)
+	assert.Equal(t, "=Экспорт, \"CSV\"", task["title"])
+	assert.Equal(t, "Две\nстроки", task["comment"])
+	assert.Equal(t, "d 3", task["repeat"])
+
+	ret, err = postJSON("api/task?id="+exportID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	ret = importCSV(t, "", importCSVFile)
+	assert.Equal(t, "no column is mapped to title", ret["error"])
+	ret = importCSV(t, "?mapping="+url.QueryEscape(`{"Нет такой":"title"}`), importCSVFile)
+	assert.NotEmpty(t, ret["error"])
+
+	mapping := "?mapping=" + url.QueryEscape(`{"Название":"title","Срок":"date","Приоритет":"priority","Метки":"tags"}`)
+
+	before, err := count(db)
+	assert.NoError(t, err)
+
+	ret = importCSV(t, mapping+"&dry_run=true", importCSVFile)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, true, ret["dry_run"])
+	assert.EqualValues(t, 2, ret["imported"])
+	assert.EqualValues(t, 1, ret["skipped"])
+	assert.EqualValues(t, 2, ret["failed"])
+	assert.Equal(t, []any{4.0, 5.0}, ret["failed_lines"])
+	assert.Equal(t, []any{`column "Лишнее" is ignored`}, ret["warnings"])
+	first := ret["items"].([]any)[0].(map[string]any)["task"].(map[string]any)
+	assert.Equal(t, []any{"дом", "кот"}, first["tags"])
+	assert.EqualValues(t, 1, first["priority"])
+
+	after, err := count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after, "Пробный импорт не должен добавлять задачи")
+
+	ret = importCSV(t, mapping, importCSVFile)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, true, ret["committed"])
+	assert.EqualValues(t, 2, ret["imported"])
+
+	after, err = count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before+2, after)
+
+	for _, item := range ret["items"].([]any) {
+		task, ok := item.(map[string]any)["task"].(map[string]any)
+		if !ok || item.(map[string]any)["status"] != "imported" {
+			continue
+		}
+		ret, err := postJSON("api/task?id="+fmt.Sprint(task["id"]), nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+}
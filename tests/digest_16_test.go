@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestPreview(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	date := today.AddDate(0, 0, 60)
+
+	ids := []string{
+		addTask(t, task{date: date.AddDate(0, 0, -1).Format(`20060102`), title: "Digest просрочено"}),
+		addTask(t, task{date: date.Format(`20060102`), title: "Digest сегодня <b>"}),
+		addTask(t, task{date: date.AddDate(0, 0, 3).Format(`20060102`), title: "Digest через три дня"}),
+		addTask(t, task{date: date.AddDate(0, 0, 4).Format(`20060102`), title: "Digest через четыре дня"}),
+	}
+	ret, err := postJSON("api/task", map[string]any{
+		"date":    date.Format(`20060102`),
+		"title":   "Digest проект",
+		"project": "Работа",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	ids = append(ids, fmt.Sprint(ret["id"]))
+
+	body, err := requestJSON("api/digest/preview?format=text&date="+date.Format(`20060102`), nil, http.MethodGet)
+	assert.NoError(t, err)
+	text := string(body)
+	assert.Contains(t, text, "Agenda for "+date.Format("02.01.2006"))
+	assert.Contains(t, text, "Digest просрочено")
+	assert.Contains(t, text, "Digest через три дня")
+	assert.NotContains(t, text, "Digest через четыре дня")
+	assert.Contains(t, text, "Работа:")
+	assert.Less(t, strings.Index(text, "Overdue"), strings.Index(text, "Digest просрочено"))
+	assert.Less(t, strings.Index(text, "Today"), strings.Index(text, "Digest сегодня"))
+	todaySection := text[strings.Index(text, "Today"):strings.Index(text, "Next 3 days")]
+	assert.Less(t, strings.Index(todaySection, "Работа:"), strings.Index(todaySection, "No project"))
+
+	body, err = requestJSON("api/digest/preview?date="+date.Format(`20060102`), nil, http.MethodGet)
+	assert.NoError(t, err)
+	html := string(body)
+	assert.Contains(t, html, "<h2>Today")
+	assert.Contains(t, html, "Digest сегодня &lt;b&gt;")
+
+	ret, err = postJSON("api/digest/preview?format=pdf", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	for _, id := range ids {
+		_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type outboxEvent struct {
+	Name       string `db:"name"`
+	Dispatched bool   `db:"dispatched"`
+}
+
+func taskEvents(t *testing.T, after int64) []outboxEvent {
+	db := openDB(t)
+	defer db.Close()
+
+	var events []outboxEvent
+	err := db.Select(&events, `SELECT name, dispatched_at IS NOT NULL AS dispatched
+		FROM event_outbox WHERE id > ? ORDER BY id`, after)
+	assert.NoError(t, err)
+	return events
+}
+
+func TestTaskEventsOutbox(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	var last int64
+	err := db.Get(&last, `SELECT coalesce(max(id), 0) FROM event_outbox`)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	id := addTask(t, task{date: now.Format(`20060102`), title: "Проверить события"})
+	postpone(t, id, "+1d")
+
+	ret, err := postJSON("api/tasks/bulk", map[string]any{
+		"ids":       []any{id, "999999999"},
+		"operation": "complete",
+		"atomic":    true,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, false, ret["committed"])
+
+	_, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+
+	var events []outboxEvent
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		events = taskEvents(t, last)
+		if len(events) == 3 && events[2].Dispatched {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.Name
+		assert.True(t, e.Dispatched, "Событие %s должно быть доставлено", e.Name)
+	}
+	assert.Equal(t, []string{"task.created", "task.rescheduled", "task.completed"}, names)
+}
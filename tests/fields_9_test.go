@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addField(t *testing.T, values map[string]any) string {
+	ret, err := postJSON("api/field", values, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotNil(t, ret["id"])
+	return fmt.Sprint(ret["id"])
+}
+
+func TestCustomFields(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	ticket := "ticket" + suffix
+	cost := "cost" + suffix
+	stage := "stage" + suffix
+
+	ticketID := addField(t, map[string]any{"name": ticket, "type": "text"})
+	costID := addField(t, map[string]any{"name": cost, "type": "number"})
+	stageID := addField(t, map[string]any{"name": stage, "type": "enum", "options": []string{"new", "done"}})
+
+	tbl := []map[string]any{
+		{"name": "", "type": "text"},
+		{"name": "bad name", "type": "text"},
+		{"name": "x" + suffix, "type": "colour"},
+		{"name": "y" + suffix, "type": "enum"},
+		{"name": ticket, "type": "text"},
+	}
+	for _, v := range tbl {
+		ret, err := postJSON("api/field", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для поля %v", v)
+	}
+
+	ret, err := postJSON("api/task", map[string]any{
+		"title":  "Ответить клиенту",
+		"fields": map[string]any{ticket: "SUP-1", cost: "12.50", stage: "new"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	tbl = []map[string]any{
+		{cost: "дорого"},
+		{stage: "archived"},
+		{"unknown" + suffix: "value"},
+	}
+	for _, v := range tbl {
+		ret, err := postJSON("api/task", map[string]any{
+			"title":  "Ответить клиенту",
+			"fields": v,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для значений %v", v)
+	}
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{ticket: "SUP-1", cost: "12.5", stage: "new"}, ret["fields"])
+
+	ret, err = postJSON("api/tasks?field."+cost+"=12.500", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["tasks"], 1)
+
+	ret, err = postJSON("api/tasks?field."+stage+"=done", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["tasks"], 0)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"id":    id,
+		"date":  time.Now().Format(`20060102`),
+		"title": "Ответить клиенту повторно",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["fields"], 3)
+
+	ret, err = postJSON("api/field", map[string]any{
+		"id":      stageID,
+		"name":    stage,
+		"type":    "enum",
+		"options": []string{"done"},
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"dropped_values": 1.0}, ret)
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{ticket: "SUP-1", cost: "12.5"}, ret["fields"])
+
+	for _, fieldID := range []string{ticketID, costID, stageID} {
+		ret, err = postJSON("api/field?id="+fieldID, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["fields"])
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const importCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:import-1@example.com\r\n" +
+	"SUMMARY:Полить цветы\\, кактус\r\n" +
+	"DESCRIPTION:Каждые три дня\\nне забыть\r\n" +
+	"DUE;VALUE=DATE:20300105\r\n" +
+	"RRULE:FREQ=DAILY;INTERVAL=3\r\n" +
+	"CATEGORIES:дом\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:import-2@example.com\r\n" +
+	"SUMMARY:Созвон по импорту\r\n" +
+	"DTSTART;VALUE=DATE:20300107\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,TH\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:import-3@example.com\r\n" +
+	"SUMMARY:Ограниченный повтор\r\n" +
+	"DTSTART;VALUE=DATE:20300108\r\n" +
+	"RRULE:FREQ=DAILY;COUNT=5\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:import-4@example.com\r\n" +
+	"SUMMARY:Уже сделано\r\n" +
+	"STATUS:COMPLETED\r\n" +
+	"END:VTODO\r\n" +
+	"END:VCALENDAR\r\n"
+
+func importICS(t *testing.T, query string, body string) map[string]any {
+	return importFile(t, "api/import/ics"+query, body)
+}
+
+func TestImportICS(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret := importICS(t, "", "BEGIN:VCALENDAR\r\nSUMMARY\r\n")
+	assert.NotEmpty(t, ret["error"])
+
+	before, err := count(db)
+	assert.NoError(t, err)
+
+	ret = importICS(t, "?dry_run=true", importCalendar)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, true, ret["dry_run"])
+	assert.Equal(t, false, ret["committed"])
+	assert.EqualValues(t, 3, ret["imported"])
+	assert.EqualValues(t, 1, ret["skipped"])
+
+	items := ret["items"].([]any)
+	assert.Len(t, items, 4)
+	first := items[0].(map[string]any)
+	assert.Equal(t, "import-1@example.com", first["ref"])
+	firstTask := first["task"].(map[string]any)
+	assert.Equal(t, "Полить цветы, кактус", firstTask["title"])
+	assert.Equal(t, "Каждые три дня\nне забыть", firstTask["comment"])
+	assert.Equal(t, "d 3", firstTask["repeat"])
+	limited := items[2].(map[string]any)
+	assert.NotEmpty(t, limited["warnings"])
+	assert.Equal(t, "skipped", items[3].(map[string]any)["status"])
+
+	after, err := count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after, "Пробный импорт не должен добавлять задачи")
+
+	ret = importICS(t, "", importCalendar)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, true, ret["committed"])
+	assert.EqualValues(t, 3, ret["imported"])
+
+	after, err = count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before+3, after)
+
+	ids := []string{}
+	for _, item := range ret["items"].([]any) {
+		task, ok := item.(map[string]any)["task"].(map[string]any)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprint(task["id"])
+		assert.NotEmpty(t, id)
+		ids = append(ids, id)
+	}
+	assert.Len(t, ids, 3)
+
+	task, err := postJSON("api/task?id="+ids[1], nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Созвон по импорту", task["title"])
+	assert.Equal(t, "w 1,4", task["repeat"])
+
+	for _, id := range ids {
+		ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+	impossible := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:import-5@example.com\r\n" +
+		"SUMMARY:Тридцатое февраля\r\nDUE;VALUE=DATE:20300130\r\n" +
+		"RRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	ret = importICS(t, "?dry_run=true", impossible)
+	assert.Empty(t, ret["error"])
+	items = ret["items"].([]any)
+	assert.Len(t, items, 1)
+	assert.NotEmpty(t, items[0].(map[string]any)["warnings"])
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func dialLive(t *testing.T) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(getURL("api/live"), "http")
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	return conn
+}
+
+func sendLive(t *testing.T, conn *websocket.Conn, msg map[string]any) map[string]any {
+	assert.NoError(t, conn.WriteJSON(msg))
+	for {
+		reply := readLive(t, conn)
+		if reply["type"] != "event" {
+			assert.Equal(t, msg["id"], reply["id"])
+			return reply
+		}
+	}
+}
+
+func readLive(t *testing.T, conn *websocket.Conn) map[string]any {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg map[string]any
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Сообщение не получено: %v", err)
+	}
+	return msg
+}
+
+// readLiveEvent skips events of other tasks, which earlier tests may still be
+// producing.
+func readLiveEvent(t *testing.T, conn *websocket.Conn, id string) map[string]any {
+	for {
+		msg := readLive(t, conn)
+		if msg["type"] == "event" && msg["task"].(map[string]any)["id"] == id {
+			return msg
+		}
+	}
+}
+
+func TestLiveTasks(t *testing.T) {
+	alice := dialLive(t)
+	defer alice.Close()
+	bob := dialLive(t)
+	defer bob.Close()
+
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	for _, msg := range []map[string]any{
+		{"id": "1", "type": "explode"},
+		{"id": "2", "type": "create", "task": map[string]any{"date": today}},
+		{"id": "3", "type": "complete", "task_id": "abc"},
+		{"id": "4", "type": "subscribe", "from": "tomorrow"},
+		{"id": "5", "type": "unsubscribe", "subscription": 100},
+	} {
+		reply := sendLive(t, alice, msg)
+		assert.Equal(t, "error", reply["type"], "Ожидается ошибка для %v", msg)
+		assert.NotEmpty(t, reply["error"])
+	}
+
+	reply := sendLive(t, bob, map[string]any{"id": "s1", "type": "subscribe", "project": "Сайт"})
+	assert.Equal(t, "ack", reply["type"])
+	assert.Equal(t, float64(1), reply["subscription"])
+	reply = sendLive(t, alice, map[string]any{"id": "s1", "type": "subscribe", "from": today, "to": today})
+	assert.Equal(t, "ack", reply["type"])
+
+	reply = sendLive(t, alice, map[string]any{"id": "c1", "type": "create", "task": map[string]any{
+		"date":    today,
+		"title":   "Обновить сайт",
+		"project": "Сайт",
+	}})
+	assert.Equal(t, "ack", reply["type"])
+	id := reply["task_id"].(string)
+	assert.NotEmpty(t, id)
+
+	e := readLiveEvent(t, bob, id)
+	assert.Equal(t, "event", e["type"])
+	assert.Equal(t, "task.created", e["event"])
+	assert.Equal(t, float64(1), e["subscription"])
+	assert.Equal(t, id, e["task"].(map[string]any)["id"])
+
+	// A task outside of Bob's project is not sent to him.
+	other := addTask(t, task{date: today, title: "Купить хлеб"})
+	e = readLiveEvent(t, alice, other)
+	assert.Equal(t, "task.created", e["event"])
+	assert.Equal(t, other, e["task"].(map[string]any)["id"])
+
+	reply = sendLive(t, bob, map[string]any{"id": "u1", "type": "update", "task": map[string]any{
+		"id":      id,
+		"date":    today,
+		"title":   "Обновить сайт до 2.0",
+		"project": "Сайт",
+	}})
+	assert.Equal(t, "ack", reply["type"])
+	e = readLiveEvent(t, alice, id)
+	assert.Equal(t, "task.updated", e["event"])
+	assert.Equal(t, "Обновить сайт до 2.0", e["task"].(map[string]any)["title"])
+
+	reply = sendLive(t, bob, map[string]any{"id": "d1", "type": "delete", "task_id": other})
+	assert.Equal(t, "ack", reply["type"])
+	e = readLiveEvent(t, alice, other)
+	assert.Equal(t, "task.deleted", e["event"])
+	assert.Equal(t, other, e["task"].(map[string]any)["id"])
+
+	reply = sendLive(t, alice, map[string]any{"id": "d2", "type": "complete", "task_id": id})
+	assert.Equal(t, "ack", reply["type"])
+	e = readLiveEvent(t, bob, id)
+	assert.Equal(t, "task.completed", e["event"])
+	assert.Equal(t, id, e["task"].(map[string]any)["id"])
+
+	reply = sendLive(t, bob, map[string]any{"id": "x1", "type": "unsubscribe", "subscription": 1})
+	assert.Equal(t, "ack", reply["type"])
+
+	ret, err := postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
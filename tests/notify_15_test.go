@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type received struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func standInServer(t *testing.T, status int) (*httptest.Server, chan received) {
+	requests := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{path: r.URL.Path, header: r.Header, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+// smtpStandIn accepts a single SMTP session and returns the message data.
+func smtpStandIn(t *testing.T) (string, chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				fmt.Fprint(conn, "250-localhost\r\n250 8BITMIME\r\n")
+			case strings.HasPrefix(cmd, "DATA"):
+				fmt.Fprint(conn, "354 go ahead\r\n")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				fmt.Fprint(conn, "250 queued\r\n")
+			case strings.HasPrefix(cmd, "QUIT"):
+				fmt.Fprint(conn, "221 bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func addChannel(t *testing.T, channel map[string]any) string {
+	ret, err := postJSON("api/notify/channel", channel, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	return fmt.Sprint(ret["id"])
+}
+
+func testChannel(t *testing.T, id string) map[string]any {
+	ret, err := postJSON("api/notify/test?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	return ret
+}
+
+func TestNotificationChannels(t *testing.T) {
+	for _, v := range []map[string]any{
+		{"kind": "sms", "url": "http://localhost"},
+		{"kind": "webhook", "url": "ftp://localhost"},
+		{"kind": "webhook"},
+		{"kind": "push", "url": "http://localhost", "format": "pushover"},
+		{"kind": "email", "host": "localhost", "from": "todo", "to": []string{"me@example.com"}},
+		{"kind": "email", "host": "localhost", "from": "todo@example.com"},
+	} {
+		ret, err := postJSON("api/notify/channel", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для %v", v)
+	}
+
+	webhook, webhookRequests := standInServer(t, http.StatusOK)
+	webhookID := addChannel(t, map[string]any{"kind": "webhook", "name": "hook", "url": webhook.URL + "/hook"})
+	ret := testChannel(t, webhookID)
+	assert.Empty(t, ret["error"])
+	req := <-webhookRequests
+	assert.Equal(t, "/hook", req.path)
+	var payload map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(req.body), &payload))
+	assert.Equal(t, "Test notification", payload["title"])
+
+	ntfy, ntfyRequests := standInServer(t, http.StatusOK)
+	ntfyID := addChannel(t, map[string]any{"kind": "push", "url": ntfy.URL + "/todo", "token": "secret"})
+	ret = testChannel(t, ntfyID)
+	assert.Empty(t, ret["error"])
+	req = <-ntfyRequests
+	assert.Equal(t, "/todo", req.path)
+	assert.Equal(t, "Test notification", req.header.Get("Title"))
+	assert.Equal(t, "Bearer secret", req.header.Get("Authorization"))
+
+	gotify, gotifyRequests := standInServer(t, http.StatusOK)
+	gotifyID := addChannel(t, map[string]any{"kind": "push", "format": "gotify", "url": gotify.URL, "token": "app"})
+	ret = testChannel(t, gotifyID)
+	assert.Empty(t, ret["error"])
+	req = <-gotifyRequests
+	assert.Equal(t, "/message", req.path)
+	assert.Equal(t, "app", req.header.Get("X-Gotify-Key"))
+
+	addr, messages := smtpStandIn(t)
+	host, port, _ := net.SplitHostPort(addr)
+	var portNumber int
+	fmt.Sscan(port, &portNumber)
+	emailID := addChannel(t, map[string]any{
+		"kind": "email",
+		"host": host,
+		"port": portNumber,
+		"from": "todo@example.com",
+		"to":   []string{"me@example.com"},
+	})
+	ret = testChannel(t, emailID)
+	assert.Empty(t, ret["error"])
+	message := <-messages
+	assert.Contains(t, message, "To: me@example.com")
+	assert.Contains(t, message, "Notifications from the task manager")
+
+	failing, _ := standInServer(t, http.StatusInternalServerError)
+	failingID := addChannel(t, map[string]any{"kind": "webhook", "url": failing.URL, "enabled": false})
+	ret = testChannel(t, failingID)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err := postJSON("api/notify/channel?id="+ntfyID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["token"])
+
+	ret, err = postJSON("api/notify/channels", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(ret["channels"].([]any)), 5)
+
+	ret, err = postJSON("api/notify/test?kind=webhook", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	for _, id := range []string{webhookID, ntfyID, gotifyID, emailID, failingID} {
+		ret, err = postJSON("api/notify/channel?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+	}
+
+	ret = testChannel(t, webhookID)
+	assert.NotEmpty(t, ret["error"])
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverdueTasks(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1).Format(`20060102`)
+
+	res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat)
+	VALUES (?, 'Просроченная задача', '', '')`, yesterday)
+	assert.NoError(t, err)
+	single, err := res.LastInsertId()
+	assert.NoError(t, err)
+
+	res, err = db.Exec(`INSERT INTO scheduler (date, title, comment, repeat)
+	VALUES (?, 'Просроченная повторяющаяся задача', '', 'd 7')`, yesterday)
+	assert.NoError(t, err)
+	repeating, err := res.LastInsertId()
+	assert.NoError(t, err)
+
+	ret, err := postJSON(fmt.Sprintf("api/task?id=%d", single), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, true, ret["overdue"])
+
+	ret, err = postJSON("api/tasks/overdue", nil, http.MethodGet)
+	assert.NoError(t, err)
+	ids := make(map[string]bool)
+	for _, item := range ret["tasks"].([]any) {
+		ids[item.(map[string]any)["id"].(string)] = true
+	}
+	assert.True(t, ids[fmt.Sprint(single)])
+	assert.True(t, ids[fmt.Sprint(repeating)])
+
+	ret, err = postJSON("api/tasks/rollover", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, ret["rolled"], float64(1))
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, single)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Format(`20060102`), task.Date)
+
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, repeating)
+	assert.NoError(t, err)
+	assert.Equal(t, yesterday, task.Date)
+
+	var rolled int
+	err = db.Get(&rolled, `SELECT count(id) FROM task_history WHERE task_id = ? AND event = 'rolled'`, single)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rolled)
+
+	ret, err = postJSON(fmt.Sprintf("api/task?id=%d", single), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["overdue"])
+
+	for _, id := range []int64{single, repeating} {
+		_, err = db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+		assert.NoError(t, err)
+	}
+}
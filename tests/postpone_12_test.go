@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postpone(t *testing.T, id string, to string) map[string]any {
+	ret, err := postJSON("api/task/postpone?id="+id, map[string]any{"to": to}, http.MethodPost)
+	assert.NoError(t, err)
+	return ret
+}
+
+func TestPostponeTask(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	id := addTask(t, task{
+		date:    today.Format(`20060102`),
+		title:   "Разобрать почту",
+		comment: "Входящие",
+	})
+
+	for _, v := range []string{"", "+0d", "+1y", "next holiday", "20000101", "31.12.2099"} {
+		ret := postpone(t, id, v)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для %q", v)
+	}
+
+	ret := postpone(t, id, "+1d")
+	assert.Equal(t, today.AddDate(0, 0, 1).Format(`20060102`), ret["date"])
+	assert.Equal(t, "Входящие", ret["comment"])
+
+	ret = postpone(t, id, "+1w")
+	assert.Equal(t, today.AddDate(0, 0, 8).Format(`20060102`), ret["date"])
+
+	ret = postpone(t, id, "next monday")
+	monday := today.AddDate(0, 0, 1)
+	for monday.Weekday() != time.Monday {
+		monday = monday.AddDate(0, 0, 1)
+	}
+	assert.Equal(t, monday.Format(`20060102`), ret["date"])
+
+	ret = postpone(t, id, "next thur")
+	thursday := today.AddDate(0, 0, 1)
+	for thursday.Weekday() != time.Thursday {
+		thursday = thursday.AddDate(0, 0, 1)
+	}
+	assert.Equal(t, thursday.Format(`20060102`), ret["date"])
+
+	date := today.AddDate(0, 0, 30).Format(`20060102`)
+	ret = postpone(t, id, date)
+	assert.Equal(t, date, ret["date"])
+
+	ret = postpone(t, "999999999", "+1d")
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err := postJSON("api/tasks/postponed?min=3", nil, http.MethodGet)
+	assert.NoError(t, err)
+	found := false
+	for _, item := range ret["tasks"].([]any) {
+		if m := item.(map[string]any); m["id"] == id {
+			found = true
+			assert.GreaterOrEqual(t, m["postponed"], float64(3))
+		}
+	}
+	assert.True(t, found)
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+}
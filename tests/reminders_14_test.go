@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReminders(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	id := addTask(t, task{
+		date:  now.Format(`20060102`),
+		title: "Позвонить врачу",
+	})
+
+	for _, v := range []map[string]any{
+		{"task_id": id, "time": "25:00"},
+		{"task_id": id, "time": "9.30"},
+		{"task_id": id, "time": "09:00", "before": -1},
+		{"task_id": id, "time": "09:00", "before": 20000},
+		{"task_id": "abc", "time": "09:00"},
+		{"task_id": "999999999", "time": "09:00"},
+	} {
+		ret, err := postJSON("api/reminder", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для %v", v)
+	}
+
+	ret, err := postJSON("api/reminder", map[string]any{
+		"task_id": id,
+		"time":    "23:59",
+		"before":  60,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	later := ret["id"]
+
+	ret, err = postJSON("api/reminder", map[string]any{
+		"task_id": id,
+		"time":    now.Format("15:04"),
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	due := ret["id"]
+
+	ret, err = postJSON("api/reminders?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	reminders := ret["reminders"].([]any)
+	assert.Len(t, reminders, 2)
+
+	var status string
+	deadline := time.Now().Add(70 * time.Second)
+	for time.Now().Before(deadline) {
+		err = db.Get(&status, `SELECT status FROM reminder_deliveries WHERE reminder_id = ?`, due)
+		if err == nil && status == "sent" {
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	assert.Equal(t, "sent", status, "Напоминание должно быть отправлено")
+
+	var pending int
+	err = db.Get(&pending, `SELECT count(*) FROM reminder_deliveries WHERE reminder_id = ?`, later)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+
+	ret, err = postJSON(fmt.Sprintf("api/reminder?id=%v", later), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+
+	ret, err = postJSON(fmt.Sprintf("api/reminder?id=%v", later), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+
+	var left int
+	err = db.Get(&left, `SELECT count(*) FROM reminders WHERE task_id = ?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, left)
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitTaskTitle(t *testing.T, title string) (string, string) {
+	db := openDB(t)
+	defer db.Close()
+
+	var found struct {
+		ID   int64  `db:"id"`
+		Date string `db:"date"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		err := db.Get(&found, `SELECT id, date FROM scheduler WHERE title = ?`, title)
+		if err == nil {
+			return fmt.Sprint(found.ID), found.Date
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("Задача %q не создана правилом", title)
+	return "", ""
+}
+
+func TestRules(t *testing.T) {
+	for _, v := range []map[string]any{
+		{"name": "", "trigger": "task.completed", "actions": []any{map[string]any{"type": "complete"}}},
+		{"name": "x", "trigger": "task.archived", "actions": []any{map[string]any{"type": "complete"}}},
+		{"name": "x", "trigger": "task.completed"},
+		{"name": "x", "trigger": "task.completed", "actions": []any{map[string]any{"type": "explode"}}},
+		{"name": "x", "trigger": "task.completed", "actions": []any{map[string]any{"type": "create_task"}}},
+		{"name": "x", "trigger": "task.completed", "actions": []any{map[string]any{"type": "set_priority", "priority": 9}}},
+		{"name": "x", "trigger": "task.completed", "actions": []any{map[string]any{"type": "postpone", "to": "soon"}}},
+		{"name": "x", "trigger": "task.completed", "actions": []any{map[string]any{"type": "complete"}},
+			"conditions": []any{map[string]any{"field": "color", "op": "eq", "value": "red"}}},
+		{"name": "x", "trigger": "task.completed", "actions": []any{map[string]any{"type": "complete"}},
+			"conditions": []any{map[string]any{"field": "postponed", "op": "gte", "value": "many"}}},
+	} {
+		ret, err := postJSON("api/rule", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для %v", v)
+	}
+
+	ret, err := postJSON("api/rule", map[string]any{
+		"name":       "Changelog после релиза",
+		"trigger":    "task.completed",
+		"conditions": []any{map[string]any{"field": "tag", "op": "eq", "value": "#release"}},
+		"actions": []any{map[string]any{
+			"type":  "create_task",
+			"title": "Changelog: {{title}}",
+			"date":  "+1d",
+			"tags":  []string{"docs"},
+		}},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	releaseRule := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/rule", map[string]any{
+		"name":       "Важно, если откладывали",
+		"trigger":    "task.rescheduled",
+		"conditions": []any{map[string]any{"field": "postponed", "op": "gte", "value": "3"}},
+		"actions":    []any{map[string]any{"type": "raise_priority"}},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	priorityRule := fmt.Sprint(ret["id"])
+
+	now := time.Now()
+	ret, err = postJSON("api/task", map[string]any{
+		"date":  now.Format(`20060102`),
+		"title": "Выпустить 3.1",
+		"tags":  []string{"release"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	releaseID := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/rule/dry-run?task_id="+releaseID, map[string]any{
+		"name":       "Проверка",
+		"trigger":    "task.completed",
+		"conditions": []any{map[string]any{"field": "tag", "op": "eq", "value": "release"}},
+		"actions":    []any{map[string]any{"type": "set_priority", "priority": 2}},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, true, ret["matched"])
+	assert.Equal(t, "release", ret["conditions"].([]any)[0].(map[string]any)["actual"])
+	assert.Equal(t, []any{"set priority 2"}, ret["actions"])
+
+	_, err = postJSON("api/task/done?id="+releaseID, nil, http.MethodPost)
+	assert.NoError(t, err)
+
+	changelogID, date := waitTaskTitle(t, "Changelog: Выпустить 3.1")
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), date)
+
+	ret, err = postJSON("api/rule/executions?id="+releaseRule, nil, http.MethodGet)
+	assert.NoError(t, err)
+	executions := ret["executions"].([]any)
+	if assert.Len(t, executions, 1) {
+		e := executions[0].(map[string]any)
+		assert.Equal(t, "success", e["status"])
+		assert.Equal(t, releaseID, e["task_id"])
+
+		// The outbox delivers at least once: an event delivered again must
+		// not run the rule again.
+		db := openDB(t)
+		var dispatched int
+		deadline := time.Now().Add(5 * time.Second)
+		for dispatched == 0 && time.Now().Before(deadline) {
+			err = db.Get(&dispatched, `SELECT count(id) FROM event_outbox WHERE id = ? AND dispatched_at IS NOT NULL`,
+				e["event_id"])
+			assert.NoError(t, err)
+			time.Sleep(50 * time.Millisecond)
+		}
+		_, err = db.Exec(`UPDATE event_outbox SET dispatched_at = NULL WHERE id = ?`, e["event_id"])
+		assert.NoError(t, err)
+		db.Close()
+	}
+
+	// Completing the created task must not trigger the rule again.
+	_, err = postJSON("api/task/done?id="+changelogID, nil, http.MethodPost)
+	assert.NoError(t, err)
+
+	id := addTask(t, task{date: now.Format(`20060102`), title: "Разобрать почту"})
+	for i := 0; i < 3; i++ {
+		postpone(t, id, "+1d")
+	}
+
+	var priority any
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		ret, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		if priority = ret["priority"]; priority != nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, float64(1), priority)
+
+	ret, err = postJSON("api/rule/executions?id="+priorityRule, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["executions"], 1)
+
+	ret, err = postJSON("api/rule/executions?id="+releaseRule, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["executions"], 1)
+	var changelogs int
+	db := openDB(t)
+	defer db.Close()
+	assert.NoError(t, db.Get(&changelogs, `SELECT count(id) FROM scheduler WHERE title = ?`, "Changelog: Выпустить 3.1"))
+	assert.Equal(t, 0, changelogs, "Выполненная задача не должна создаваться повторно")
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	for _, ruleID := range []string{releaseRule, priorityRule} {
+		ret, err = postJSON("api/rule?id="+ruleID, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+	}
+
+	ret, err = postJSON("api/rule/executions?id="+releaseRule, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getStats(t *testing.T, query string) (int, map[string]any) {
+	status, _, body := rawRequest(t, http.MethodGet, "api/stats"+query, "", nil)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(body), &m))
+	return status, m
+}
+
+func completeTask(t *testing.T, id string) {
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+}
+
+func TestStats(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	today := now.Format("20060102")
+	query := "?from=" + today + "&to=" + today
+	_, before := getStats(t, query)
+
+	ret, err := postJSON("api/task", map[string]any{"date": today, "title": "Зарядка", "repeat": "d 1"}, http.MethodPost)
+	assert.NoError(t, err)
+	habit := fmt.Sprint(ret["id"])
+	ret, err = postJSON("api/task", map[string]any{"date": today, "title": "Разовая для статистики"}, http.MethodPost)
+	assert.NoError(t, err)
+	once := fmt.Sprint(ret["id"])
+
+	// Two on-time completions, a late one and one more on time.
+	completeTask(t, habit)
+	completeTask(t, habit)
+	_, err = db.Exec("UPDATE scheduler SET date = ? WHERE id = ?", now.AddDate(0, 0, -1).Format("20060102"), habit)
+	assert.NoError(t, err)
+	completeTask(t, habit)
+	completeTask(t, habit)
+	completeTask(t, once)
+
+	var recorded int
+	assert.NoError(t, db.Get(&recorded,
+		"SELECT count(id) FROM task_history WHERE event = 'completed' AND task_id = ?", habit))
+	assert.Equal(t, 4, recorded)
+
+	postpone(t, habit, "+1d")
+	postpone(t, habit, "+1d")
+
+	status, ret := getStats(t, query)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, today, ret["from"])
+	assert.Equal(t, today, ret["to"])
+	assert.EqualValues(t, 5, ret["completed"].(float64)-before["completed"].(float64))
+	assert.EqualValues(t, 4, ret["on_time"].(float64)-before["on_time"].(float64))
+	assert.EqualValues(t, 1, ret["late"].(float64)-before["late"].(float64))
+
+	days := ret["days"].([]any)
+	assert.Len(t, days, 1)
+	assert.Equal(t, ret["completed"], days[0].(map[string]any)["completed"])
+	weeks := ret["weeks"].([]any)
+	assert.Len(t, weeks, 1)
+	assert.Equal(t, ret["completed"], weeks[0].(map[string]any)["completed"])
+
+	var streak map[string]any
+	for _, item := range ret["streaks"].([]any) {
+		if item.(map[string]any)["task_id"] == habit {
+			streak = item.(map[string]any)
+		}
+		assert.NotEqual(t, once, item.(map[string]any)["task_id"], "У разовой задачи нет серии")
+	}
+	if assert.NotNil(t, streak) {
+		assert.Equal(t, "d 1", streak["repeat"])
+		assert.EqualValues(t, 1, streak["current"])
+		assert.EqualValues(t, 2, streak["longest"])
+	}
+
+	postponed := map[string]any{}
+	for _, item := range ret["postponed"].([]any) {
+		if item.(map[string]any)["task_id"] == habit {
+			postponed = item.(map[string]any)
+		}
+	}
+	assert.Equal(t, "Зарядка", postponed["title"])
+	assert.EqualValues(t, 2, postponed["postponed"])
+
+	// A month by default.
+	status, ret = getStats(t, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, ret["days"], 30)
+	assert.Equal(t, today, ret["to"])
+
+	status, _ = getStats(t, "?from=20300102&to=20300101")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = getStats(t, "?from=20280101&to=20300101")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// The id of the task is reused by the next test, so wait until the events
+	// of this one are dispatched.
+	ch, cancel := openStream(t, "")
+	defer cancel()
+	ret, err = postJSON("api/task?id="+habit, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	for waitStreamEvent(t, ch, habit).event != "task.deleted" {
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type streamEvent struct {
+	id    string
+	event string
+	data  map[string]any
+}
+
+func openStream(t *testing.T, lastEventID string) (chan streamEvent, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, getURL("api/stream"), nil)
+	assert.NoError(t, err)
+	if len(lastEventID) > 0 {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		cancel()
+		t.FailNow()
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	ch := make(chan streamEvent, 16)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		e := streamEvent{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				e.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.data)
+			case len(line) == 0 && len(e.event) > 0:
+				ch <- e
+				e = streamEvent{}
+			}
+		}
+	}()
+	return ch, cancel
+}
+
+// waitStreamEvent skips events of other tasks, which earlier tests may still
+// be producing.
+func waitStreamEvent(t *testing.T, ch chan streamEvent, id string) streamEvent {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-ch:
+			if e.data["task"].(map[string]any)["id"] == id {
+				return e
+			}
+		case <-timeout:
+			t.Fatal("Событие не получено")
+			return streamEvent{}
+		}
+	}
+}
+
+func TestTaskStream(t *testing.T) {
+	resp, err := http.Get(getURL("api/stream?last_event_id=abc"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	ch, cancel := openStream(t, "")
+
+	now := time.Now()
+	id := addTask(t, task{date: now.Format(`20060102`), title: "Смотреть поток"})
+
+	e := waitStreamEvent(t, ch, id)
+	assert.Equal(t, "task.created", e.event)
+	assert.NotEmpty(t, e.id)
+	assert.Equal(t, id, e.data["task"].(map[string]any)["id"])
+	assert.Equal(t, "Смотреть поток", e.data["task"].(map[string]any)["title"])
+	cancel()
+
+	for _, title := range []string{"Смотреть поток 2", "Смотреть поток 3"} {
+		_, err = postJSON("api/task", map[string]any{
+			"id":    id,
+			"date":  now.Format(`20060102`),
+			"title": title,
+		}, http.MethodPut)
+		assert.NoError(t, err)
+	}
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+
+	// Wait for the events to be dispatched before resuming.
+	time.Sleep(500 * time.Millisecond)
+
+	ch, cancel = openStream(t, e.id)
+	defer cancel()
+
+	var names, titles []string
+	for i := 0; i < 3; i++ {
+		e = waitStreamEvent(t, ch, id)
+		names = append(names, e.event)
+		titles = append(titles, e.data["task"].(map[string]any)["title"].(string))
+	}
+	assert.Equal(t, []string{"task.updated", "task.updated", "task.deleted"}, names)
+	assert.Equal(t, []string{"Смотреть поток 2", "Смотреть поток 3", "Смотреть поток 3"}, titles)
+
+	id = addTask(t, task{date: now.Format(`20060102`), title: "Живое событие"})
+	e = waitStreamEvent(t, ch, id)
+	assert.Equal(t, "task.created", e.event)
+	assert.Equal(t, id, e.data["task"].(map[string]any)["id"])
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+}
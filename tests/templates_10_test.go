@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	name := fmt.Sprint("onboarding", time.Now().UnixNano())
+
+	tbl := []map[string]any{
+		{"name": "", "title": "Заголовок"},
+		{"name": name, "title": ""},
+		{"name": name, "title": "Заголовок", "repeat": "ooops"},
+		{"name": name, "title": "Заголовок", "tags": []string{"bad tag"}},
+	}
+	for _, v := range tbl {
+		ret, err := postJSON("api/template", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для шаблона %v", v)
+	}
+
+	ret, err := postJSON("api/template", map[string]any{
+		"name":      name,
+		"title":     "Встретить {{name}}",
+		"comment":   "Первый день {{date}}",
+		"checklist": []string{"Выдать ноутбук", "Познакомить с {{mentor}}"},
+		"tags":      []string{"onboarding"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/template", map[string]any{"name": name, "title": "Дубликат"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/template?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, name, ret["name"])
+	assert.Len(t, ret["checklist"], 2)
+
+	ret, err = postJSON("api/template/instantiate?id="+id, map[string]any{
+		"offset": "d 3",
+		"vars":   map[string]string{"name": "Анну", "mentor": "Олегом"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(ret["id"])
+
+	date := time.Now().AddDate(0, 0, 3)
+	task, err := postJSON("api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, date.Format(`20060102`), task["date"])
+	assert.Equal(t, "Встретить Анну", task["title"])
+	assert.Equal(t, "Первый день "+date.Format(`02.01.2006`)+
+		"\n\n- [ ] Выдать ноутбук\n- [ ] Познакомить с Олегом", task["comment"])
+	assert.Equal(t, []any{"onboarding"}, task["tags"])
+
+	ret, err = postJSON("api/template/instantiate?id="+id, map[string]any{"offset": "x"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/template?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	_, err = postJSON("api/task?id="+taskID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeTracking(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := postJSON("api/time/stop", nil, http.MethodPost)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     now.Format(`20060102`),
+		"title":    "Подготовить отчёт",
+		"estimate": 90,
+		"project":  "reports",
+		"tags":     []string{"#work", "writing", "work"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	body, err := postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(90), body["estimate"])
+	assert.Equal(t, "reports", body["project"])
+	assert.Equal(t, []any{"work", "writing"}, body["tags"])
+
+	ret, err = postJSON("api/task", map[string]any{
+		"title":    "Отрицательная оценка",
+		"estimate": -5,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/time/start?id="+id, map[string]any{"note": "черновик"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, id, ret["task_id"])
+	assert.Equal(t, true, ret["running"])
+	assert.Equal(t, "черновик", ret["note"])
+
+	ret, err = postJSON("api/time/start?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"], "Ожидается ошибка при запуске второго таймера")
+
+	ret, err = postJSON("api/time", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, id, ret["task_id"])
+
+	time.Sleep(1100 * time.Millisecond)
+	ret, err = postJSON("api/time/stop", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, false, ret["running"])
+	assert.NotEmpty(t, ret["stop"])
+
+	ret, err = postJSON("api/time/stop", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/time/entries?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["entries"], 1)
+
+	today := now.Format(`20060102`)
+	ret, err = postJSON("api/time/report?from="+today+"&to="+today, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, today, ret["from"])
+	tasks, ok := ret["tasks"].([]any)
+	assert.True(t, ok)
+	found := false
+	for _, item := range tasks {
+		if m, ok := item.(map[string]any); ok && m["key"] == id {
+			found = true
+			assert.Equal(t, float64(90), m["estimate"])
+			assert.GreaterOrEqual(t, m["duration"], float64(1))
+		}
+	}
+	assert.True(t, found)
+
+	ret, err = postJSON("api/time/report?from=20240201&to=20240101", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+}
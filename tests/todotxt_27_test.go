@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const importTodoTxtFile = "(A) 2024-01-01 Позвонить маме +Семья @телефон due:2030-02-10 rec:+1w\n" +
+	"x 2024-01-02 2024-01-01 Уже сделано\n" +
+	"\n" +
+	"Неверная дата due:2030-02-30\n" +
+	"Оплатить связь rec:1m due:2030-03-15 est:15\n"
+
+func importTodoTxt(t *testing.T, query string, body string) map[string]any {
+	return importFile(t, "api/import/todotxt"+query, body)
+}
+
+func TestTodoTxt(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     "20300105",
+		"title":    "Экспорт todo.txt",
+		"comment":  "Скидка 50%\nдо пятницы",
+		"repeat":   "w 1,4",
+		"project":  "Дом и сад",
+		"priority": 3,
+		"estimate": 45,
+		"tags":     []string{"todotxt"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	exportID := fmt.Sprint(ret["id"])
+
+	status, header, body := rawRequest(t, http.MethodGet, "api/tasks?format=todotxt&search="+
+		url.QueryEscape("Экспорт todo.txt"), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, header.Get("Content-Type"), "text/plain")
+	assert.Equal(t, "(A) Экспорт todo.txt +Дом%20и%20сад @todotxt due:2030-01-05 rec:w_1,4 est:45 "+
+		"note:Скидка%2050%25%0Aдо%20пятницы\n", body)
+
+	// The export imports back as the same task.
+	ret = importTodoTxt(t, "?dry_run=true", body)
+	assert.Empty(t, ret["error"])
+	assert.EqualValues(t, 1, ret["imported"])
+	task := ret["items"].([]any)[0].(map[string]any)["task"].(map[string]any)
+	assert.Equal(t, "Экспорт todo.txt", task["title"])
+	assert.Equal(t, "Скидка 50%\nдо пятницы", task["comment"])
+	assert.Equal(t, "w 1,4", task["repeat"])
+	assert.Equal(t, "Дом и сад", task["project"])
+	assert.EqualValues(t, 3, task["priority"])
+	assert.EqualValues(t, 45, task["estimate"])
+
+	ret, err = postJSON("api/task?id="+exportID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	// Title words that look like todo.txt markup are escaped on export.
+	for idx, title := range []string{
+		"x +дача @телефон due:завтра rec:1d field.цвет:синий скидка 50% %41",
+		"(A) сначала важное",
+		"2024-01-01 старт проекта note:черновик",
+		"два  пробела\tи табуляция",
+	} {
+		comment := fmt.Sprintf("todotxt-roundtrip-%d", idx)
+		ret, err := postJSON("api/task", map[string]any{
+			"date": "20300105", "title": title, "comment": comment,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		id := fmt.Sprint(ret["id"])
+
+		status, _, body := rawRequest(t, http.MethodGet, "api/tasks?format=todotxt&search="+comment, "", nil)
+		assert.Equal(t, http.StatusOK, status)
+		ret = importTodoTxt(t, "?dry_run=true", body)
+		assert.Empty(t, ret["error"])
+		assert.EqualValues(t, 1, ret["imported"], body)
+		item := ret["items"].([]any)[0].(map[string]any)
+		task := item["task"].(map[string]any)
+		assert.Equal(t, title, task["title"], body)
+		assert.Equal(t, "20300105", task["date"])
+		assert.Nil(t, task["project"])
+		assert.Nil(t, task["tags"])
+		assert.Nil(t, task["priority"])
+
+		ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+
+	before, err := count(db)
+	assert.NoError(t, err)
+
+	ret = importTodoTxt(t, "?dry_run=true", importTodoTxtFile)
+	assert.Empty(t, ret["error"])
+	assert.EqualValues(t, 2, ret["imported"])
+	assert.EqualValues(t, 1, ret["skipped"])
+	assert.Equal(t, []any{4.0}, ret["failed_lines"])
+	first := ret["items"].([]any)[0].(map[string]any)["task"].(map[string]any)
+	assert.Equal(t, "Позвонить маме", first["title"])
+	assert.Equal(t, "Семья", first["project"])
+	assert.Equal(t, []any{"телефон"}, first["tags"])
+	assert.Equal(t, "d 7", first["repeat"])
+	assert.Equal(t, "20300210", first["date"])
+
+	after, err := count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after, "Пробный импорт не должен добавлять задачи")
+
+	ret = importTodoTxt(t, "?dry_run=true", "Старый отчёт due:2020-01-10\n")
+	assert.EqualValues(t, 1, ret["imported"])
+	task = ret["items"].([]any)[0].(map[string]any)["task"].(map[string]any)
+	assert.Equal(t, "20200110", task["date"], "Импорт сохраняет дату просроченной задачи")
+
+	ret = importTodoTxt(t, "", importTodoTxtFile)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, true, ret["committed"])
+
+	after, err = count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before+2, after)
+
+	// Ids of the tasks are reused by the next test, so wait until the events
+	// of this one are dispatched.
+	ch, cancel := openStream(t, "")
+	defer cancel()
+	var last string
+	for _, item := range ret["items"].([]any) {
+		task, ok := item.(map[string]any)["task"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if task["title"] == "Оплатить связь" {
+			assert.Equal(t, "m 15", task["repeat"])
+		}
+		last = fmt.Sprint(task["id"])
+		ret, err := postJSON("api/task?id="+last, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+	for waitStreamEvent(t, ch, last).event != "task.deleted" {
+	}
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitRequest(t *testing.T, requests chan received) received {
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook не получен")
+		return received{}
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	for _, v := range []map[string]any{
+		{"url": "localhost/hook"},
+		{"url": "http://localhost/hook", "events": []string{"task.archived"}},
+	} {
+		ret, err := postJSON("api/webhook", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "Ожидается ошибка для %v", v)
+	}
+
+	hook, requests := standInServer(t, http.StatusOK)
+	ret, err := postJSON("api/webhook", map[string]any{
+		"url":    hook.URL,
+		"secret": "s3cret",
+		"events": []string{"task.created", "task.rescheduled", "task.deleted"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	hookID := fmt.Sprint(ret["id"])
+
+	var calls int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer flaky.Close()
+	ret, err = postJSON("api/webhook", map[string]any{
+		"url":    flaky.URL,
+		"events": []string{"task.created"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	flakyID := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/webhook?id="+flakyID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["secret"], 64)
+
+	now := time.Now()
+	id := addTask(t, task{date: now.Format(`20060102`), title: "Собрать релиз"})
+
+	req := waitRequest(t, requests)
+	assert.Equal(t, "task.created", req.header.Get("X-Webhook-Event"))
+	createdEventID := req.header.Get("X-Webhook-Event-ID")
+	assert.NotEmpty(t, createdEventID)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(req.body))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.header.Get("X-Webhook-Signature"))
+	var payload map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(req.body), &payload))
+	assert.Equal(t, "task.created", payload["event"])
+	assert.Equal(t, id, payload["task"].(map[string]any)["id"])
+
+	_, err = postJSON("api/task", map[string]any{
+		"id":    id,
+		"date":  now.Format(`20060102`),
+		"title": "Собрать релиз 2.0",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+
+	postpone(t, id, "+1d")
+	req = waitRequest(t, requests)
+	assert.Equal(t, "task.rescheduled", req.header.Get("X-Webhook-Event"))
+	assert.NoError(t, json.Unmarshal([]byte(req.body), &payload))
+	assert.Equal(t, "Собрать релиз 2.0", payload["task"].(map[string]any)["title"])
+
+	_, err = postJSON("api/task", map[string]any{
+		"id":    id,
+		"date":  now.AddDate(0, 0, 3).Format(`20060102`),
+		"title": "Собрать релиз 2.0",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	req = waitRequest(t, requests)
+	assert.Equal(t, "task.rescheduled", req.header.Get("X-Webhook-Event"))
+	payload = nil
+	assert.NoError(t, json.Unmarshal([]byte(req.body), &payload))
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), payload["from"])
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	req = waitRequest(t, requests)
+	assert.Equal(t, "task.deleted", req.header.Get("X-Webhook-Event"))
+
+	var delivery map[string]any
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		ret, err = postJSON("api/webhook/deliveries?id="+flakyID, nil, http.MethodGet)
+		assert.NoError(t, err)
+		if list := ret["deliveries"].([]any); len(list) == 1 {
+			delivery = list[0].(map[string]any)
+			if delivery["status"] == "sent" {
+				break
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	assert.Equal(t, "sent", delivery["status"], "Доставка должна быть повторена")
+	assert.Equal(t, float64(2), delivery["attempts"])
+	assert.Equal(t, float64(http.StatusNoContent), delivery["response_status"])
+
+	ret, err = postJSON("api/webhook/deliveries?id="+hookID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	deliveries := ret["deliveries"].([]any)
+	assert.Len(t, deliveries, 4)
+	for _, d := range deliveries {
+		assert.Equal(t, "sent", d.(map[string]any)["status"])
+	}
+	assert.Equal(t, createdEventID, deliveries[3].(map[string]any)["event_id"])
+
+	for _, id := range []string{hookID, flakyID} {
+		ret, err = postJSON("api/webhook?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+	}
+
+	ret, err = postJSON("api/webhook/deliveries?id="+hookID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}